@@ -0,0 +1,128 @@
+// File: batchfile/batch.go
+
+// Package batchfile reads transfer batches delivered as files and writes
+// back what happened to each transfer. Two formats are understood: CSV with
+// a header row, and a fixed-width record layout modelled on NACHA files.
+package batchfile
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"transfer-service/models"
+)
+
+type Format string
+
+const (
+	FormatCSV        Format = "csv"
+	FormatFixedWidth Format = "fixed"
+)
+
+// DetectFormat picks the format from the file extension: .csv is CSV,
+// anything else is fixed width
+func DetectFormat(path string) Format {
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		return FormatCSV
+	}
+	return FormatFixedWidth
+}
+
+// RowError is a problem with one row. The rest of the file is still read so
+// that every bad row is reported at once.
+type RowError struct {
+	Line      int    // 1-based line in the file
+	RequestId string // empty if the row's own request id is unreadable
+	Field     string
+	Code      string
+	Message   string
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Message)
+}
+
+// Batch is a parsed file. Requests holds the valid rows in file order and
+// Lines the line each came from; Errors holds the rows that were rejected.
+// Id is the batch id from a fixed-width header; CSV files have none.
+type Batch struct {
+	Id       string
+	Requests []models.TransferRequest
+	Lines    []int
+	Errors   []RowError
+
+	seen map[string]int // request id -> first line
+}
+
+// Parse reads a whole batch. It only fails for problems with the file as a
+// whole, such as a missing header or a trailer that does not match the
+// records; bad rows end up in Batch.Errors.
+func Parse(r io.Reader, format Format) (*Batch, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatFixedWidth:
+		return parseFixedWidth(r)
+	}
+	return nil, fmt.Errorf("batchfile: unsupported format %q", format)
+}
+
+// add records a row, or its first error. Rows must be added in file order so
+// that a repeated request id is blamed on its later use; the results file is
+// keyed by request id, so each may appear only once.
+func (b *Batch) add(line int, req models.TransferRequest, err *RowError) {
+	if first, ok := b.seen[req.RequestId]; ok && err == nil {
+		err = &RowError{
+			Field:   "request_id",
+			Code:    "DUPLICATE_REQUEST_ID",
+			Message: fmt.Sprintf("request id %s already used on line %d", req.RequestId, first),
+		}
+	}
+	if req.RequestId != "" {
+		if _, ok := b.seen[req.RequestId]; !ok {
+			if b.seen == nil {
+				b.seen = make(map[string]int)
+			}
+			b.seen[req.RequestId] = line
+		}
+	}
+	if err != nil {
+		err.Line, err.RequestId = line, req.RequestId
+		b.Errors = append(b.Errors, *err)
+		return
+	}
+	b.Requests = append(b.Requests, req)
+	b.Lines = append(b.Lines, line)
+}
+
+// buildRequest validates the fields every format shares. amount parses the
+// amount once the currency is known.
+func buildRequest(requestId, from, to, currency string, amount func(currency string) (models.Money, error)) (models.TransferRequest, *RowError) {
+	req := models.TransferRequest{RequestId: requestId, FromAccountId: from, ToAccountId: to}
+	for _, f := range []struct{ name, value string }{
+		{"request_id", requestId}, {"from_account_id", from}, {"to_account_id", to},
+	} {
+		if f.value == "" {
+			return req, &RowError{Field: f.name, Code: "MISSING_FIELD", Message: "is required"}
+		}
+	}
+	if from == to {
+		return req, &RowError{Field: "to_account_id", Code: "SAME_ACCOUNT_TRANSFER", Message: "must differ from from_account_id"}
+	}
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if _, ok := models.CurrencyExponent(currency); !ok {
+		return req, &RowError{Field: "currency", Code: "UNKNOWN_CURRENCY", Message: fmt.Sprintf("unknown currency %q", currency)}
+	}
+	amt, err := amount(currency)
+	if err != nil {
+		return req, &RowError{Field: "amount", Code: models.ErrorCode(err), Message: err.Error()}
+	}
+	if !amt.IsPositive() {
+		return req, &RowError{Field: "amount", Code: "INVALID_AMOUNT", Message: "must be positive"}
+	}
+	req.Amount = amt
+	return req, nil
+}
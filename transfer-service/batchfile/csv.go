@@ -0,0 +1,78 @@
+// File: batchfile/csv.go
+package batchfile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"transfer-service/models"
+)
+
+// CSV batches start with a header naming the columns, in any order:
+//
+//	request_id,from_account_id,to_account_id,amount,currency
+//	PAY-0001,1,2,150.00,INR
+//
+// amount is a decimal string in major units; the currency column is optional
+// and defaults to INR.
+var csvRequired = []string{"request_id", "from_account_id", "to_account_id", "amount"}
+
+func parseCSV(r io.Reader) (*Batch, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("batchfile: empty CSV file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("batchfile: header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvRequired {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("batchfile: header is missing column %q", name)
+		}
+	}
+
+	b := &Batch{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return b, nil
+		}
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			b.add(pe.Line, models.TransferRequest{}, &RowError{Field: "row", Code: "MALFORMED_ROW", Message: pe.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("batchfile: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(header) {
+			b.add(line, models.TransferRequest{}, &RowError{
+				Field:   "row",
+				Code:    "MALFORMED_ROW",
+				Message: fmt.Sprintf("has %d fields, header has %d", len(record), len(header)),
+			})
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		req, rowErr := buildRequest(field("request_id"), field("from_account_id"), field("to_account_id"), field("currency"),
+			func(currency string) (models.Money, error) { return models.ParseMoney(field("amount"), currency) })
+		b.add(line, req, rowErr)
+	}
+}
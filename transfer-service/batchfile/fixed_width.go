@@ -0,0 +1,167 @@
+// File: batchfile/fixed_width.go
+package batchfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"transfer-service/models"
+)
+
+// Fixed-width batches follow the NACHA shape of one header, detail records
+// and a trailer that checks them. Columns are 1-based and inclusive; text is
+// left-aligned and space-padded, numbers are zero-padded.
+//
+//	header   1      record type "1"
+//	         2-21   batch id
+//	         22-29  creation date, YYYYMMDD
+//	detail   1      record type "6"
+//	         2-21   request id
+//	         22-36  from account id
+//	         37-51  to account id
+//	         52-54  currency
+//	         55-69  amount in minor units (paise for INR)
+//	trailer  1      record type "9"
+//	         2-7    number of detail records
+//	         8-25   sum of the amount fields
+//
+// Trailing spaces may be trimmed from any line. Blank lines are ignored.
+type span struct{ start, end int }
+
+var (
+	headerBatchId = span{2, 21}
+	headerDate    = span{22, 29}
+
+	detailRequestId = span{2, 21}
+	detailFrom      = span{22, 36}
+	detailTo        = span{37, 51}
+	detailCurrency  = span{52, 54}
+	detailAmount    = span{55, 69}
+
+	trailerCount = span{2, 7}
+	trailerTotal = span{8, 25}
+)
+
+const (
+	headerWidth  = 29
+	detailWidth  = 69
+	trailerWidth = 25
+)
+
+// field cuts a span out of a line already padded to its record's width
+func (s span) field(line string) string {
+	return strings.TrimSpace(line[s.start-1 : s.end])
+}
+
+func parseFixedWidth(r io.Reader) (*Batch, error) {
+	var (
+		b       = &Batch{}
+		lineNo  int
+		header  bool
+		trailer bool
+		details int
+		total   uint64
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		lineNo++
+		raw := strings.TrimRight(sc.Text(), " \r")
+		if raw == "" {
+			continue
+		}
+		if trailer {
+			return nil, fmt.Errorf("batchfile: line %d: record after the trailer", lineNo)
+		}
+
+		switch raw[0] {
+		case '1':
+			if header {
+				return nil, fmt.Errorf("batchfile: line %d: second header record", lineNo)
+			}
+			line, err := pad(raw, headerWidth, lineNo)
+			if err != nil {
+				return nil, err
+			}
+			if headerBatchId.field(line) == "" {
+				return nil, fmt.Errorf("batchfile: line %d: header has no batch id", lineNo)
+			}
+			if _, err := time.Parse("20060102", headerDate.field(line)); err != nil {
+				return nil, fmt.Errorf("batchfile: line %d: header date %q is not YYYYMMDD", lineNo, headerDate.field(line))
+			}
+			b.Id = headerBatchId.field(line)
+			header = true
+
+		case '6':
+			if !header {
+				return nil, fmt.Errorf("batchfile: line %d: detail record before the header", lineNo)
+			}
+			details++
+			if len(raw) > detailWidth {
+				b.add(lineNo, models.TransferRequest{}, &RowError{
+					Field:   "row",
+					Code:    "MALFORMED_ROW",
+					Message: fmt.Sprintf("is %d characters, detail records are %d", len(raw), detailWidth),
+				})
+				continue
+			}
+			line := raw + strings.Repeat(" ", detailWidth-len(raw))
+			digits := detailAmount.field(line)
+			minor, perr := strconv.ParseUint(digits, 10, 63)
+			if perr == nil {
+				total += minor
+			}
+			req, rowErr := buildRequest(detailRequestId.field(line), detailFrom.field(line), detailTo.field(line), detailCurrency.field(line),
+				func(currency string) (models.Money, error) {
+					if perr != nil || len(digits) != detailAmount.end-detailAmount.start+1 {
+						return models.Money{}, models.NewMalformedAmountError(digits)
+					}
+					return models.NewMoney(int64(minor), currency), nil
+				})
+			b.add(lineNo, req, rowErr)
+
+		case '9':
+			if !header {
+				return nil, fmt.Errorf("batchfile: line %d: trailer before the header", lineNo)
+			}
+			line, err := pad(raw, trailerWidth, lineNo)
+			if err != nil {
+				return nil, err
+			}
+			if count, err := strconv.Atoi(trailerCount.field(line)); err != nil || count != details {
+				return nil, fmt.Errorf("batchfile: line %d: trailer counts %q detail records, file has %d", lineNo, trailerCount.field(line), details)
+			}
+			if sum, err := strconv.ParseUint(trailerTotal.field(line), 10, 64); err != nil || sum != total {
+				return nil, fmt.Errorf("batchfile: line %d: trailer total %q does not match the detail amounts (%d)", lineNo, trailerTotal.field(line), total)
+			}
+			trailer = true
+
+		default:
+			if !header {
+				return nil, fmt.Errorf("batchfile: line %d: file does not start with a header record", lineNo)
+			}
+			details++
+			b.add(lineNo, models.TransferRequest{}, &RowError{Field: "record_type", Code: "MALFORMED_ROW", Message: fmt.Sprintf("unknown record type %q", raw[0])})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("batchfile: %w", err)
+	}
+	if !header {
+		return nil, fmt.Errorf("batchfile: no header record")
+	}
+	if !trailer {
+		return nil, fmt.Errorf("batchfile: no trailer record")
+	}
+	return b, nil
+}
+
+// pad right-pads a header or trailer line to its width
+func pad(raw string, width, lineNo int) (string, error) {
+	if len(raw) > width {
+		return "", fmt.Errorf("batchfile: line %d: record is %d characters, expected %d", lineNo, len(raw), width)
+	}
+	return raw + strings.Repeat(" ", width-len(raw)), nil
+}
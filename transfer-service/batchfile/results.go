@@ -0,0 +1,74 @@
+// File: batchfile/results.go
+package batchfile
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"transfer-service/models"
+)
+
+type Status string
+
+const (
+	StatusCompleted Status = "COMPLETED" // the transfer went through
+	StatusFailed    Status = "FAILED"    // the service rejected, or in a dry run would reject, the transfer
+	StatusRejected  Status = "REJECTED"  // the row was invalid and never ran
+	StatusValid     Status = "VALID"     // dry run: the transfer would be attempted
+)
+
+// Result is one line of the results file
+type Result struct {
+	Line       int
+	RequestId  string
+	TransferId string
+	Status     Status
+	ErrorCode  string
+	Message    string
+}
+
+// Rejected turns the batch's row errors into results
+func (b *Batch) Rejected() []Result {
+	results := make([]Result, len(b.Errors))
+	for i, e := range b.Errors {
+		results[i] = Result{Line: e.Line, RequestId: e.RequestId, Status: StatusRejected, ErrorCode: e.Code, Message: e.Field + ": " + e.Message}
+	}
+	return results
+}
+
+// Outcome builds the result of running the request at position i
+func (b *Batch) Outcome(i int, r models.TransferResult) Result {
+	result := Result{Line: b.Lines[i], RequestId: b.Requests[i].RequestId, TransferId: r.TransferId, Status: StatusCompleted}
+	if r.Error != nil {
+		result.Status, result.ErrorCode, result.Message = StatusFailed, models.ErrorCode(r.Error), r.Error.Error()
+	}
+	return result
+}
+
+// Checked builds the dry-run result of the request at position i, where err
+// is what the transfer would fail with
+func (b *Batch) Checked(i int, err error) Result {
+	result := b.Outcome(i, models.TransferResult{Error: err})
+	if err == nil {
+		result.Status = StatusValid
+	}
+	return result
+}
+
+var resultsCSVHeader = []string{"line", "request_id", "transfer_id", "status", "error_code", "message"}
+
+// WriteResults writes the results as CSV
+func WriteResults(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(resultsCSVHeader); err != nil {
+		return err
+	}
+	for _, r := range results {
+		row := []string{strconv.Itoa(r.Line), r.RequestId, r.TransferId, string(r.Status), r.ErrorCode, r.Message}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,118 @@
+// File: events/bus.go
+package events
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Bus hands every published event to every registered listener, in
+// publication order. A synchronous bus delivers on the publisher's goroutine
+// before Publish returns; an asynchronous one queues the event for a single
+// delivery goroutine so that slow listeners do not hold up transfers.
+type Bus struct {
+	listeners []Listener
+	mutex     sync.RWMutex
+
+	queue  chan Event // nil for a synchronous bus
+	done   chan struct{}
+	send   sync.RWMutex // held for reading while queueing, for writing while closing
+	closed bool
+}
+
+type BusOption func(*Bus)
+
+// WithAsync delivers events from a background goroutine, queueing up to
+// buffer of them before Publish blocks
+func WithAsync(buffer int) BusOption {
+	return func(b *Bus) { b.queue = make(chan Event, buffer) }
+}
+
+func NewBus(opts ...BusOption) *Bus {
+	b := &Bus{}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.queue != nil {
+		b.done = make(chan struct{})
+		go b.dispatch()
+	}
+	return b
+}
+
+func (b *Bus) RegisterListener(listener Listener) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.listeners = append(b.listeners, listener)
+}
+
+func (b *Bus) UnregisterListener(listener Listener) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i, l := range b.listeners {
+		if l == listener {
+			b.listeners = append(b.listeners[:i:i], b.listeners[i+1:]...)
+			break
+		}
+	}
+}
+
+// Publish delivers e, or queues it on an asynchronous bus. Events published
+// after Close are dropped.
+func (b *Bus) Publish(e Event) {
+	if b.queue == nil {
+		b.notify(e)
+		return
+	}
+	b.send.RLock()
+	defer b.send.RUnlock()
+	if b.closed {
+		fmt.Printf("[EVENTS] bus closed, dropping %s\n", e.EventType())
+		return
+	}
+	b.queue <- e
+}
+
+// Close delivers every queued event and stops the delivery goroutine. It is
+// a no-op on a synchronous bus.
+func (b *Bus) Close() {
+	if b.queue == nil {
+		return
+	}
+	b.send.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.send.Unlock()
+	<-b.done
+}
+
+func (b *Bus) dispatch() {
+	defer close(b.done)
+	for e := range b.queue {
+		b.notify(e)
+	}
+}
+
+// notify delivers e to a snapshot of the listeners, so a listener may
+// (un)register others without deadlocking
+func (b *Bus) notify(e Event) {
+	b.mutex.RLock()
+	listeners := append([]Listener(nil), b.listeners...)
+	b.mutex.RUnlock()
+	for _, l := range listeners {
+		safeDeliver(l, e)
+	}
+}
+
+// safeDeliver keeps one failing listener from taking down the publisher or
+// starving the others
+func safeDeliver(l Listener, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("[EVENTS] listener %T panicked on %s: %v\n", l, e.EventType(), r)
+		}
+	}()
+	deliver(l, e)
+}
@@ -0,0 +1,123 @@
+// File: events/event.go
+
+// Package events publishes what happens to transfers and accounts to
+// subscribers elsewhere in the process. Subscribers implement Listener and
+// register with a Bus, the way DoorListeners register with a Door; the
+// transfer service releases events through an outbox so that none is ever
+// published for a change that was rolled back.
+package events
+
+import (
+	"time"
+	"transfer-service/models"
+)
+
+type Type string
+
+const (
+	TypeTransferInitiated     Type = "TransferInitiated"
+	TypeTransferCompleted     Type = "TransferCompleted"
+	TypeTransferFailed        Type = "TransferFailed"
+	TypeAccountBalanceChanged Type = "AccountBalanceChanged"
+)
+
+// Meta is carried by every event
+type Meta struct {
+	Sequence   int64     `json:"sequence,omitempty"` // outbox position, set on publication
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+func (m Meta) EventMeta() Meta { return m }
+
+func (m *Meta) setSequence(seq int64) { m.Sequence = seq }
+
+// Event is one of TransferInitiated, TransferCompleted, TransferFailed or
+// AccountBalanceChanged
+type Event interface {
+	EventType() Type
+	EventMeta() Meta
+}
+
+// TransferInitiated is published when a transfer request is accepted for
+// processing, before anything is checked
+type TransferInitiated struct {
+	Meta
+	Reference     string       `json:"reference"` // the RequestId, or from->to without one
+	RequestId     string       `json:"requestId,omitempty"`
+	FromAccountId string       `json:"fromAccountId"`
+	ToAccountId   string       `json:"toAccountId"`
+	Amount        models.Money `json:"amount"`
+}
+
+// TransferCompleted is published once a transfer's balances are stored.
+// Credit is what the recipient received, which differs from Amount only for
+// a cross-currency transfer; Fee was charged to the sender on top of Amount.
+type TransferCompleted struct {
+	Meta
+	Reference     string       `json:"reference"`
+	RequestId     string       `json:"requestId,omitempty"`
+	FromAccountId string       `json:"fromAccountId"`
+	ToAccountId   string       `json:"toAccountId"`
+	Amount        models.Money `json:"amount"`
+	Credit        models.Money `json:"credit"`
+	Fee           models.Money `json:"fee"`
+}
+
+// TransferFailed is published when a transfer ends without moving money
+type TransferFailed struct {
+	Meta
+	Reference     string       `json:"reference"`
+	RequestId     string       `json:"requestId,omitempty"`
+	FromAccountId string       `json:"fromAccountId"`
+	ToAccountId   string       `json:"toAccountId"`
+	Amount        models.Money `json:"amount"`
+	ErrorCode     string       `json:"errorCode"`
+	Message       string       `json:"message"`
+}
+
+// AccountBalanceChanged is published for every account a completed transfer
+// touched. Balance is the balance right after the transfer was applied.
+type AccountBalanceChanged struct {
+	Meta
+	AccountId string       `json:"accountId"`
+	Reference string       `json:"reference"`
+	Delta     models.Money `json:"delta"`
+	Balance   models.Money `json:"balance"`
+}
+
+func (TransferInitiated) EventType() Type     { return TypeTransferInitiated }
+func (TransferCompleted) EventType() Type     { return TypeTransferCompleted }
+func (TransferFailed) EventType() Type        { return TypeTransferFailed }
+func (AccountBalanceChanged) EventType() Type { return TypeAccountBalanceChanged }
+
+// Listener is a subscriber. Each method is called once for every published
+// event of its type.
+type Listener interface {
+	OnTransferInitiated(e TransferInitiated)
+	OnTransferCompleted(e TransferCompleted)
+	OnTransferFailed(e TransferFailed)
+	OnAccountBalanceChanged(e AccountBalanceChanged)
+}
+
+// NopListener ignores every event; embed it in a subscriber that only cares
+// about some of them
+type NopListener struct{}
+
+func (NopListener) OnTransferInitiated(TransferInitiated)         {}
+func (NopListener) OnTransferCompleted(TransferCompleted)         {}
+func (NopListener) OnTransferFailed(TransferFailed)               {}
+func (NopListener) OnAccountBalanceChanged(AccountBalanceChanged) {}
+
+// deliver calls the listener method matching the event's type
+func deliver(l Listener, e Event) {
+	switch e := e.(type) {
+	case TransferInitiated:
+		l.OnTransferInitiated(e)
+	case TransferCompleted:
+		l.OnTransferCompleted(e)
+	case TransferFailed:
+		l.OnTransferFailed(e)
+	case AccountBalanceChanged:
+		l.OnAccountBalanceChanged(e)
+	}
+}
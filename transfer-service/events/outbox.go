@@ -0,0 +1,122 @@
+// File: events/outbox.go
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"transfer-service/repository"
+)
+
+// NewRecord encodes e for an outbox
+func NewRecord(e Event) (*repository.OutboxRecord, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("events: encode %s: %w", e.EventType(), err)
+	}
+	return &repository.OutboxRecord{Type: string(e.EventType()), Payload: payload, CreatedAt: e.EventMeta().OccurredAt}, nil
+}
+
+// Decode turns an outbox record back into its event, stamped with the
+// record's sequence if it has one
+func Decode(rec *repository.OutboxRecord) (Event, error) {
+	switch Type(rec.Type) {
+	case TypeTransferInitiated:
+		return decode[TransferInitiated](rec)
+	case TypeTransferCompleted:
+		return decode[TransferCompleted](rec)
+	case TypeTransferFailed:
+		return decode[TransferFailed](rec)
+	case TypeAccountBalanceChanged:
+		return decode[AccountBalanceChanged](rec)
+	}
+	return nil, fmt.Errorf("events: unknown event type %q", rec.Type)
+}
+
+func decode[T Event](rec *repository.OutboxRecord) (Event, error) {
+	var e T
+	if err := json.Unmarshal(rec.Payload, &e); err != nil {
+		return nil, fmt.Errorf("events: decode %s %d: %w", rec.Type, rec.Sequence, err)
+	}
+	if rec.Sequence != 0 {
+		any(&e).(interface{ setSequence(int64) }).setSequence(rec.Sequence)
+	}
+	return e, nil
+}
+
+// relayBatch bounds how many records one Flush reads at a time
+const relayBatch = 100
+
+// Relay moves events from an outbox onto a bus. An event is marked published
+// only after the bus has taken it, so a crash in between publishes it again:
+// delivery is at least once, and listeners can use Sequence to drop repeats.
+type Relay struct {
+	outbox repository.Outbox
+	bus    *Bus
+	wake   chan struct{} // a pending nudge for Run
+	mutex  sync.Mutex    // one flush at a time keeps publication in order
+}
+
+func NewRelay(outbox repository.Outbox, bus *Bus) *Relay {
+	return &Relay{outbox: outbox, bus: bus, wake: make(chan struct{}, 1)}
+}
+
+// Nudge asks Run to flush now rather than at its next tick. It never blocks:
+// nudges arriving while one is pending fold into it.
+func (r *Relay) Nudge() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Flush publishes every pending event in sequence order and returns how
+// many it published
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	published := 0
+	for {
+		records, err := r.outbox.Pending(ctx, relayBatch)
+		if err != nil || len(records) == 0 {
+			return published, err
+		}
+		sequences := make([]int64, len(records))
+		for i, rec := range records {
+			sequences[i] = rec.Sequence
+			e, err := Decode(rec)
+			if err != nil {
+				// an undecodable record would block the outbox for good
+				fmt.Printf("[EVENTS] skipping outbox record: %v\n", err)
+				continue
+			}
+			r.bus.Publish(e)
+			published++
+		}
+		if err := r.outbox.MarkPublished(ctx, sequences...); err != nil {
+			return published, err
+		}
+	}
+}
+
+// Run flushes whenever it is nudged and every interval until ctx ends; the
+// ticks pick up events whose publication failed earlier or that another
+// process appended
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+		if _, err := r.Flush(ctx); err != nil {
+			fmt.Printf("[EVENTS] outbox flush failed: %v\n", err)
+		}
+	}
+}
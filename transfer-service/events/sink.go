@@ -0,0 +1,110 @@
+// File: events/sink.go
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"transfer-service/repository"
+)
+
+// MemorySink is a listener that keeps every event it receives
+type MemorySink struct {
+	events []Event
+	mutex  sync.Mutex
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+// Events returns what the sink has received so far, oldest first
+func (s *MemorySink) Events() []Event {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+func (s *MemorySink) record(e Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, e)
+}
+
+func (s *MemorySink) OnTransferInitiated(e TransferInitiated)         { s.record(e) }
+func (s *MemorySink) OnTransferCompleted(e TransferCompleted)         { s.record(e) }
+func (s *MemorySink) OnTransferFailed(e TransferFailed)               { s.record(e) }
+func (s *MemorySink) OnAccountBalanceChanged(e AccountBalanceChanged) { s.record(e) }
+
+// FileSink is a listener that appends every event to a file as one JSON
+// object per line:
+//
+//	{"type":"TransferCompleted","event":{"sequence":3,...}}
+type FileSink struct {
+	file  *os.File
+	mutex sync.Mutex
+}
+
+type fileLine struct {
+	Type  Type            `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// NewFileSink opens path for appending, creating it if needed
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: f}, nil
+}
+
+func (s *FileSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.file.Close()
+}
+
+func (s *FileSink) record(e Event) {
+	payload, err := json.Marshal(e)
+	if err == nil {
+		payload, err = json.Marshal(fileLine{Type: e.EventType(), Event: payload})
+	}
+	if err != nil {
+		fmt.Printf("[EVENTS] file sink: %v\n", err)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, err := s.file.Write(append(payload, '\n')); err != nil {
+		fmt.Printf("[EVENTS] file sink: %v\n", err)
+	}
+}
+
+func (s *FileSink) OnTransferInitiated(e TransferInitiated)         { s.record(e) }
+func (s *FileSink) OnTransferCompleted(e TransferCompleted)         { s.record(e) }
+func (s *FileSink) OnTransferFailed(e TransferFailed)               { s.record(e) }
+func (s *FileSink) OnAccountBalanceChanged(e AccountBalanceChanged) { s.record(e) }
+
+// ReadEvents decodes what a FileSink wrote
+func ReadEvents(r io.Reader) ([]Event, error) {
+	var out []Event
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var line fileLine
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("events: %w", err)
+		}
+		e, err := Decode(&repository.OutboxRecord{Type: string(line.Type), Payload: line.Event})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
@@ -0,0 +1,208 @@
+// File: fees/config.go
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"transfer-service/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the declarative form of a fee schedule, e.g.
+//
+//	revenueAccount: FEES
+//	fees:
+//	  - name: convenience
+//	    type: flat
+//	    amount: "2.00"
+//	    waivedFor: [MERCHANT]
+//	  - name: commission
+//	    type: percentage
+//	    bps: 25
+//	    min: "1.00"
+//	    max: "50.00"
+//	  - name: slab
+//	    type: tiered
+//	    tiers:
+//	      - upTo: "1000.00"
+//	      - upTo: "25000.00"
+//	        flat: "5.00"
+//	      - bps: 10
+type Config struct {
+	RevenueAccount string      `json:"revenueAccount" yaml:"revenueAccount"`
+	Fees           []FeeConfig `json:"fees" yaml:"fees"`
+}
+
+type FeeConfig struct {
+	Name      string       `json:"name" yaml:"name"`
+	Type      string       `json:"type" yaml:"type"`
+	Currency  string       `json:"currency,omitempty" yaml:"currency,omitempty"`
+	Amount    string       `json:"amount,omitempty" yaml:"amount,omitempty"`
+	BPS       int64        `json:"bps,omitempty" yaml:"bps,omitempty"`
+	Min       string       `json:"min,omitempty" yaml:"min,omitempty"`
+	Max       string       `json:"max,omitempty" yaml:"max,omitempty"`
+	Tiers     []TierConfig `json:"tiers,omitempty" yaml:"tiers,omitempty"`
+	WaivedFor []string     `json:"waivedFor,omitempty" yaml:"waivedFor,omitempty"`
+}
+
+type TierConfig struct {
+	UpTo string `json:"upTo,omitempty" yaml:"upTo,omitempty"`
+	Flat string `json:"flat,omitempty" yaml:"flat,omitempty"`
+	BPS  int64  `json:"bps,omitempty" yaml:"bps,omitempty"`
+}
+
+// LoadConfig reads a YAML (.yaml, .yml) or JSON (.json) fee schedule
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	case ".json":
+		return ParseJSON(data)
+	}
+	return nil, fmt.Errorf("fees: unsupported config file %s", path)
+}
+
+func ParseYAML(data []byte) (*Config, error) {
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("fees: %w", err)
+	}
+	return &c, nil
+}
+
+func ParseJSON(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("fees: %w", err)
+	}
+	return &c, nil
+}
+
+// Schedule builds the configured fees, rejecting any that are incomplete
+func (c *Config) Schedule() (*Schedule, error) {
+	if c.RevenueAccount == "" {
+		return nil, fmt.Errorf("fees: missing revenueAccount")
+	}
+	s := &Schedule{RevenueAccountId: c.RevenueAccount}
+	seen := make(map[string]bool)
+	for _, f := range c.Fees {
+		if f.Name == "" {
+			return nil, NewInvalidFeeError(f.Name, "missing name")
+		}
+		if seen[f.Name] {
+			return nil, NewInvalidFeeError(f.Name, "duplicate name")
+		}
+		seen[f.Name] = true
+
+		component, err := f.component()
+		if err != nil {
+			return nil, err
+		}
+		s.Components = append(s.Components, component)
+	}
+	return s, nil
+}
+
+func (f FeeConfig) component() (Component, error) {
+	currency := f.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	c := Component{Name: f.Name, Currency: currency}
+	for _, t := range f.WaivedFor {
+		c.WaivedFor = append(c.WaivedFor, models.AccountType(strings.ToUpper(t)))
+	}
+	if f.BPS < 0 {
+		return Component{}, NewInvalidFeeError(f.Name, "bps must not be negative")
+	}
+
+	switch f.Type {
+	case "flat":
+		amount, err := f.money(f.Amount, currency, true)
+		if err != nil {
+			return Component{}, err
+		}
+		c.Rule = &FlatFee{Amount: amount}
+	case "percentage":
+		if f.BPS == 0 {
+			return Component{}, NewInvalidFeeError(f.Name, "bps must be positive")
+		}
+		min, err := f.money(f.Min, currency, false)
+		if err != nil {
+			return Component{}, err
+		}
+		max, err := f.money(f.Max, currency, false)
+		if err != nil {
+			return Component{}, err
+		}
+		if !min.IsZero() && !max.IsZero() {
+			if cmp, _ := min.Cmp(max); cmp > 0 {
+				return Component{}, NewInvalidFeeError(f.Name, "min is above max")
+			}
+		}
+		c.Rule = &PercentageFee{BasisPoints: f.BPS, Min: min, Max: max}
+	case "tiered":
+		rule, err := f.tiered(currency)
+		if err != nil {
+			return Component{}, err
+		}
+		c.Rule = rule
+	default:
+		return Component{}, NewInvalidFeeError(f.Name, fmt.Sprintf("unknown type %q", f.Type))
+	}
+	return c, nil
+}
+
+func (f FeeConfig) tiered(currency string) (*TieredFee, error) {
+	if len(f.Tiers) == 0 {
+		return nil, NewInvalidFeeError(f.Name, "tiered fee needs at least one tier")
+	}
+	rule := &TieredFee{}
+	for i, tc := range f.Tiers {
+		if tc.BPS < 0 {
+			return nil, NewInvalidFeeError(f.Name, "bps must not be negative")
+		}
+		upTo, err := f.money(tc.UpTo, currency, false)
+		if err != nil {
+			return nil, err
+		}
+		flat, err := f.money(tc.Flat, currency, false)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			prev := rule.Tiers[i-1].UpTo
+			if prev.IsZero() {
+				return nil, NewInvalidFeeError(f.Name, "only the last tier may be open-ended")
+			}
+			if cmp, _ := upTo.Cmp(prev); !upTo.IsZero() && cmp <= 0 {
+				return nil, NewInvalidFeeError(f.Name, "tiers must be in ascending order")
+			}
+		}
+		rule.Tiers = append(rule.Tiers, Tier{UpTo: upTo, Flat: flat, BasisPoints: tc.BPS})
+	}
+	return rule, nil
+}
+
+// money parses an amount from the config; an empty optional amount is zero
+func (f FeeConfig) money(s, currency string, required bool) (models.Money, error) {
+	if s == "" && !required {
+		return models.Zero(currency), nil
+	}
+	m, err := models.ParseMoney(s, currency)
+	if err != nil {
+		return models.Money{}, NewInvalidFeeError(f.Name, err.Error())
+	}
+	if m.IsNegative() || (required && m.IsZero()) {
+		return models.Money{}, NewInvalidFeeError(f.Name, fmt.Sprintf("invalid amount %q", s))
+	}
+	return m, nil
+}
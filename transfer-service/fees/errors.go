@@ -0,0 +1,8 @@
+// File: fees/errors.go
+package fees
+
+import "fmt"
+
+func NewInvalidFeeError(name, reason string) error {
+	return fmt.Errorf("fees: fee %q: %s", name, reason)
+}
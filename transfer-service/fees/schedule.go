@@ -0,0 +1,140 @@
+// File: fees/schedule.go
+package fees
+
+import "transfer-service/models"
+
+// Rule prices one fee component for a transfer amount
+type Rule interface {
+	Compute(amount models.Money) (models.Money, error)
+}
+
+// FlatFee charges the same amount on every transfer
+type FlatFee struct {
+	Amount models.Money
+}
+
+func (f *FlatFee) Compute(amount models.Money) (models.Money, error) {
+	return f.Amount, nil
+}
+
+// PercentageFee charges BasisPoints of the amount, rounded up to the next
+// minor unit and kept within Min and Max when they are set
+type PercentageFee struct {
+	BasisPoints int64
+	Min         models.Money // zero means no floor
+	Max         models.Money // zero means no cap
+}
+
+func (f *PercentageFee) Compute(amount models.Money) (models.Money, error) {
+	fee, err := amount.MulRat(f.BasisPoints, 10000, models.RoundUp)
+	if err != nil {
+		return models.Money{}, err
+	}
+	return clamp(fee, f.Min, f.Max)
+}
+
+func clamp(fee, min, max models.Money) (models.Money, error) {
+	if !min.IsZero() {
+		if cmp, err := fee.Cmp(min); err != nil {
+			return models.Money{}, err
+		} else if cmp < 0 {
+			fee = min
+		}
+	}
+	if !max.IsZero() {
+		if cmp, err := fee.Cmp(max); err != nil {
+			return models.Money{}, err
+		} else if cmp > 0 {
+			fee = max
+		}
+	}
+	return fee, nil
+}
+
+// Tier prices amounts up to and including UpTo as Flat plus BasisPoints of
+// the amount. A zero UpTo makes the tier open-ended.
+type Tier struct {
+	UpTo        models.Money
+	Flat        models.Money
+	BasisPoints int64
+}
+
+// TieredFee picks the first tier whose UpTo covers the amount; tiers must be
+// in ascending order. Amounts above every tier are not charged.
+type TieredFee struct {
+	Tiers []Tier
+}
+
+func (f *TieredFee) Compute(amount models.Money) (models.Money, error) {
+	for _, t := range f.Tiers {
+		if !t.UpTo.IsZero() {
+			cmp, err := amount.Cmp(t.UpTo)
+			if err != nil {
+				return models.Money{}, err
+			}
+			if cmp > 0 {
+				continue
+			}
+		}
+		variable, err := amount.MulRat(t.BasisPoints, 10000, models.RoundUp)
+		if err != nil {
+			return models.Money{}, err
+		}
+		if t.Flat.IsZero() {
+			return variable, nil
+		}
+		return t.Flat.Add(variable)
+	}
+	return models.Zero(amount.Currency()), nil
+}
+
+// Component is one named line of a fee schedule. It only applies to
+// transfers in Currency and is waived for payers of the listed types.
+type Component struct {
+	Name      string
+	Currency  string
+	Rule      Rule
+	WaivedFor []models.AccountType
+}
+
+func (c Component) waived(payer models.AccountType) bool {
+	for _, t := range c.WaivedFor {
+		if t == payer {
+			return true
+		}
+	}
+	return false
+}
+
+// Schedule is the set of fees charged on a transfer. Fees are paid by the
+// sender on top of the amount and credited to RevenueAccountId.
+type Schedule struct {
+	RevenueAccountId string
+	Components       []Component
+}
+
+// Calculate prices a transfer of amount sent by an account of the payer
+// type. It returns nil when nothing is charged.
+func (s *Schedule) Calculate(payer models.AccountType, amount models.Money) (*models.FeeBreakdown, error) {
+	breakdown := &models.FeeBreakdown{RevenueAccountId: s.RevenueAccountId, Total: models.Zero(amount.Currency())}
+	for _, c := range s.Components {
+		if c.Currency != amount.Currency() || c.waived(payer) {
+			continue
+		}
+		fee, err := c.Rule.Compute(amount)
+		if err != nil {
+			return nil, err
+		}
+		if !fee.IsPositive() {
+			continue
+		}
+		if breakdown.Total, err = breakdown.Total.Add(fee); err != nil {
+			return nil, err
+		}
+		breakdown.Components = append(breakdown.Components, models.FeeComponent{Name: c.Name, Amount: fee})
+	}
+	if breakdown.Total.IsZero() {
+		return nil, nil
+	}
+	return breakdown, nil
+}
@@ -0,0 +1,74 @@
+// File: fx/config.go
+package fx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RatesFile is the on-disk form of a rate table, e.g.
+//
+//	asOf: 2024-03-01T09:00:00Z
+//	rates:
+//	  - from: USD
+//	    to: INR
+//	    rate: "83.25"
+type RatesFile struct {
+	AsOf  time.Time    `json:"asOf,omitempty" yaml:"asOf,omitempty"`
+	Rates []RateConfig `json:"rates" yaml:"rates"`
+}
+
+type RateConfig struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+	Rate string `json:"rate" yaml:"rate"`
+}
+
+// LoadRatesFile reads a YAML (.yaml, .yml) or JSON (.json) rate table
+func LoadRatesFile(path string) (*StaticRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f RatesFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &f)
+	case ".json":
+		err = json.Unmarshal(data, &f)
+	default:
+		return nil, fmt.Errorf("fx: unsupported rates file %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fx: %w", err)
+	}
+	return f.StaticRates()
+}
+
+// StaticRates builds a provider from the file, rejecting malformed or duplicate pairs
+func (f *RatesFile) StaticRates() (*StaticRates, error) {
+	seen := make(map[pair]bool)
+	rates := make([]Rate, 0, len(f.Rates))
+	for _, rc := range f.Rates {
+		r, err := ParseRate(rc.From, rc.To, rc.Rate)
+		if err != nil {
+			return nil, err
+		}
+		if rc.From == rc.To {
+			return nil, NewInvalidRateError(rc.From, rc.To, "same currency")
+		}
+		if seen[pair{rc.From, rc.To}] {
+			return nil, NewInvalidRateError(rc.From, rc.To, "duplicate pair")
+		}
+		seen[pair{rc.From, rc.To}] = true
+		r.AsOf = f.AsOf
+		rates = append(rates, r)
+	}
+	return NewStaticRates(rates...), nil
+}
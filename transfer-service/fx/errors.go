@@ -0,0 +1,8 @@
+// File: fx/errors.go
+package fx
+
+import "fmt"
+
+func NewInvalidRateError(from, to, reason string) error {
+	return fmt.Errorf("fx: rate %s/%s: %s", from, to, reason)
+}
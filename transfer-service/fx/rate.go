@@ -0,0 +1,54 @@
+// File: fx/rate.go
+package fx
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"transfer-service/models"
+)
+
+// Rate says how many units of To one unit of From buys. Value is exact so
+// that conversions round once, at the end, in minor units.
+type Rate struct {
+	From  string
+	To    string
+	Value *big.Rat
+	AsOf  time.Time
+}
+
+// maxRateDigits bounds how a rate that is not a short decimal is printed
+const maxRateDigits = 12
+
+// ParseRate parses a decimal rate such as "83.25" without going through float64
+func ParseRate(from, to, value string) (Rate, error) {
+	for _, c := range []string{from, to} {
+		if _, ok := models.CurrencyExponent(c); !ok {
+			return Rate{}, models.NewUnknownCurrencyError(c)
+		}
+	}
+	text := strings.TrimSpace(value)
+	v, ok := new(big.Rat).SetString(text)
+	if !ok || strings.ContainsAny(text, "/eE") {
+		return Rate{}, NewInvalidRateError(from, to, fmt.Sprintf("malformed rate %q", value))
+	}
+	if v.Sign() <= 0 {
+		return Rate{}, NewInvalidRateError(from, to, "rate must be positive")
+	}
+	return Rate{From: from, To: to, Value: v}, nil
+}
+
+// String prints the rate as a plain decimal, e.g. "83.25"
+func (r Rate) String() string {
+	if r.Value == nil {
+		return ""
+	}
+	for digits := 0; digits < maxRateDigits; digits++ {
+		s := r.Value.FloatString(digits)
+		if back, _ := new(big.Rat).SetString(s); back.Cmp(r.Value) == 0 {
+			return s
+		}
+	}
+	return r.Value.FloatString(maxRateDigits)
+}
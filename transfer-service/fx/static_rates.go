@@ -0,0 +1,54 @@
+// File: fx/static_rates.go
+package fx
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"transfer-service/models"
+)
+
+// StaticRates serves a fixed table of rates. Only the pairs it was given are
+// quoted; the inverse of a pair is not derived, since real desks price each
+// direction separately.
+type StaticRates struct {
+	rates map[pair]Rate
+	mutex sync.RWMutex
+}
+
+type pair struct{ from, to string }
+
+func NewStaticRates(rates ...Rate) *StaticRates {
+	s := &StaticRates{rates: make(map[pair]Rate)}
+	for _, r := range rates {
+		s.Set(r)
+	}
+	return s
+}
+
+// Set adds or replaces the rate for r.From -> r.To
+func (s *StaticRates) Set(r Rate) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rates[pair{r.From, r.To}] = r
+}
+
+// Rate returns the configured rate, or FX_RATE_UNAVAILABLE when there is none
+func (s *StaticRates) Rate(ctx context.Context, from, to string) (Rate, error) {
+	select {
+	case <-ctx.Done():
+		return Rate{}, models.WrapContextError(ctx.Err())
+	default:
+	}
+	if from == to {
+		return Rate{From: from, To: to, Value: big.NewRat(1, 1)}, nil
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	r, ok := s.rates[pair{from, to}]
+	if !ok {
+		return Rate{}, models.NewFXRateUnavailableError(from, to)
+	}
+	return r, nil
+}
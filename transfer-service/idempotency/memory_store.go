@@ -0,0 +1,93 @@
+// File: idempotency/memory_store.go
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+	"transfer-service/models"
+)
+
+// InMemoryStore keeps idempotency records in process memory until their TTL elapses
+type InMemoryStore struct {
+	records map[string]*Record
+	ttl     time.Duration
+	now     func() time.Time
+	mutex   sync.Mutex
+}
+
+type StoreOption func(*InMemoryStore)
+
+// WithClock replaces time.Now, mainly so tests can move time forward
+func WithClock(now func() time.Time) StoreOption {
+	return func(s *InMemoryStore) { s.now = now }
+}
+
+func NewInMemoryStore(ttl time.Duration, opts ...StoreOption) *InMemoryStore {
+	s := &InMemoryStore{
+		records: make(map[string]*Record),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *InMemoryStore) Begin(ctx context.Context, key, fingerprint string) (*Record, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.WrapContextError(ctx.Err())
+	default:
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	if rec, exists := s.records[key]; exists && now.Before(rec.ExpiresAt) {
+		copied := *rec
+		return &copied, nil
+	}
+	s.records[key] = &Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+	return nil, nil
+}
+
+func (s *InMemoryStore) Complete(ctx context.Context, key string, result models.TransferResult) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	rec, exists := s.records[key]
+	if !exists {
+		return &models.TransferError{
+			Code:    "IDEMPOTENCY_KEY_NOT_FOUND",
+			Message: "No reservation for request " + key,
+			Details: map[string]interface{}{"requestId": key},
+		}
+	}
+	rec.Completed = true
+	rec.Result = result
+	return nil
+}
+
+// PurgeExpired drops records whose TTL has elapsed and returns how many were removed
+func (s *InMemoryStore) PurgeExpired() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	removed := 0
+	for key, rec := range s.records {
+		if !now.Before(rec.ExpiresAt) {
+			delete(s.records, key)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,37 @@
+// File: idempotency/store.go
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+	"transfer-service/models"
+)
+
+// Record remembers what happened to one RequestId
+type Record struct {
+	Key         string
+	Fingerprint string
+	Completed   bool
+	Result      models.TransferResult
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+type Store interface {
+	// Begin reserves key for the caller. If the key is already known and has
+	// not expired, the existing record is returned and nothing is reserved.
+	Begin(ctx context.Context, key, fingerprint string) (*Record, error)
+	// Complete stores the final result for a key reserved by Begin
+	Complete(ctx context.Context, key string, result models.TransferResult) error
+}
+
+// Fingerprint identifies a request's payload so that a reused RequestId
+// with different contents can be told apart from a genuine retry
+func Fingerprint(req models.TransferRequest) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s",
+		req.FromAccountId, req.ToAccountId, req.Amount.Minor(), req.Amount.Currency())))
+	return hex.EncodeToString(sum[:])
+}
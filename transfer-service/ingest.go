@@ -0,0 +1,115 @@
+// File: ingest.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"transfer-service/batchfile"
+	"transfer-service/models"
+	"transfer-service/service"
+)
+
+// runIngest implements
+//
+//	transfer-service ingest [flags] <batch file>
+//
+// It parses a CSV or fixed-width batch, runs the valid rows through
+// BulkTransfer and writes one result per row. With -dry-run every row is only
+// checked and no money moves.
+func runIngest(args []string) {
+	fs := flag.NewFlagSet("ingest", flag.ExitOnError)
+	cfg := registerServiceFlags(fs)
+	format := fs.String("format", "", "csv or fixed; empty picks by file extension")
+	out := fs.String("out", "", "results file; empty writes <batch file>.results.csv")
+	dryRun := fs.Bool("dry-run", false, "validate every row without moving money")
+	atomic := fs.Bool("atomic", false, "apply every transfer or none")
+	workers := fs.Int("workers", 0, "concurrent transfers; 0 uses the service default")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s ingest [flags] <batch file>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = string(batchfile.DetectFormat(path))
+	}
+	if *out == "" {
+		*out = path + ".results.csv"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("Ingest failed: %v\n", err)
+		os.Exit(1)
+	}
+	batch, err := batchfile.Parse(f, batchfile.Format(*format))
+	f.Close()
+	if err != nil {
+		fmt.Printf("Ingest failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Parsed %s: %d valid rows, %d rejected\n", path, len(batch.Requests), len(batch.Errors))
+	for _, e := range batch.Errors {
+		fmt.Printf("  %v\n", e)
+	}
+
+	svc, err := cfg.build(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	results := batch.Rejected()
+	switch {
+	case *dryRun:
+		for i, req := range batch.Requests {
+			results = append(results, batch.Checked(i, svc.CheckTransfer(ctx, req)))
+		}
+	case *atomic && len(batch.Errors) > 0:
+		// an atomic batch with rejected rows could only ever partly apply
+		notRun := &models.TransferError{Code: "BATCH_ABORTED", Message: "Atomic batch not run: the file has rejected rows"}
+		for i := range batch.Requests {
+			results = append(results, batch.Outcome(i, models.TransferResult{Error: notRun}))
+		}
+	default:
+		opts := service.BulkOptions{Workers: *workers, Ordered: true, Atomic: *atomic}
+		svc.BulkTransferEach(ctx, batch.Requests, opts, func(r service.BulkResult) {
+			results = append(results, batch.Outcome(r.Index, r.TransferResult))
+		})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+
+	if err := writeResults(*out, results); err != nil {
+		fmt.Printf("Writing results failed: %v\n", err)
+		os.Exit(1)
+	}
+	counts := make(map[batchfile.Status]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	fmt.Printf("Results written to %s: completed=%d, failed=%d, rejected=%d, valid=%d\n", *out,
+		counts[batchfile.StatusCompleted], counts[batchfile.StatusFailed], counts[batchfile.StatusRejected], counts[batchfile.StatusValid])
+}
+
+func writeResults(path string, results []batchfile.Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := batchfile.WriteResults(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,28 @@
+// File: ledger/errors.go
+package ledger
+
+import (
+	"fmt"
+	"transfer-service/models"
+)
+
+func NewUnbalancedEntryError(reference, currency string, debits, credits models.Money) *models.TransferError {
+	return &models.TransferError{
+		Code:    "UNBALANCED_ENTRY",
+		Message: fmt.Sprintf("Journal entry %s does not balance in %s", reference, currency),
+		Details: map[string]interface{}{
+			"reference": reference,
+			"currency":  currency,
+			"debits":    debits,
+			"credits":   credits,
+		},
+	}
+}
+
+func NewInvalidPostingError(reference, reason string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "INVALID_POSTING",
+		Message: fmt.Sprintf("Journal entry %s has an invalid posting: %s", reference, reason),
+		Details: map[string]interface{}{"reference": reference, "reason": reason},
+	}
+}
@@ -0,0 +1,143 @@
+// File: ledger/ledger.go
+package ledger
+
+import (
+	"context"
+	"strings"
+	"time"
+	"transfer-service/models"
+)
+
+// Direction says which side of the books a posting lands on
+type Direction string
+
+const (
+	Debit  Direction = "DEBIT"
+	Credit Direction = "CREDIT"
+)
+
+// OpeningBalanceAccount is the contra account used to book balances that
+// existed before the ledger started recording
+const OpeningBalanceAccount = "__opening_balance__"
+
+// FXPositionAccount holds the currency bought and sold by cross-currency
+// transfers, and FXFeeAccount the conversion fees kept from them
+const (
+	FXPositionAccount = "__fx_position__"
+	FXFeeAccount      = "__fx_fees__"
+)
+
+// IsSystemAccount reports whether id is one of the ledger's own contra
+// accounts rather than a customer account held in a repository
+func IsSystemAccount(id string) bool {
+	return strings.HasPrefix(id, "__") && strings.HasSuffix(id, "__")
+}
+
+// Posting is one line of a journal entry. Amount is always positive; the
+// Direction decides whether it adds to (credit) or takes from (debit) an account.
+type Posting struct {
+	ID        string
+	EntryID   string
+	AccountID string
+	Direction Direction
+	Amount    models.Money
+	CreatedAt time.Time
+}
+
+// JournalEntry groups postings whose debits and credits must balance per currency
+type JournalEntry struct {
+	ID          string
+	Reference   string
+	Description string
+	Postings    []Posting
+	CreatedAt   time.Time
+}
+
+type Ledger interface {
+	// Record validates and stores a balanced entry, assigning ids and timestamps
+	Record(ctx context.Context, entry JournalEntry) (*JournalEntry, error)
+	// Postings lists an account's postings in the order they were recorded
+	Postings(ctx context.Context, accountId string) ([]Posting, error)
+	// Balance derives an account's balance from its postings
+	Balance(ctx context.Context, accountId, currency string) (models.Money, error)
+	// Accounts lists every account that has at least one posting
+	Accounts(ctx context.Context) ([]string, error)
+}
+
+// NewTransferEntry builds the two-posting entry for moving amount from one account to another
+func NewTransferEntry(reference, fromId, toId string, amount models.Money) JournalEntry {
+	return JournalEntry{
+		Reference:   reference,
+		Description: "transfer " + fromId + " -> " + toId,
+		Postings: []Posting{
+			{AccountID: fromId, Direction: Debit, Amount: amount},
+			{AccountID: toId, Direction: Credit, Amount: amount},
+		},
+	}
+}
+
+// AddTransfer appends a debit of amount from one account and the matching
+// credit to another, e.g. to book a fee alongside the transfer it was charged on
+func (e *JournalEntry) AddTransfer(fromId, toId string, amount models.Money) {
+	e.Postings = append(e.Postings,
+		Posting{AccountID: fromId, Direction: Debit, Amount: amount},
+		Posting{AccountID: toId, Direction: Credit, Amount: amount},
+	)
+}
+
+// NewConversionEntry builds the entry for a cross-currency transfer. The
+// sender's source amount is split between the fee account and the FX
+// position, which in turn pays out the converted amount to the recipient, so
+// each currency balances on its own.
+func NewConversionEntry(reference, fromId, toId string, source, fee, converted models.Money) (JournalEntry, error) {
+	sold, err := source.Sub(fee)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	entry := JournalEntry{
+		Reference:   reference,
+		Description: "fx transfer " + fromId + " -> " + toId,
+		Postings: []Posting{
+			{AccountID: fromId, Direction: Debit, Amount: source},
+			{AccountID: FXPositionAccount, Direction: Credit, Amount: sold},
+			{AccountID: FXPositionAccount, Direction: Debit, Amount: converted},
+			{AccountID: toId, Direction: Credit, Amount: converted},
+		},
+	}
+	if fee.IsPositive() {
+		entry.Postings = append(entry.Postings, Posting{AccountID: FXFeeAccount, Direction: Credit, Amount: fee})
+	}
+	return entry, nil
+}
+
+// RecordOpeningBalances books each account's current balance against
+// OpeningBalanceAccount so that later reconciliation starts from a known state
+func RecordOpeningBalances(ctx context.Context, l Ledger, accounts []*models.Account) error {
+	for _, acc := range accounts {
+		balance := acc.GetBalance()
+		if balance.IsZero() {
+			continue
+		}
+		entry := NewTransferEntry("opening:"+acc.ID, OpeningBalanceAccount, acc.ID, balance)
+		entry.Description = "opening balance " + acc.ID
+		if balance.IsNegative() {
+			amount, err := balance.Neg()
+			if err != nil {
+				return err
+			}
+			entry = NewTransferEntry("opening:"+acc.ID, acc.ID, OpeningBalanceAccount, amount)
+		}
+		if _, err := l.Record(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signed returns the posting's effect on its account's balance
+func (p Posting) signed() (models.Money, error) {
+	if p.Direction == Debit {
+		return p.Amount.Neg()
+	}
+	return p.Amount, nil
+}
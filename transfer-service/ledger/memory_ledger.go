@@ -0,0 +1,142 @@
+// File: ledger/memory_ledger.go
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"transfer-service/models"
+)
+
+// InMemoryLedger keeps journal entries in process memory
+type InMemoryLedger struct {
+	entries  []*JournalEntry
+	postings map[string][]Posting
+	nextId   int64
+	mutex    sync.RWMutex
+}
+
+func NewInMemoryLedger() *InMemoryLedger {
+	return &InMemoryLedger{postings: make(map[string][]Posting)}
+}
+
+func (l *InMemoryLedger) Record(ctx context.Context, entry JournalEntry) (*JournalEntry, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.WrapContextError(ctx.Err())
+	default:
+	}
+
+	if err := ValidateEntry(entry); err != nil {
+		return nil, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.nextId++
+	now := time.Now()
+	recorded := &JournalEntry{
+		ID:          fmt.Sprintf("JE-%06d", l.nextId),
+		Reference:   entry.Reference,
+		Description: entry.Description,
+		CreatedAt:   now,
+	}
+	for i, p := range entry.Postings {
+		p.ID = fmt.Sprintf("%s-%d", recorded.ID, i+1)
+		p.EntryID = recorded.ID
+		p.CreatedAt = now
+		recorded.Postings = append(recorded.Postings, p)
+		l.postings[p.AccountID] = append(l.postings[p.AccountID], p)
+	}
+	l.entries = append(l.entries, recorded)
+	return recorded, nil
+}
+
+func (l *InMemoryLedger) Postings(ctx context.Context, accountId string) ([]Posting, error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return append([]Posting(nil), l.postings[accountId]...), nil
+}
+
+func (l *InMemoryLedger) Balance(ctx context.Context, accountId, currency string) (models.Money, error) {
+	postings, err := l.Postings(ctx, accountId)
+	if err != nil {
+		return models.Money{}, err
+	}
+	return sumPostings(postings, currency)
+}
+
+func (l *InMemoryLedger) Accounts(ctx context.Context) ([]string, error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	ids := make([]string, 0, len(l.postings))
+	for id := range l.postings {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// ValidateEntry checks that every posting is well formed and that debits equal
+// credits in each currency the entry touches
+func ValidateEntry(entry JournalEntry) error {
+	if len(entry.Postings) < 2 {
+		return NewInvalidPostingError(entry.Reference, "an entry needs at least two postings")
+	}
+
+	type totals struct{ debits, credits models.Money }
+	byCurrency := make(map[string]*totals)
+	for _, p := range entry.Postings {
+		if p.AccountID == "" {
+			return NewInvalidPostingError(entry.Reference, "posting without account")
+		}
+		if !p.Amount.IsPositive() {
+			return NewInvalidPostingError(entry.Reference, "posting amount must be positive")
+		}
+		cur := p.Amount.Currency()
+		t, ok := byCurrency[cur]
+		if !ok {
+			t = &totals{debits: models.Zero(cur), credits: models.Zero(cur)}
+			byCurrency[cur] = t
+		}
+		var err error
+		switch p.Direction {
+		case Debit:
+			t.debits, err = t.debits.Add(p.Amount)
+		case Credit:
+			t.credits, err = t.credits.Add(p.Amount)
+		default:
+			return NewInvalidPostingError(entry.Reference, fmt.Sprintf("unknown direction %q", p.Direction))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for cur, t := range byCurrency {
+		if t.debits != t.credits {
+			return NewUnbalancedEntryError(entry.Reference, cur, t.debits, t.credits)
+		}
+	}
+	return nil
+}
+
+// sumPostings adds up the signed postings in one currency
+func sumPostings(postings []Posting, currency string) (models.Money, error) {
+	total := models.Zero(currency)
+	for _, p := range postings {
+		if p.Amount.Currency() != currency {
+			continue
+		}
+		v, err := p.signed()
+		if err != nil {
+			return models.Money{}, err
+		}
+		if total, err = total.Add(v); err != nil {
+			return models.Money{}, err
+		}
+	}
+	return total, nil
+}
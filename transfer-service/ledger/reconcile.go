@@ -0,0 +1,51 @@
+// File: ledger/reconcile.go
+package ledger
+
+import (
+	"context"
+	"transfer-service/models"
+)
+
+// Discrepancy is an account whose stored balance differs from its postings
+type Discrepancy struct {
+	AccountID     string
+	Balance       models.Money
+	PostedBalance models.Money
+}
+
+type ReconciliationReport struct {
+	Checked       int
+	Discrepancies []Discrepancy
+}
+
+// Balanced reports whether every checked account matched its postings
+func (r *ReconciliationReport) Balanced() bool {
+	return len(r.Discrepancies) == 0
+}
+
+// Reconcile proves sum(postings) == balance for each given account
+func Reconcile(ctx context.Context, l Ledger, accounts []*models.Account) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{}
+	for _, acc := range accounts {
+		select {
+		case <-ctx.Done():
+			return nil, models.WrapContextError(ctx.Err())
+		default:
+		}
+
+		balance := acc.GetBalance()
+		posted, err := l.Balance(ctx, acc.ID, balance.Currency())
+		if err != nil {
+			return nil, err
+		}
+		report.Checked++
+		if posted != balance {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				AccountID:     acc.ID,
+				Balance:       balance,
+				PostedBalance: posted,
+			})
+		}
+	}
+	return report, nil
+}
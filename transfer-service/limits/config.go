@@ -0,0 +1,181 @@
+// File: limits/config.go
+package limits
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"transfer-service/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the declarative form of a limit policy set, e.g.
+//
+//	rules:
+//	  - name: upi-per-txn
+//	    type: per_transaction
+//	    max: "100000.00"
+//	    currency: INR
+//	  - name: upi-daily
+//	    type: daily_amount
+//	    max: "200000.00"
+//	    currency: INR
+//	    other_currencies:
+//	      - max: "2500.00"
+//	        currency: USD
+//	    timezone: Asia/Kolkata
+//	  - name: burst
+//	    type: velocity
+//	    count: 5
+//	    window: 1m
+//
+// An amount rule refuses transfers in any currency it gives no max for.
+type Config struct {
+	Rules []RuleConfig `json:"rules" yaml:"rules"`
+}
+
+type RuleConfig struct {
+	Name     string   `json:"name" yaml:"name"`
+	Type     string   `json:"type" yaml:"type"`
+	Max      string   `json:"max,omitempty" yaml:"max,omitempty"`
+	Currency string   `json:"currency,omitempty" yaml:"currency,omitempty"`
+	Timezone string   `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Count    int      `json:"count,omitempty" yaml:"count,omitempty"`
+	Window   string   `json:"window,omitempty" yaml:"window,omitempty"`
+	Accounts []string `json:"accounts,omitempty" yaml:"accounts,omitempty"`
+	// OtherCurrencies caps amount rules in currencies besides Currency
+	OtherCurrencies []AmountConfig `json:"other_currencies,omitempty" yaml:"other_currencies,omitempty"`
+}
+
+// AmountConfig is a cap in one currency
+type AmountConfig struct {
+	Max      string `json:"max" yaml:"max"`
+	Currency string `json:"currency" yaml:"currency"`
+}
+
+// LoadConfig reads a YAML (.yaml, .yml) or JSON (.json) rule file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	case ".json":
+		return ParseJSON(data)
+	}
+	return nil, fmt.Errorf("limits: unsupported config file %s", path)
+}
+
+func ParseYAML(data []byte) (*Config, error) {
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("limits: %w", err)
+	}
+	return &c, nil
+}
+
+func ParseJSON(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("limits: %w", err)
+	}
+	return &c, nil
+}
+
+// Policies builds the configured rules, rejecting any that are incomplete
+func (c *Config) Policies() ([]Policy, error) {
+	seen := make(map[string]bool)
+	policies := make([]Policy, 0, len(c.Rules))
+	for _, r := range c.Rules {
+		if r.Name == "" {
+			return nil, NewInvalidRuleError(r.Name, "missing name")
+		}
+		if seen[r.Name] {
+			return nil, NewInvalidRuleError(r.Name, "duplicate name")
+		}
+		seen[r.Name] = true
+
+		p, err := r.policy()
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func (r RuleConfig) policy() (Policy, error) {
+	switch r.Type {
+	case "per_transaction":
+		max, others, err := r.maxAmounts()
+		if err != nil {
+			return nil, err
+		}
+		return &PerTransactionPolicy{RuleName: r.Name, Max: max, OtherMax: others, Accounts: r.Accounts}, nil
+	case "daily_amount":
+		max, others, err := r.maxAmounts()
+		if err != nil {
+			return nil, err
+		}
+		loc := time.UTC
+		if r.Timezone != "" {
+			if loc, err = time.LoadLocation(r.Timezone); err != nil {
+				return nil, NewInvalidRuleError(r.Name, err.Error())
+			}
+		}
+		return &DailyAmountPolicy{RuleName: r.Name, Max: max, OtherMax: others, Location: loc, Accounts: r.Accounts}, nil
+	case "velocity":
+		if r.Count <= 0 {
+			return nil, NewInvalidRuleError(r.Name, "count must be positive")
+		}
+		window, err := time.ParseDuration(r.Window)
+		if err != nil || window <= 0 {
+			return nil, NewInvalidRuleError(r.Name, fmt.Sprintf("invalid window %q", r.Window))
+		}
+		return &VelocityPolicy{RuleName: r.Name, MaxCount: r.Count, Window: window, Accounts: r.Accounts}, nil
+	}
+	return nil, NewInvalidRuleError(r.Name, fmt.Sprintf("unknown type %q", r.Type))
+}
+
+// maxAmounts parses the rule's cap and its caps in other currencies, at most
+// one per currency
+func (r RuleConfig) maxAmounts() (models.Money, []models.Money, error) {
+	max, err := r.parseMax(r.Max, r.Currency)
+	if err != nil {
+		return models.Money{}, nil, err
+	}
+	seen := map[string]bool{max.Currency(): true}
+	others := make([]models.Money, 0, len(r.OtherCurrencies))
+	for _, o := range r.OtherCurrencies {
+		m, err := r.parseMax(o.Max, o.Currency)
+		if err != nil {
+			return models.Money{}, nil, err
+		}
+		if seen[m.Currency()] {
+			return models.Money{}, nil, NewInvalidRuleError(r.Name, fmt.Sprintf("more than one max for %s", m.Currency()))
+		}
+		seen[m.Currency()] = true
+		others = append(others, m)
+	}
+	return max, others, nil
+}
+
+func (r RuleConfig) parseMax(amount, currency string) (models.Money, error) {
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	max, err := models.ParseMoney(amount, currency)
+	if err != nil {
+		return models.Money{}, NewInvalidRuleError(r.Name, err.Error())
+	}
+	if !max.IsPositive() {
+		return models.Money{}, NewInvalidRuleError(r.Name, "max must be positive")
+	}
+	return max, nil
+}
@@ -0,0 +1,145 @@
+// File: limits/engine.go
+package limits
+
+import (
+	"context"
+	"sync"
+	"time"
+	"transfer-service/models"
+)
+
+// Engine evaluates every policy against a sending account's recent usage.
+// Admission and bookkeeping happen under one lock, so two concurrent
+// transfers cannot both squeeze under the same limit.
+type Engine struct {
+	policies []Policy
+	usage    map[string][]usageEntry
+	seq      int64
+	lookback time.Duration
+	now      func() time.Time
+	mutex    sync.Mutex
+}
+
+type usageEntry struct {
+	id     int64
+	amount models.Money
+	at     time.Time
+}
+
+type EngineOption func(*Engine)
+
+// WithClock replaces time.Now, mainly so tests can control day and window boundaries
+func WithClock(now func() time.Time) EngineOption {
+	return func(e *Engine) { e.now = now }
+}
+
+func NewEngine(policies []Policy, opts ...EngineOption) *Engine {
+	e := &Engine{
+		policies: policies,
+		usage:    make(map[string][]usageEntry),
+		now:      time.Now,
+	}
+	for _, p := range policies {
+		if p.Lookback() > e.lookback {
+			e.lookback = p.Lookback()
+		}
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Reservation is admitted usage that can be handed back if the transfer fails
+type Reservation struct {
+	engine    *Engine
+	accountId string
+	id        int64
+	once      sync.Once
+}
+
+// Release forgets the reserved usage; it is safe to call more than once
+func (r *Reservation) Release() {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() { r.engine.release(r.accountId, r.id) })
+}
+
+// Reserve checks the transfer against every policy and, if all allow it,
+// counts it towards the sending account's usage
+func (e *Engine) Reserve(ctx context.Context, req models.TransferRequest) (*Reservation, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.WrapContextError(ctx.Err())
+	default:
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	now := e.now()
+	e.prune(req.FromAccountId, now)
+	attempt := Attempt{FromAccountId: req.FromAccountId, ToAccountId: req.ToAccountId, Amount: req.Amount, At: now}
+	usage := accountUsage(e.usage[req.FromAccountId])
+	for _, p := range e.policies {
+		if err := p.Evaluate(attempt, usage); err != nil {
+			return nil, err
+		}
+	}
+
+	e.seq++
+	e.usage[req.FromAccountId] = append(e.usage[req.FromAccountId], usageEntry{id: e.seq, amount: req.Amount, at: now})
+	return &Reservation{engine: e, accountId: req.FromAccountId, id: e.seq}, nil
+}
+
+func (e *Engine) release(accountId string, id int64) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	entries := e.usage[accountId]
+	for i, u := range entries {
+		if u.id == id {
+			e.usage[accountId] = append(entries[:i:i], entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// prune drops usage no policy can see any more; the caller holds the lock
+func (e *Engine) prune(accountId string, now time.Time) {
+	entries := e.usage[accountId]
+	cutoff := now.Add(-e.lookback)
+	i := 0
+	for i < len(entries) && entries[i].at.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		e.usage[accountId] = append([]usageEntry(nil), entries[i:]...)
+	}
+}
+
+type accountUsage []usageEntry
+
+func (u accountUsage) AmountSince(currency string, since time.Time) (models.Money, error) {
+	total := models.Zero(currency)
+	for _, e := range u {
+		if e.at.Before(since) || e.amount.Currency() != currency {
+			continue
+		}
+		var err error
+		if total, err = total.Add(e.amount); err != nil {
+			return models.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func (u accountUsage) CountSince(since time.Time) int {
+	n := 0
+	for _, e := range u {
+		if !e.at.Before(since) {
+			n++
+		}
+	}
+	return n
+}
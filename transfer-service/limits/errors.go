@@ -0,0 +1,44 @@
+// File: limits/errors.go
+package limits
+
+import (
+	"fmt"
+	"transfer-service/models"
+)
+
+// NewLimitExceededError names the rule that fired in Details so callers can tell rules apart
+func NewLimitExceededError(rule, ruleType, accountId string, details map[string]interface{}) *models.TransferError {
+	d := map[string]interface{}{
+		"rule":      rule,
+		"ruleType":  ruleType,
+		"accountId": accountId,
+	}
+	for k, v := range details {
+		d[k] = v
+	}
+	return &models.TransferError{
+		Code:    "LIMIT_EXCEEDED",
+		Message: fmt.Sprintf("Transfer from %s exceeds limit %q", accountId, rule),
+		Details: d,
+	}
+}
+
+// NewNoLimitForCurrencyError refuses a transfer in a currency an amount rule
+// sets no cap for
+func NewNoLimitForCurrencyError(rule, ruleType, accountId, currency string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "LIMIT_EXCEEDED",
+		Message: fmt.Sprintf("Limit %q sets no cap for %s transfers from %s", rule, currency, accountId),
+		Details: map[string]interface{}{
+			"rule":      rule,
+			"ruleType":  ruleType,
+			"accountId": accountId,
+			"currency":  currency,
+			"reason":    "no_limit_for_currency",
+		},
+	}
+}
+
+func NewInvalidRuleError(rule, reason string) error {
+	return fmt.Errorf("limits: rule %q: %s", rule, reason)
+}
@@ -0,0 +1,165 @@
+// File: limits/policy.go
+package limits
+
+import (
+	"time"
+	"transfer-service/models"
+)
+
+// Attempt is the transfer a policy is asked to judge
+type Attempt struct {
+	FromAccountId string
+	ToAccountId   string
+	Amount        models.Money
+	At            time.Time
+}
+
+// Usage is the sending account's recent, already-admitted activity
+type Usage interface {
+	// AmountSince sums admitted amounts in currency at or after since
+	AmountSince(currency string, since time.Time) (models.Money, error)
+	// CountSince counts admitted transfers at or after since
+	CountSince(since time.Time) int
+}
+
+// Policy is one limit rule. Evaluate returns a LIMIT_EXCEEDED error when the
+// attempt would break the rule, or nil when the rule does not object.
+type Policy interface {
+	Name() string
+	Evaluate(attempt Attempt, usage Usage) error
+	// Lookback is how much history the policy needs to see
+	Lookback() time.Duration
+}
+
+// scope restricts a rule to some sending accounts; empty means all of them
+type scope []string
+
+func (s scope) applies(accountId string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, id := range s {
+		if id == accountId {
+			return true
+		}
+	}
+	return false
+}
+
+// capFor picks the cap in currency from a rule's Max and OtherMax. An
+// amount rule fails closed: a transfer in a currency it sets no cap for is
+// refused rather than let past every limit.
+func capFor(rule, ruleType string, a Attempt, max models.Money, otherMax []models.Money) (models.Money, error) {
+	currency := a.Amount.Currency()
+	if max.Currency() == currency {
+		return max, nil
+	}
+	for _, m := range otherMax {
+		if m.Currency() == currency {
+			return m, nil
+		}
+	}
+	return models.Money{}, NewNoLimitForCurrencyError(rule, ruleType, a.FromAccountId, currency)
+}
+
+// PerTransactionPolicy caps the amount of a single transfer. Max is the cap
+// in one currency and OtherMax holds at most one cap for each other currency;
+// transfers in any currency without a cap are refused.
+type PerTransactionPolicy struct {
+	RuleName string
+	Max      models.Money
+	OtherMax []models.Money
+	Accounts []string
+}
+
+func (p *PerTransactionPolicy) Name() string            { return p.RuleName }
+func (p *PerTransactionPolicy) Lookback() time.Duration { return 0 }
+
+func (p *PerTransactionPolicy) Evaluate(a Attempt, _ Usage) error {
+	if !scope(p.Accounts).applies(a.FromAccountId) {
+		return nil
+	}
+	max, err := capFor(p.RuleName, "per_transaction", a, p.Max, p.OtherMax)
+	if err != nil {
+		return err
+	}
+	if cmp, _ := a.Amount.Cmp(max); cmp > 0 {
+		return NewLimitExceededError(p.RuleName, "per_transaction", a.FromAccountId, map[string]interface{}{
+			"limit":     max,
+			"attempted": a.Amount,
+		})
+	}
+	return nil
+}
+
+// DailyAmountPolicy caps the total an account may send per calendar day, in
+// each currency separately; Max and OtherMax work as for PerTransactionPolicy
+type DailyAmountPolicy struct {
+	RuleName string
+	Max      models.Money
+	OtherMax []models.Money
+	Location *time.Location // day boundaries; nil means UTC
+	Accounts []string
+}
+
+func (p *DailyAmountPolicy) Name() string            { return p.RuleName }
+func (p *DailyAmountPolicy) Lookback() time.Duration { return 25 * time.Hour }
+
+func (p *DailyAmountPolicy) Evaluate(a Attempt, usage Usage) error {
+	if !scope(p.Accounts).applies(a.FromAccountId) {
+		return nil
+	}
+	max, err := capFor(p.RuleName, "daily_amount", a, p.Max, p.OtherMax)
+	if err != nil {
+		return err
+	}
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := a.At.In(loc)
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	used, err := usage.AmountSince(max.Currency(), dayStart)
+	if err != nil {
+		return err
+	}
+	total, err := used.Add(a.Amount)
+	if err != nil {
+		return err
+	}
+	if cmp, _ := total.Cmp(max); cmp > 0 {
+		return NewLimitExceededError(p.RuleName, "daily_amount", a.FromAccountId, map[string]interface{}{
+			"limit":     max,
+			"used":      used,
+			"attempted": a.Amount,
+		})
+	}
+	return nil
+}
+
+// VelocityPolicy caps how many transfers an account may send within a window
+type VelocityPolicy struct {
+	RuleName string
+	MaxCount int
+	Window   time.Duration
+	Accounts []string
+}
+
+func (p *VelocityPolicy) Name() string            { return p.RuleName }
+func (p *VelocityPolicy) Lookback() time.Duration { return p.Window }
+
+func (p *VelocityPolicy) Evaluate(a Attempt, usage Usage) error {
+	if !scope(p.Accounts).applies(a.FromAccountId) {
+		return nil
+	}
+	count := usage.CountSince(a.At.Add(-p.Window))
+	if count+1 > p.MaxCount {
+		return NewLimitExceededError(p.RuleName, "velocity", a.FromAccountId, map[string]interface{}{
+			"limit":  p.MaxCount,
+			"window": p.Window.String(),
+			"count":  count,
+		})
+	}
+	return nil
+}
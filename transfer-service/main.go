@@ -3,21 +3,179 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"flag"
 	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+	"transfer-service/events"
+	"transfer-service/fees"
+	"transfer-service/fx"
+	"transfer-service/idempotency"
+	"transfer-service/ledger"
+	"transfer-service/limits"
 	"transfer-service/models"
 	"transfer-service/repository"
+	"transfer-service/risk"
 	"transfer-service/service"
+
+	_ "modernc.org/sqlite"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "ingest" {
+		runIngest(os.Args[2:])
+		return
+	}
+
+	cfg := registerServiceFlags(flag.CommandLine)
+	addr := flag.String("addr", ":8080", "HTTP listen address")
+	demo := flag.Bool("demo", false, "run the console demo instead of serving HTTP")
+	flag.Parse()
+
 	fmt.Println("Money Transfer Service v4 - Concurrency + Tests")
 	fmt.Println("================================================")
 
-	repo := repository.GetSqlAccountRepository()
-	svc := service.NewUPITransferService(repo)
+	svc, err := cfg.build(context.Background())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if *demo {
+		runDemo(svc)
+		return
+	}
+
+	go svc.RunHoldExpiry(context.Background(), time.Minute)
+	if relay := svc.EventRelay(); relay != nil {
+		go relay.Run(context.Background(), time.Second)
+	}
+
+	handler := service.NewHTTPHandler(
+		service.MakeTransferEndpoint(svc),
+		service.MakeBalanceEndpoint(svc),
+		service.MakeBulkTransferEndpoint(svc),
+		service.MakeStatsEndpoint(svc),
+	)
+
+	log.Printf("Server running on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
+
+// serviceFlags are the flags every mode uses to assemble the service
+type serviceFlags struct {
+	dbPath     *string
+	limitsPath *string
+	riskPath   *string
+	fxPath     *string
+	fxMarkup   *int64
+	feesPath   *string
+	eventsPath *string
+	holdExpiry *time.Duration
+}
+
+func registerServiceFlags(fs *flag.FlagSet) *serviceFlags {
+	return &serviceFlags{
+		dbPath:     fs.String("db", "", "SQLite database file; empty uses the in-memory repository"),
+		limitsPath: fs.String("limits", "", "YAML or JSON transfer limit rules"),
+		riskPath:   fs.String("risk", "", "YAML or JSON risk rules screening transfers for fraud"),
+		fxPath:     fs.String("fx", "", "YAML or JSON exchange rate table for cross-currency transfers"),
+		fxMarkup:   fs.Int64("fx-markup", 0, "fee kept on each conversion, in basis points"),
+		feesPath:   fs.String("fees", "", "YAML or JSON fee schedule"),
+		eventsPath: fs.String("events", "", "append transfer events to this file as JSON lines"),
+		holdExpiry: fs.Duration("hold-expiry", 7*24*time.Hour, "how long an authorization holds funds before it lapses"),
+	}
+}
+
+// build opens the repository and wires the service the flags describe
+func (f *serviceFlags) build(ctx context.Context) (*service.UPITransferService, error) {
+	repo, err := openRepository(ctx, *f.dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("Repository setup failed: %v", err)
+	}
+	journal := ledger.NewInMemoryLedger()
+	seed, err := repo.GetMultipleAccounts(ctx, []string{"1", "2", "3"})
+	if err == nil {
+		err = ledger.RecordOpeningBalances(ctx, journal, seed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Ledger setup failed: %v", err)
+	}
+	opts := []service.Option{
+		service.WithLedger(journal),
+		service.WithIdempotencyStore(idempotency.NewInMemoryStore(24 * time.Hour)),
+		service.WithTransferRepository(repository.NewInMemoryTransferRepository()),
+		service.WithHoldExpiry(*f.holdExpiry),
+	}
+	if holds, ok := repo.(repository.HoldRepository); ok {
+		opts = append(opts, service.WithHoldRepository(holds))
+	}
+	if *f.limitsPath != "" {
+		cfg, err := limits.LoadConfig(*f.limitsPath)
+		if err != nil {
+			return nil, fmt.Errorf("Limits setup failed: %v", err)
+		}
+		policies, err := cfg.Policies()
+		if err != nil {
+			return nil, fmt.Errorf("Limits setup failed: %v", err)
+		}
+		opts = append(opts, service.WithLimits(limits.NewEngine(policies)))
+	}
+	if *f.riskPath != "" {
+		cfg, err := risk.LoadConfig(*f.riskPath)
+		if err != nil {
+			return nil, fmt.Errorf("Risk setup failed: %v", err)
+		}
+		rules, err := cfg.BuildRules()
+		if err != nil {
+			return nil, fmt.Errorf("Risk setup failed: %v", err)
+		}
+		opts = append(opts, service.WithRiskEvaluator(risk.NewEngine(rules)))
+	}
+	if *f.fxPath != "" {
+		rates, err := fx.LoadRatesFile(*f.fxPath)
+		if err != nil {
+			return nil, fmt.Errorf("FX setup failed: %v", err)
+		}
+		opts = append(opts, service.WithFXRateProvider(rates), service.WithFXMarkup(*f.fxMarkup))
+	}
+	var schedule *fees.Schedule
+	if *f.feesPath != "" {
+		cfg, err := fees.LoadConfig(*f.feesPath)
+		if err == nil {
+			schedule, err = cfg.Schedule()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Fees setup failed: %v", err)
+		}
+		opts = append(opts, service.WithFees(schedule))
+	}
+	if *f.eventsPath != "" {
+		sink, err := events.NewFileSink(*f.eventsPath)
+		if err != nil {
+			return nil, fmt.Errorf("Events setup failed: %v", err)
+		}
+		bus := events.NewBus()
+		bus.RegisterListener(sink)
+		opts = append(opts, service.WithEventBus(bus))
+	}
+	svc := service.NewUPITransferService(repo, opts...)
+	if schedule != nil {
+		_, err := svc.OpenAccount(ctx, schedule.RevenueAccountId, "Fee revenue", models.Zero(models.DefaultCurrency))
+		if err != nil && models.ErrorCode(err) != "ACCOUNT_ALREADY_EXISTS" {
+			return nil, fmt.Errorf("Fees setup failed: %v", err)
+		}
+	}
+	return svc, nil
+}
 
+// runDemo drives the service through a single and a bulk transfer on the console
+func runDemo(svc *service.UPITransferService) {
 	// Single transfer demo
-	err := svc.Transfer(context.Background(), "1", "2", 150)
+	err := svc.Transfer(context.Background(), "1", "2", models.MustParseMoney("150.00", models.DefaultCurrency))
 	if err != nil {
 		fmt.Printf("Transfer failed: %v\n", err)
 	} else {
@@ -26,11 +184,11 @@ func main() {
 
 	// Bulk transfer demo
 	transfers := []models.TransferRequest{
-		{FromAccountId: "1", ToAccountId: "2", Amount: 10, RequestId: "REQ-1"},
-		{FromAccountId: "2", ToAccountId: "3", Amount: 20, RequestId: "REQ-2"},
-		{FromAccountId: "3", ToAccountId: "1", Amount: 15, RequestId: "REQ-3"},
+		{FromAccountId: "1", ToAccountId: "2", Amount: models.MustParseMoney("10.00", models.DefaultCurrency), RequestId: "REQ-1"},
+		{FromAccountId: "2", ToAccountId: "3", Amount: models.MustParseMoney("20.00", models.DefaultCurrency), RequestId: "REQ-2"},
+		{FromAccountId: "3", ToAccountId: "1", Amount: models.MustParseMoney("15.00", models.DefaultCurrency), RequestId: "REQ-3"},
 	}
-	results := svc.BulkTransfer(context.Background(), transfers)
+	results := svc.BulkTransfer(context.Background(), transfers, service.BulkOptions{Ordered: true})
 	for _, r := range results {
 		if r.Success {
 			fmt.Printf("%s: SUCCESS\n", r.RequestId)
@@ -41,4 +199,35 @@ func main() {
 
 	total, success := svc.GetStats()
 	fmt.Printf("\nFinal Stats: total=%d, success=%d\n", total, success)
+
+	report, err := svc.Reconcile(context.Background())
+	if err != nil {
+		fmt.Printf("Reconciliation failed: %v\n", err)
+	} else {
+		fmt.Printf("Reconciliation: checked=%d, balanced=%t\n", report.Checked, report.Balanced())
+	}
+
+	if relay := svc.EventRelay(); relay != nil {
+		if _, err := relay.Flush(context.Background()); err != nil {
+			fmt.Printf("Publishing events failed: %v\n", err)
+		}
+	}
+}
+
+// openRepository returns the shared in-memory repository, or a SQLite-backed
+// repository seeded with the demo accounts when a database path is given
+func openRepository(ctx context.Context, dbPath string) (repository.AccountRepository, error) {
+	if dbPath == "" {
+		return repository.GetSqlAccountRepository(), nil
+	}
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	repo, err := repository.NewDBAccountRepository(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	err = repo.SeedAccounts(ctx, repository.DemoAccounts()...)
+	return repo, err
 }
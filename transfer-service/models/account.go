@@ -3,37 +3,182 @@ package models
 
 import "sync"
 
+// AccountStatus is where an account is in its lifecycle
+type AccountStatus string
+
+const (
+	AccountActive AccountStatus = "ACTIVE" // may send and receive
+	AccountFrozen AccountStatus = "FROZEN" // may receive but not send
+	AccountClosed AccountStatus = "CLOSED" // terminal; no movement at all
+)
+
+// AccountType classifies accounts for pricing; fee schedules may waive
+// charges for some types
+type AccountType string
+
+const (
+	AccountPersonal AccountType = "PERSONAL"
+	AccountMerchant AccountType = "MERCHANT"
+	AccountInternal AccountType = "INTERNAL" // the bank's own accounts, e.g. fee revenue
+)
+
+// Account balances come in two kinds. Balance is the ledger balance, what
+// the account holds; Held is the part of it reserved by open authorizations.
+// Only the available balance, Balance - Held, may be spent.
 type Account struct {
 	ID      string
 	Name    string
-	Balance float64
-	Mutex   sync.RWMutex
+	Balance Money
+	Held    Money // zero value means nothing held
+	// Currency is the ISO-4217 code the account is held in; empty means the
+	// balance's currency. Money arriving in another currency is converted.
+	Currency string
+	Type     AccountType   // empty means AccountPersonal
+	Status   AccountStatus // empty means AccountActive
+	Version  int64         // optimistic-lock counter maintained by persistent stores
+	Mutex    sync.RWMutex
+}
+
+func (a *Account) GetStatus() AccountStatus {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+	return a.statusLocked()
+}
+
+// statusLocked reads the status while the caller already holds the mutex
+func (a *Account) statusLocked() AccountStatus {
+	if a.Status == "" {
+		return AccountActive
+	}
+	return a.Status
+}
+
+// CanDebit reports why money may not leave the account; the caller holds the mutex
+func (a *Account) CanDebit() error {
+	switch a.statusLocked() {
+	case AccountFrozen:
+		return NewAccountFrozenError(a.ID)
+	case AccountClosed:
+		return NewAccountClosedError(a.ID)
+	}
+	return nil
+}
+
+// CanCredit reports why money may not enter the account; the caller holds the mutex
+func (a *Account) CanCredit() error {
+	if a.statusLocked() == AccountClosed {
+		return NewAccountClosedError(a.ID)
+	}
+	return nil
+}
+
+func (a *Account) GetType() AccountType {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+	if a.Type == "" {
+		return AccountPersonal
+	}
+	return a.Type
+}
+
+func (a *Account) GetCurrency() string {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+	return a.currencyLocked()
 }
 
-func (a *Account) GetBalance() float64 {
+// currencyLocked reads the currency while the caller already holds the mutex
+func (a *Account) currencyLocked() string {
+	if a.Currency == "" {
+		return a.Balance.Currency()
+	}
+	return a.Currency
+}
+
+func (a *Account) GetBalance() Money {
 	a.Mutex.RLock()
 	defer a.Mutex.RUnlock()
 	return a.Balance
 }
 
-func (a *Account) UpdateBalance(newBalance float64) {
+func (a *Account) GetHeld() Money {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+	return a.HeldLocked()
+}
+
+// HeldLocked reads the held amount while the caller already holds the mutex
+func (a *Account) HeldLocked() Money {
+	if a.Held.Currency() == "" {
+		return Zero(a.Balance.Currency())
+	}
+	return a.Held
+}
+
+// AvailableBalance is what the account may spend: its ledger balance less
+// everything held
+func (a *Account) AvailableBalance() Money {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+	return a.AvailableLocked()
+}
+
+// AvailableLocked computes the available balance while the caller already
+// holds the mutex
+func (a *Account) AvailableLocked() Money {
+	available, err := a.Balance.Sub(a.HeldLocked())
+	if err != nil {
+		// a held amount in another currency cannot be netted; spend nothing
+		return Zero(a.Balance.Currency())
+	}
+	return available
+}
+
+// Balances reads the ledger, held and available balances in one go
+func (a *Account) Balances() AccountBalance {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+	return AccountBalance{Ledger: a.Balance, Held: a.HeldLocked(), Available: a.AvailableLocked()}
+}
+
+func (a *Account) UpdateBalance(newBalance Money) {
 	a.Mutex.Lock()
 	defer a.Mutex.Unlock()
 	a.Balance = newBalance
 }
 
-func (a *Account) DebitAmount(amount float64) bool {
+func (a *Account) DebitAmount(amount Money) error {
 	a.Mutex.Lock()
 	defer a.Mutex.Unlock()
-	if a.Balance >= amount {
-		a.Balance -= amount
-		return true
+	if err := a.CanDebit(); err != nil {
+		return err
+	}
+	available := a.AvailableLocked()
+	cmp, err := available.Cmp(amount)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return NewInsufficientBalanceError(a.ID, available, amount, Zero(amount.Currency()))
 	}
-	return false
+	balance, err := a.Balance.Sub(amount)
+	if err != nil {
+		return err
+	}
+	a.Balance = balance
+	return nil
 }
 
-func (a *Account) CreditAmount(amount float64) {
+func (a *Account) CreditAmount(amount Money) error {
 	a.Mutex.Lock()
 	defer a.Mutex.Unlock()
-	a.Balance += amount
+	if err := a.CanCredit(); err != nil {
+		return err
+	}
+	balance, err := a.Balance.Add(amount)
+	if err != nil {
+		return err
+	}
+	a.Balance = balance
+	return nil
 }
@@ -16,6 +16,17 @@ func (e *TransferError) Error() string {
 	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
 }
 
+// ErrorCode returns the TransferError code of err, or INTERNAL_ERROR for any other error
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if te, ok := err.(*TransferError); ok {
+		return te.Code
+	}
+	return "INTERNAL_ERROR"
+}
+
 // Predefined error types
 func NewAccountNotFoundError(accountId string) *TransferError {
 	return &TransferError{
@@ -25,32 +36,264 @@ func NewAccountNotFoundError(accountId string) *TransferError {
 	}
 }
 
-func NewInsufficientBalanceError(accountId string, balance, amount float64) *TransferError {
+func NewAccountAlreadyExistsError(accountId string) *TransferError {
+	return &TransferError{
+		Code:    "ACCOUNT_ALREADY_EXISTS",
+		Message: fmt.Sprintf("Account %s already exists", accountId),
+		Details: map[string]interface{}{"accountId": accountId},
+	}
+}
+
+func NewAccountFrozenError(accountId string) *TransferError {
+	return &TransferError{
+		Code:    "ACCOUNT_FROZEN",
+		Message: fmt.Sprintf("Account %s is frozen", accountId),
+		Details: map[string]interface{}{"accountId": accountId},
+	}
+}
+
+func NewAccountClosedError(accountId string) *TransferError {
+	return &TransferError{
+		Code:    "ACCOUNT_CLOSED",
+		Message: fmt.Sprintf("Account %s is closed", accountId),
+		Details: map[string]interface{}{"accountId": accountId},
+	}
+}
+
+func NewAccountNotEmptyError(accountId string, balance Money) *TransferError {
+	return &TransferError{
+		Code:    "ACCOUNT_NOT_EMPTY",
+		Message: fmt.Sprintf("Account %s still holds %s", accountId, balance),
+		Details: map[string]interface{}{"accountId": accountId, "balance": balance},
+	}
+}
+
+func NewInvalidStatusTransitionError(accountId string, from, to AccountStatus) *TransferError {
+	return &TransferError{
+		Code:    "INVALID_STATUS_TRANSITION",
+		Message: fmt.Sprintf("Account %s cannot move from %s to %s", accountId, from, to),
+		Details: map[string]interface{}{"accountId": accountId, "from": from, "to": to},
+	}
+}
+
+func NewTransferNotFoundError(transferId string) *TransferError {
+	return &TransferError{
+		Code:    "TRANSFER_NOT_FOUND",
+		Message: fmt.Sprintf("Transfer %s not found", transferId),
+		Details: map[string]interface{}{"transferId": transferId},
+	}
+}
+
+func NewHistoryNotConfiguredError() *TransferError {
+	return &TransferError{Code: "HISTORY_NOT_CONFIGURED", Message: "No transfer history configured"}
+}
+
+func NewAccountListingNotSupportedError() *TransferError {
+	return &TransferError{Code: "ACCOUNT_LISTING_NOT_SUPPORTED", Message: "The account store cannot list its accounts"}
+}
+
+func NewInvalidCursorError(cursor string) *TransferError {
+	return &TransferError{
+		Code:    "INVALID_CURSOR",
+		Message: "Statement cursor is not valid",
+		Details: map[string]interface{}{"cursor": cursor},
+	}
+}
+
+func NewConcurrentModificationError(accountId string, version int64) *TransferError {
+	return &TransferError{
+		Code:    "CONCURRENT_MODIFICATION",
+		Message: fmt.Sprintf("Account %s was modified concurrently", accountId),
+		Details: map[string]interface{}{"accountId": accountId, "version": version},
+	}
+}
+
+func NewConcurrentTransferUpdateError(transferId string, version int64) *TransferError {
+	return &TransferError{
+		Code:    "CONCURRENT_MODIFICATION",
+		Message: fmt.Sprintf("Transfer %s was modified concurrently", transferId),
+		Details: map[string]interface{}{"transferId": transferId, "version": version},
+	}
+}
+
+// NewInsufficientBalanceError reports a debit of amount plus fee that the
+// balance cannot cover; pass a zero fee when nothing is charged
+func NewInsufficientBalanceError(accountId string, balance, amount, fee Money) *TransferError {
+	required := amount
+	if !fee.IsZero() {
+		if sum, err := amount.Add(fee); err == nil {
+			required = sum
+		}
+	}
 	return &TransferError{
 		Code:    "INSUFFICIENT_BALANCE",
 		Message: fmt.Sprintf("Account %s has insufficient balance", accountId),
 		Details: map[string]interface{}{
 			"accountId": accountId,
 			"balance":   balance,
-			"required":  amount,
+			"amount":    amount,
+			"fee":       fee,
+			"required":  required,
 		},
 	}
 }
 
-func NewInvalidAmountError(amount float64) *TransferError {
+func NewInvalidAmountError(amount Money) *TransferError {
 	return &TransferError{
 		Code:    "INVALID_AMOUNT",
-		Message: fmt.Sprintf("Invalid transfer amount: %.2f", amount),
+		Message: fmt.Sprintf("Invalid transfer amount: %s", amount),
 		Details: map[string]interface{}{"amount": amount},
 	}
 }
 
+func NewEmptyAccountIdError() *TransferError {
+	return &TransferError{
+		Code:    "EMPTY_ACCOUNT_ID",
+		Message: "Account id must not be empty",
+	}
+}
+
+func NewIdempotencyConflictError(requestId string) *TransferError {
+	return &TransferError{
+		Code:    "IDEMPOTENCY_CONFLICT",
+		Message: fmt.Sprintf("Request %s was already used with a different payload", requestId),
+		Details: map[string]interface{}{"requestId": requestId},
+	}
+}
+
+func NewRequestInProgressError(requestId string) *TransferError {
+	return &TransferError{
+		Code:    "REQUEST_IN_PROGRESS",
+		Message: fmt.Sprintf("Request %s is still being processed", requestId),
+		Details: map[string]interface{}{"requestId": requestId},
+	}
+}
+
+// Money errors
+func NewUnknownCurrencyError(currency string) *TransferError {
+	return &TransferError{
+		Code:    "UNKNOWN_CURRENCY",
+		Message: fmt.Sprintf("Unknown currency %q", currency),
+		Details: map[string]interface{}{"currency": currency},
+	}
+}
+
+func NewCurrencyMismatchError(expected, actual string) *TransferError {
+	return &TransferError{
+		Code:    "CURRENCY_MISMATCH",
+		Message: fmt.Sprintf("Currency mismatch: %s vs %s", expected, actual),
+		Details: map[string]interface{}{"expected": expected, "actual": actual},
+	}
+}
+
+func NewFXRateUnavailableError(from, to string) *TransferError {
+	return &TransferError{
+		Code:    "FX_RATE_UNAVAILABLE",
+		Message: fmt.Sprintf("No exchange rate available from %s to %s", from, to),
+		Details: map[string]interface{}{"from": from, "to": to},
+	}
+}
+
+func NewMalformedAmountError(input string) *TransferError {
+	return &TransferError{
+		Code:    "MALFORMED_AMOUNT",
+		Message: fmt.Sprintf("Malformed amount %q", input),
+		Details: map[string]interface{}{"input": input},
+	}
+}
+
+func NewPrecisionLossError(input, currency string) *TransferError {
+	return &TransferError{
+		Code:    "PRECISION_LOSS",
+		Message: fmt.Sprintf("Amount %s cannot be represented in %s without rounding", input, currency),
+		Details: map[string]interface{}{"input": input, "currency": currency},
+	}
+}
+
+func NewAmountOverflowError(op string) *TransferError {
+	return &TransferError{
+		Code:    "AMOUNT_OVERFLOW",
+		Message: fmt.Sprintf("Amount overflow in %s", op),
+		Details: map[string]interface{}{"operation": op},
+	}
+}
+
+func NewStorageError(operation string, err error) *TransferError {
+	return &TransferError{
+		Code:    "STORAGE_ERROR",
+		Message: fmt.Sprintf("Storage failure during %s: %v", operation, err),
+		Details: map[string]interface{}{"operation": operation},
+	}
+}
+
+// NewStorageReadError reports a storage failure met while the transfer was
+// still reading, before it wrote anything, so a new attempt cannot pay twice
+func NewStorageReadError(cause *TransferError) *TransferError {
+	return &TransferError{
+		Code:    "STORAGE_READ_FAILED",
+		Message: cause.Message,
+		Details: cause.Details,
+	}
+}
+
+// NewPartialFailureCompensatedError reports a transfer where some writes
+// failed and the ones that succeeded were reversed
+func NewPartialFailureCompensatedError(cause error, compensated []string) *TransferError {
+	return &TransferError{
+		Code:    "PARTIAL_FAILURE_COMPENSATED",
+		Message: fmt.Sprintf("Transfer failed part-way and was rolled back: %v", cause),
+		Details: map[string]interface{}{
+			"cause":       cause.Error(),
+			"compensated": compensated,
+		},
+	}
+}
+
+// NewCompensationFailedError reports a transfer that could not be rolled
+// back; the listed accounts need manual repair
+func NewCompensationFailedError(cause error, unreconciled []string, compensationErr error) *TransferError {
+	return &TransferError{
+		Code:    "COMPENSATION_FAILED",
+		Message: fmt.Sprintf("Transfer failed part-way and could not be rolled back: %v", cause),
+		Details: map[string]interface{}{
+			"cause":             cause.Error(),
+			"unreconciled":      unreconciled,
+			"compensationError": compensationErr.Error(),
+		},
+	}
+}
+
 func NewTimeoutError() *TransferError {
 	return &TransferError{
 		Code:    "TIMEOUT",
 		Message: "Operation timed out",
 	}
 }
+
+// NewBulkAbortedError marks a bulk item that was never attempted because the
+// batch stopped after too many failures
+func NewBulkAbortedError(failures int) *TransferError {
+	return &TransferError{
+		Code:    "BULK_ABORTED",
+		Message: fmt.Sprintf("Bulk transfer stopped after %d failed item(s)", failures),
+		Details: map[string]interface{}{"failures": failures},
+	}
+}
+
+// NewBatchAbortedError marks an item of an atomic batch that did not apply
+// because another item, at index, failed with cause
+func NewBatchAbortedError(index int, requestId string, cause error) *TransferError {
+	return &TransferError{
+		Code:    "BATCH_ABORTED",
+		Message: fmt.Sprintf("Atomic batch aborted by item %d: %v", index, cause),
+		Details: map[string]interface{}{
+			"abortedByIndex":     index,
+			"abortedByRequestId": requestId,
+			"cause":              ErrorCode(cause),
+		},
+	}
+}
+
 func NewCancelledError() *TransferError {
 	return &TransferError{
 		Code:    "CANCELLED",
@@ -66,3 +309,65 @@ func WrapContextError(err error) *TransferError {
 	}
 	return &TransferError{Code: "CONTEXT_ERROR", Message: err.Error()}
 }
+
+func NewHoldNotFoundError(holdId string) *TransferError {
+	return &TransferError{
+		Code:    "HOLD_NOT_FOUND",
+		Message: fmt.Sprintf("Hold %s not found", holdId),
+		Details: map[string]interface{}{"holdId": holdId},
+	}
+}
+
+// NewHoldNotOpenError reports a capture or void of a hold that no longer
+// reserves anything
+func NewHoldNotOpenError(holdId string, status HoldStatus, op string) *TransferError {
+	code := "HOLD_NOT_OPEN"
+	if status == HoldExpired {
+		code = "HOLD_EXPIRED"
+	}
+	return &TransferError{
+		Code:    code,
+		Message: fmt.Sprintf("Cannot %s hold %s while it is %s", op, holdId, status),
+		Details: map[string]interface{}{"holdId": holdId, "status": status, "operation": op},
+	}
+}
+
+func NewCaptureExceedsHoldError(holdId string, remaining, amount Money) *TransferError {
+	return &TransferError{
+		Code:    "CAPTURE_EXCEEDS_HOLD",
+		Message: fmt.Sprintf("Capture of %s exceeds the %s still held by %s", amount, remaining, holdId),
+		Details: map[string]interface{}{"holdId": holdId, "remaining": remaining, "amount": amount},
+	}
+}
+
+// NewNotReversibleError reports a reversal or refund of a transfer that
+// cannot be undone, such as a failed transfer or a refund itself
+func NewNotReversibleError(transferId, reason string) *TransferError {
+	return &TransferError{
+		Code:    "TRANSFER_NOT_REVERSIBLE",
+		Message: fmt.Sprintf("Transfer %s cannot be reversed: %s", transferId, reason),
+		Details: map[string]interface{}{"transferId": transferId, "reason": reason},
+	}
+}
+
+func NewRefundExceedsOriginalError(transferId string, refundable, amount Money) *TransferError {
+	return &TransferError{
+		Code:    "REFUND_EXCEEDS_ORIGINAL",
+		Message: fmt.Sprintf("Refund of %s exceeds the %s still refundable on %s", amount, refundable, transferId),
+		Details: map[string]interface{}{"transferId": transferId, "refundable": refundable, "amount": amount},
+	}
+}
+
+// NewReversalInsufficientFundsError reports that the original recipient no
+// longer has the money a reversal would take back
+func NewReversalInsufficientFundsError(transferId string, cause *TransferError) *TransferError {
+	details := map[string]interface{}{"transferId": transferId}
+	for k, v := range cause.Details {
+		details[k] = v
+	}
+	return &TransferError{
+		Code:    "REVERSAL_INSUFFICIENT_FUNDS",
+		Message: fmt.Sprintf("Recipient of %s cannot fund the reversal: %s", transferId, cause.Message),
+		Details: details,
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type HoldStatus string
+
+const (
+	HoldAuthorized HoldStatus = "AUTHORIZED" // funds reserved, nothing captured yet
+	HoldPartial    HoldStatus = "PARTIALLY_CAPTURED"
+	HoldCaptured   HoldStatus = "CAPTURED" // fully captured; terminal
+	HoldVoided     HoldStatus = "VOIDED"   // remainder released by request; terminal
+	HoldExpired    HoldStatus = "EXPIRED"  // remainder released on expiry; terminal
+)
+
+// Open reports whether the hold still reserves funds
+func (s HoldStatus) Open() bool {
+	return s == HoldAuthorized || s == HoldPartial
+}
+
+// Hold is an authorization: Amount of the sender's balance reserved for the
+// recipient until it is captured, voided or expires. Captures may settle it
+// in parts; Captured is what has been paid so far.
+type Hold struct {
+	ID            string
+	RequestId     string
+	FromAccountId string
+	ToAccountId   string
+	Amount        Money
+	Captured      Money
+	Captures      int // how many captures settled part of it
+	Status        HoldStatus
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Remaining is what is still reserved and may be captured
+func (h *Hold) Remaining() Money {
+	remaining, err := h.Amount.Sub(h.Captured)
+	if err != nil {
+		return Zero(h.Amount.Currency())
+	}
+	return remaining
+}
+
+// AccountBalance is an account's ledger balance split into what is held and
+// what is available to spend
+type AccountBalance struct {
+	Ledger    Money `json:"ledger"`
+	Held      Money `json:"held"`
+	Available Money `json:"available"`
+}
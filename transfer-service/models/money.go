@@ -0,0 +1,321 @@
+// File: models/money.go
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// DefaultCurrency is the currency assumed for UPI accounts
+const DefaultCurrency = "INR"
+
+// currencyExponents maps ISO-4217 codes to the number of minor-unit digits
+var currencyExponents = map[string]int{
+	"INR": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"SGD": 2,
+	"AED": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"BHD": 3,
+}
+
+// CurrencyExponent returns the number of minor-unit digits for an ISO-4217 code
+func CurrencyExponent(currency string) (int, bool) {
+	exp, ok := currencyExponents[currency]
+	return exp, ok
+}
+
+// RoundingMode selects how a value that does not fit in minor units is rounded.
+// The zero value, RoundExact, refuses to round so that precision is never lost silently.
+type RoundingMode int
+
+const (
+	RoundExact    RoundingMode = iota // fail instead of rounding
+	RoundHalfEven                     // banker's rounding
+	RoundHalfUp                       // ties away from zero
+	RoundDown                         // towards zero
+	RoundUp                           // away from zero
+)
+
+// Money is an exact amount held as an integer count of minor units (e.g. paise)
+// together with its ISO-4217 currency code.
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// NewMoney builds a Money from a minor-unit amount
+func NewMoney(minor int64, currency string) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(currency string) Money {
+	return Money{currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "1000.50" exactly.
+// Digits beyond the currency's precision are rejected unless they are zeros.
+func ParseMoney(s, currency string) (Money, error) {
+	return ParseMoneyRounded(s, currency, RoundExact)
+}
+
+// MustParseMoney is like ParseMoney but panics on error; meant for fixtures
+func MustParseMoney(s, currency string) Money {
+	m, err := ParseMoney(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// ParseMoneyRounded parses a decimal string, rounding extra digits with mode
+func ParseMoneyRounded(s, currency string, mode RoundingMode) (Money, error) {
+	exp, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, NewUnknownCurrencyError(currency)
+	}
+
+	raw := strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(raw, "-"):
+		neg, raw = true, raw[1:]
+	case strings.HasPrefix(raw, "+"):
+		raw = raw[1:]
+	}
+	intPart, fracPart, hasPoint := strings.Cut(raw, ".")
+	if intPart == "" || (hasPoint && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Money{}, NewMalformedAmountError(s)
+	}
+
+	num, _ := new(big.Int).SetString(intPart+fracPart, 10)
+	if neg {
+		num.Neg(num)
+	}
+	// num / 10^len(frac) is the parsed value; scale it to minor units
+	num.Mul(num, pow10(exp))
+	minor, err := roundQuo(num, pow10(len(fracPart)), mode)
+	if err != nil {
+		return Money{}, NewPrecisionLossError(s, currency)
+	}
+	if !minor.IsInt64() {
+		return Money{}, NewAmountOverflowError("parse")
+	}
+	return Money{minor: minor.Int64(), currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundQuo divides num by den (den > 0) and rounds the quotient with mode
+func roundQuo(num, den *big.Int, mode RoundingMode) (*big.Int, error) {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q, nil
+	}
+
+	away := false
+	switch mode {
+	case RoundExact:
+		return nil, fmt.Errorf("inexact result")
+	case RoundDown:
+	case RoundUp:
+		away = true
+	case RoundHalfUp, RoundHalfEven:
+		twice := new(big.Int).Abs(r)
+		twice.Lsh(twice, 1)
+		switch twice.Cmp(den) {
+		case 1:
+			away = true
+		case 0:
+			away = mode == RoundHalfUp || q.Bit(0) == 1
+		}
+	default:
+		return nil, fmt.Errorf("unknown rounding mode %d", mode)
+	}
+	if away {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q, nil
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 { return m.minor }
+
+// Currency returns the ISO-4217 currency code
+func (m Money) Currency() string { return m.currency }
+
+func (m Money) IsZero() bool     { return m.minor == 0 }
+func (m Money) IsPositive() bool { return m.minor > 0 }
+func (m Money) IsNegative() bool { return m.minor < 0 }
+
+// Add returns m+o; both must share a currency and the sum must fit in int64
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	if (o.minor > 0 && m.minor > math.MaxInt64-o.minor) ||
+		(o.minor < 0 && m.minor < math.MinInt64-o.minor) {
+		return Money{}, NewAmountOverflowError("add")
+	}
+	return Money{minor: m.minor + o.minor, currency: m.currency}, nil
+}
+
+// Sub returns m-o; both must share a currency and the difference must fit in int64
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	if (o.minor < 0 && m.minor > math.MaxInt64+o.minor) ||
+		(o.minor > 0 && m.minor < math.MinInt64+o.minor) {
+		return Money{}, NewAmountOverflowError("sub")
+	}
+	return Money{minor: m.minor - o.minor, currency: m.currency}, nil
+}
+
+// Neg returns -m
+func (m Money) Neg() (Money, error) {
+	if m.minor == math.MinInt64 {
+		return Money{}, NewAmountOverflowError("neg")
+	}
+	return Money{minor: -m.minor, currency: m.currency}, nil
+}
+
+// MulRat returns m*num/den rounded with mode; den must be positive
+func (m Money) MulRat(num, den int64, mode RoundingMode) (Money, error) {
+	if den <= 0 {
+		return Money{}, fmt.Errorf("money: non-positive denominator %d", den)
+	}
+	p := new(big.Int).Mul(big.NewInt(m.minor), big.NewInt(num))
+	q, err := roundQuo(p, big.NewInt(den), mode)
+	if err != nil {
+		return Money{}, NewPrecisionLossError(fmt.Sprintf("%s*%d/%d", m, num, den), m.currency)
+	}
+	if !q.IsInt64() {
+		return Money{}, NewAmountOverflowError("mul")
+	}
+	return Money{minor: q.Int64(), currency: m.currency}, nil
+}
+
+// Convert returns m expressed in currency at rate (units of currency per
+// unit of m's currency), rounded to the target's minor units with mode
+func (m Money) Convert(currency string, rate *big.Rat, mode RoundingMode) (Money, error) {
+	exp, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, NewUnknownCurrencyError(currency)
+	}
+	if rate == nil || rate.Sign() <= 0 {
+		return Money{}, fmt.Errorf("money: non-positive rate %v", rate)
+	}
+	srcExp := currencyExponents[m.currency]
+	// minor * rate * 10^exp / 10^srcExp
+	num := new(big.Int).Mul(big.NewInt(m.minor), rate.Num())
+	num.Mul(num, pow10(exp))
+	den := new(big.Int).Mul(rate.Denom(), pow10(srcExp))
+	q, err := roundQuo(num, den, mode)
+	if err != nil {
+		return Money{}, NewPrecisionLossError(fmt.Sprintf("%s*%s", m, rate.RatString()), currency)
+	}
+	if !q.IsInt64() {
+		return Money{}, NewAmountOverflowError("convert")
+	}
+	return Money{minor: q.Int64(), currency: currency}, nil
+}
+
+// Cmp compares m and o, returning -1, 0 or +1; both must share a currency
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.minor < o.minor:
+		return -1, nil
+	case m.minor > o.minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.currency != o.currency {
+		return NewCurrencyMismatchError(m.currency, o.currency)
+	}
+	return nil
+}
+
+// Decimal formats the amount without its currency, e.g. "1000.50"
+func (m Money) Decimal() string {
+	exp := currencyExponents[m.currency]
+	v := new(big.Int).Abs(big.NewInt(m.minor)).String()
+	sign := ""
+	if m.minor < 0 {
+		sign = "-"
+	}
+	if exp == 0 {
+		return sign + v
+	}
+	if len(v) <= exp {
+		v = strings.Repeat("0", exp-len(v)+1) + v
+	}
+	return sign + v[:len(v)-exp] + "." + v[len(v)-exp:]
+}
+
+// String formats the amount with its currency, e.g. "1000.50 INR"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.currency
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON encodes Money as {"amount":"1000.50","currency":"INR"}.
+// The amount is a string so that JSON consumers never see a binary float.
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, _ := json.Marshal(m.Decimal())
+	return json.Marshal(moneyJSON{Amount: amount, Currency: m.currency})
+}
+
+// UnmarshalJSON accepts the amount as a string or a bare JSON number; either
+// way it is parsed from its decimal text and never goes through float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	text := string(v.Amount)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(v.Amount, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseMoney(text, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -1,14 +1,97 @@
 package models
 
+import "time"
+
 type TransferRequest struct {
 	FromAccountId string
 	ToAccountId   string
-	Amount        float64
+	Amount        Money
 	RequestId     string
 }
 
 type TransferResult struct {
-	RequestId string
-	Success   bool
-	Error     error
+	RequestId  string
+	TransferId string // history record id, when transfer history is kept
+	Success    bool
+	Error      error
+	Replayed   bool          // true when returned from the idempotency store
+	Conversion *Conversion   // set when the accounts are held in different currencies
+	Fees       *FeeBreakdown // set when the sender was charged
+	// FeesReturned is set when a full reversal paid the original's fees back
+	FeesReturned *FeeBreakdown
+}
+
+// Conversion records how a cross-currency transfer was priced. The sender is
+// debited Source; Fee is kept from it and the rest is converted at Rate.
+type Conversion struct {
+	Rate      string `json:"rate"` // units of Converted's currency per unit of Source's, e.g. "83.25"
+	Source    Money  `json:"source"`
+	Fee       Money  `json:"fee"`
+	Converted Money  `json:"converted"`
+}
+
+type TransferStatus string
+
+const (
+	TransferCompleted TransferStatus = "COMPLETED"
+	TransferFailed    TransferStatus = "FAILED"
+)
+
+// FeeBreakdown lists the fees charged on a transfer. They are debited from
+// the sender on top of the amount and credited to the revenue account.
+type FeeBreakdown struct {
+	Components       []FeeComponent `json:"components"`
+	Total            Money          `json:"total"`
+	RevenueAccountId string         `json:"revenueAccountId"`
+}
+
+type FeeComponent struct {
+	Name   string `json:"name"`
+	Amount Money  `json:"amount"`
+}
+
+// Transfer is the stored history record of one transfer attempt
+type Transfer struct {
+	ID            string
+	Sequence      int64 // assigned by the store; orders records and backs cursors
+	RequestId     string
+	FromAccountId string
+	ToAccountId   string
+	Amount        Money
+	Conversion    *Conversion   // nil for same-currency transfers
+	Fees          *FeeBreakdown // nil when nothing was charged
+	FeesReturned  *FeeBreakdown // fees of the original a full reversal paid back
+	Status        TransferStatus
+	ErrorCode     string
+	CreatedAt     time.Time
+	CompletedAt   time.Time
+
+	// ReversalOf links a reversal or refund to the transfer it pays back
+	ReversalOf string
+	// Refunded is how much has been paid back, in the currency the
+	// recipient was credited; Refunds lists the linked transfers that did it
+	// and ReversalId the one made by a full reversal
+	Refunded   Money
+	Refunds    []string
+	ReversalId string
+	// Version is the store's optimistic-lock counter; UpdateTransfer refuses
+	// a record changed since it was read
+	Version int64
+}
+
+// Credited is what the recipient received: the converted amount of a
+// cross-currency transfer, the amount otherwise
+func (t *Transfer) Credited() Money {
+	if t.Conversion != nil {
+		return t.Conversion.Converted
+	}
+	return t.Amount
+}
+
+// Refundable is how much of the credit has not been paid back yet
+func (t *Transfer) Refundable() (Money, error) {
+	if t.Refunded.Currency() == "" {
+		return t.Credited(), nil
+	}
+	return t.Credited().Sub(t.Refunded)
 }
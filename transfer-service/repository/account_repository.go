@@ -6,7 +6,35 @@ import (
 )
 
 type AccountRepository interface {
+	CreateAccount(ctx context.Context, account *models.Account) error
 	GetAccountById(ctx context.Context, accountId string) (*models.Account, error)
 	UpdateAccount(ctx context.Context, account *models.Account) error
 	GetMultipleAccounts(ctx context.Context, accountIds []string) ([]*models.Account, error)
 }
+
+// AccountLister is implemented by stores that can enumerate every account
+// they hold, which reconciliation needs to find accounts the ledger missed
+type AccountLister interface {
+	// ListAccountIds returns the id of every stored account, sorted
+	ListAccountIds(ctx context.Context) ([]string, error)
+}
+
+// ListAccountIds enumerates repo's accounts, failing when it cannot
+func ListAccountIds(ctx context.Context, repo AccountRepository) ([]string, error) {
+	lister, ok := repo.(AccountLister)
+	if !ok {
+		return nil, models.NewAccountListingNotSupportedError()
+	}
+	return lister.ListAccountIds(ctx)
+}
+
+// TransactionalAccountRepository is implemented by stores that can persist
+// every side of a transfer atomically. UPITransferService uses it when
+// available instead of independent UpdateAccount calls.
+type TransactionalAccountRepository interface {
+	AccountRepository
+	// CommitTransfer writes every account a transfer touched (sender,
+	// recipient and any fee revenue account) in one transaction; either all
+	// rows change or none does
+	CommitTransfer(ctx context.Context, accounts ...*models.Account) error
+}
@@ -0,0 +1,329 @@
+// File: repository/cached_account_repository.go
+package repository
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+	"transfer-service/models"
+)
+
+var (
+	_ AccountRepository              = (*CachedRepository)(nil)
+	_ AccountLister                  = (*CachedRepository)(nil)
+	_ TransactionalAccountRepository = (*CachedTransactionalRepository)(nil)
+	_ TransactionalOutboxRepository  = (*CachedOutboxRepository)(nil)
+)
+
+const (
+	defaultCacheTTL  = 5 * time.Second
+	defaultCacheSize = 10000
+)
+
+// CacheStats counts how the cache answered GetAccountById
+type CacheStats struct {
+	Hits          int64 // answered from the cache
+	Misses        int64 // read from the store, counting coalesced callers once
+	Coalesced     int64 // misses that waited for another caller's read
+	Evictions     int64 // entries dropped to stay within the size bound
+	Expirations   int64 // entries found past their TTL
+	Invalidations int64 // entries dropped by a failed write or Invalidate
+	Size          int   // entries held now
+}
+
+// HitRate is the share of lookups answered from the cache
+func (s CacheStats) HitRate() float64 {
+	lookups := s.Hits + s.Misses + s.Coalesced
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(lookups)
+}
+
+// CacheOption configures a CachedRepository
+type CacheOption func(*CachedRepository)
+
+// WithCacheTTL sets how long an account is served without asking the store
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(r *CachedRepository) { r.ttl = ttl }
+}
+
+// WithCacheSize bounds how many accounts are kept; the least recently used
+// one makes room for a new one
+func WithCacheSize(size int) CacheOption {
+	return func(r *CachedRepository) { r.size = size }
+}
+
+// WithCacheClock replaces time.Now, so tests can expire entries
+func WithCacheClock(now func() time.Time) CacheOption {
+	return func(r *CachedRepository) { r.now = now }
+}
+
+// CachedRepository answers GetAccountById from memory and reads through to
+// the wrapped store on a miss, with concurrent misses for one account
+// sharing a single read.
+//
+// A cached balance never decides a debit on its own. GetMultipleAccounts,
+// which transfers load their accounts with, always reads the store and
+// refreshes the cache with what it finds. Writes store the written account
+// and a failed write drops the entry, so a versioned store that rejects a
+// change made to a stale copy makes the retry read afresh. Writes that
+// bypass this repository show up in GetAccountById once the TTL passes.
+type CachedRepository struct {
+	inner   AccountRepository
+	ttl     time.Duration
+	size    int
+	now     func() time.Time
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+	flights map[string]*flight
+	stats   CacheStats
+	mutex   sync.Mutex
+}
+
+type cacheEntry struct {
+	account *models.Account
+	expires time.Time
+}
+
+// flight is one store read that callers missing the same account wait on
+type flight struct {
+	done    chan struct{}
+	account *models.Account
+	err     error
+	discard bool // a write overtook the read, so its result is not cached
+}
+
+func NewCachedRepository(inner AccountRepository, opts ...CacheOption) *CachedRepository {
+	r := &CachedRepository{
+		inner:   inner,
+		ttl:     defaultCacheTTL,
+		size:    defaultCacheSize,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		flights: make(map[string]*flight),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Stats reports the counters since the repository was created
+func (r *CachedRepository) Stats() CacheStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	stats := r.stats
+	stats.Size = r.lru.Len()
+	return stats
+}
+
+// Invalidate drops accountId from the cache, for writes made around it
+func (r *CachedRepository) Invalidate(accountId string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.dropLocked(accountId) {
+		r.stats.Invalidations++
+	}
+}
+
+func (r *CachedRepository) GetAccountById(ctx context.Context, accountId string) (*models.Account, error) {
+	for {
+		r.mutex.Lock()
+		if acc, ok := r.lookupLocked(accountId); ok {
+			r.stats.Hits++
+			r.mutex.Unlock()
+			return acc, nil
+		}
+		if f, ok := r.flights[accountId]; ok {
+			r.stats.Coalesced++
+			r.mutex.Unlock()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return nil, models.WrapContextError(ctx.Err())
+			}
+			if isContextError(f.err) && ctx.Err() == nil {
+				// the caller that read for us gave up; read again ourselves
+				continue
+			}
+			return f.account, f.err
+		}
+		f := &flight{done: make(chan struct{})}
+		r.flights[accountId] = f
+		r.stats.Misses++
+		r.mutex.Unlock()
+
+		f.account, f.err = r.inner.GetAccountById(ctx, accountId)
+
+		r.mutex.Lock()
+		if r.flights[accountId] == f {
+			delete(r.flights, accountId)
+		}
+		if f.err == nil && !f.discard {
+			r.storeLocked(f.account)
+		}
+		r.mutex.Unlock()
+		close(f.done)
+		return f.account, f.err
+	}
+}
+
+// GetMultipleAccounts always reads the store; see CachedRepository
+func (r *CachedRepository) GetMultipleAccounts(ctx context.Context, accountIds []string) ([]*models.Account, error) {
+	accounts, err := r.inner.GetMultipleAccounts(ctx, accountIds)
+	if err != nil {
+		return nil, err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, acc := range accounts {
+		r.overtakeLocked(acc.ID)
+		r.storeLocked(acc)
+	}
+	return accounts, nil
+}
+
+// ListAccountIds always asks the store, which alone knows every account
+func (r *CachedRepository) ListAccountIds(ctx context.Context) ([]string, error) {
+	return ListAccountIds(ctx, r.inner)
+}
+
+func (r *CachedRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	return r.write(account, r.inner.CreateAccount(ctx, account))
+}
+
+func (r *CachedRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+	return r.write(account, r.inner.UpdateAccount(ctx, account))
+}
+
+// write caches account if its write succeeded and drops it otherwise,
+// passing err through
+func (r *CachedRepository) write(account *models.Account, err error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.overtakeLocked(account.ID)
+	if err != nil {
+		if r.dropLocked(account.ID) {
+			r.stats.Invalidations++
+		}
+		return err
+	}
+	r.storeLocked(account)
+	return nil
+}
+
+// lookupLocked returns the cached account unless it is missing or expired
+func (r *CachedRepository) lookupLocked(accountId string) (*models.Account, bool) {
+	el, ok := r.entries[accountId]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if !r.now().Before(entry.expires) {
+		r.dropLocked(accountId)
+		r.stats.Expirations++
+		return nil, false
+	}
+	r.lru.MoveToFront(el)
+	return entry.account, true
+}
+
+func (r *CachedRepository) storeLocked(account *models.Account) {
+	if r.size <= 0 {
+		return
+	}
+	expires := r.now().Add(r.ttl)
+	if el, ok := r.entries[account.ID]; ok {
+		el.Value = &cacheEntry{account: account, expires: expires}
+		r.lru.MoveToFront(el)
+		return
+	}
+	r.entries[account.ID] = r.lru.PushFront(&cacheEntry{account: account, expires: expires})
+	for r.lru.Len() > r.size {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.entries, oldest.Value.(*cacheEntry).account.ID)
+		r.stats.Evictions++
+	}
+}
+
+// dropLocked removes accountId and reports whether it was cached; a read
+// still in flight for it is no longer cached when it lands
+func (r *CachedRepository) dropLocked(accountId string) bool {
+	r.overtakeLocked(accountId)
+	el, ok := r.entries[accountId]
+	if !ok {
+		return false
+	}
+	r.lru.Remove(el)
+	delete(r.entries, accountId)
+	return true
+}
+
+// overtakeLocked keeps a read started before a newer write from caching
+// what it found; callers already waiting on it still get its result
+func (r *CachedRepository) overtakeLocked(accountId string) {
+	if f, ok := r.flights[accountId]; ok {
+		f.discard = true
+		delete(r.flights, accountId)
+	}
+}
+
+func isContextError(err error) bool {
+	code := models.ErrorCode(err)
+	return code == "TIMEOUT" || code == "CANCELLED"
+}
+
+// CachedTransactionalRepository is a CachedRepository over a store that
+// commits transfers atomically
+type CachedTransactionalRepository struct {
+	*CachedRepository
+	inner TransactionalAccountRepository
+}
+
+func NewCachedTransactionalRepository(inner TransactionalAccountRepository, opts ...CacheOption) *CachedTransactionalRepository {
+	return &CachedTransactionalRepository{CachedRepository: NewCachedRepository(inner, opts...), inner: inner}
+}
+
+func (r *CachedTransactionalRepository) CommitTransfer(ctx context.Context, accounts ...*models.Account) error {
+	return r.writeAll(accounts, r.inner.CommitTransfer(ctx, accounts...))
+}
+
+// writeAll is write for every account of one commit
+func (r *CachedRepository) writeAll(accounts []*models.Account, err error) error {
+	for _, acc := range accounts {
+		r.write(acc, err)
+	}
+	return err
+}
+
+// CachedOutboxRepository is a CachedTransactionalRepository over a store
+// that keeps the outbox next to the accounts, so that a transfer's events
+// still commit with it. The outbox itself is never cached.
+type CachedOutboxRepository struct {
+	*CachedTransactionalRepository
+	inner TransactionalOutboxRepository
+}
+
+func NewCachedOutboxRepository(inner TransactionalOutboxRepository, opts ...CacheOption) *CachedOutboxRepository {
+	return &CachedOutboxRepository{CachedTransactionalRepository: NewCachedTransactionalRepository(inner, opts...), inner: inner}
+}
+
+func (r *CachedOutboxRepository) CommitTransferWithOutbox(ctx context.Context, records []*OutboxRecord, accounts ...*models.Account) error {
+	return r.writeAll(accounts, r.inner.CommitTransferWithOutbox(ctx, records, accounts...))
+}
+
+func (r *CachedOutboxRepository) Append(ctx context.Context, records ...*OutboxRecord) error {
+	return r.inner.Append(ctx, records...)
+}
+
+func (r *CachedOutboxRepository) Pending(ctx context.Context, limit int) ([]*OutboxRecord, error) {
+	return r.inner.Pending(ctx, limit)
+}
+
+func (r *CachedOutboxRepository) MarkPublished(ctx context.Context, sequences ...int64) error {
+	return r.inner.MarkPublished(ctx, sequences...)
+}
@@ -0,0 +1,261 @@
+// File: repository/db_account_repository.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"transfer-service/models"
+)
+
+// DBAccountRepository stores accounts in a database/sql database. Writes use
+// the version column for optimistic locking: an update only succeeds if the
+// row still has the version the account was read with.
+type DBAccountRepository struct {
+	db *sql.DB
+}
+
+// NewDBAccountRepository migrates the schema and returns a repository over db
+func NewDBAccountRepository(ctx context.Context, db *sql.DB) (*DBAccountRepository, error) {
+	if err := Migrate(ctx, db); err != nil {
+		return nil, err
+	}
+	return &DBAccountRepository{db: db}, nil
+}
+
+// SeedAccounts inserts accounts, leaving any that already exist untouched
+func (r *DBAccountRepository) SeedAccounts(ctx context.Context, accounts ...*models.Account) error {
+	for _, acc := range accounts {
+		balance := acc.GetBalance()
+		_, err := r.db.ExecContext(ctx,
+			`INSERT OR IGNORE INTO accounts (id, name, balance_minor, currency, type, status, version) VALUES (?, ?, ?, ?, ?, ?, 0)`,
+			acc.ID, acc.Name, balance.Minor(), balance.Currency(), acc.GetType(), acc.GetStatus())
+		if err != nil {
+			return storageError("seed", err)
+		}
+	}
+	return nil
+}
+
+func (r *DBAccountRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	balance, status := account.GetBalance(), account.GetStatus()
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO accounts (id, name, balance_minor, currency, type, status, version) VALUES (?, ?, ?, ?, ?, ?, 0)
+		 ON CONFLICT (id) DO NOTHING`,
+		account.ID, account.Name, balance.Minor(), balance.Currency(), account.GetType(), status)
+	if err != nil {
+		return storageError("create account", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return storageError("create account", err)
+	} else if n == 0 {
+		return models.NewAccountAlreadyExistsError(account.ID)
+	}
+	account.Mutex.Lock()
+	account.Version = 0
+	account.Mutex.Unlock()
+	return nil
+}
+
+func (r *DBAccountRepository) GetAccountById(ctx context.Context, accountId string) (*models.Account, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, name, balance_minor, held_minor, currency, type, status, version FROM accounts WHERE id = ?`, accountId)
+	acc, err := scanAccount(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.NewAccountNotFoundError(accountId)
+	}
+	if err != nil {
+		return nil, storageError("get account", err)
+	}
+	return acc, nil
+}
+
+func (r *DBAccountRepository) GetMultipleAccounts(ctx context.Context, accountIds []string) ([]*models.Account, error) {
+	if len(accountIds) == 0 {
+		return []*models.Account{}, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(accountIds)), ",")
+	args := make([]interface{}, len(accountIds))
+	for i, id := range accountIds {
+		args[i] = id
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, balance_minor, held_minor, currency, type, status, version FROM accounts WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, storageError("get accounts", err)
+	}
+	defer rows.Close()
+
+	byId := make(map[string]*models.Account, len(accountIds))
+	for rows.Next() {
+		acc, err := scanAccount(rows)
+		if err != nil {
+			return nil, storageError("get accounts", err)
+		}
+		byId[acc.ID] = acc
+	}
+	if err := rows.Err(); err != nil {
+		return nil, storageError("get accounts", err)
+	}
+
+	// keep the caller's order; a repeated id shares one account object
+	accounts := make([]*models.Account, len(accountIds))
+	for i, id := range accountIds {
+		acc, ok := byId[id]
+		if !ok {
+			return nil, models.NewAccountNotFoundError(id)
+		}
+		accounts[i] = acc
+	}
+	return accounts, nil
+}
+
+func (r *DBAccountRepository) ListAccountIds(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id FROM accounts ORDER BY id`)
+	if err != nil {
+		return nil, storageError("list accounts", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, storageError("list accounts", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, storageError("list accounts", err)
+	}
+	return ids, nil
+}
+
+func (r *DBAccountRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storageError("begin", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateVersioned(ctx, tx, account); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return storageError("commit", err)
+	}
+	bumpVersion(account)
+	return nil
+}
+
+// CommitTransfer writes every account of a transfer in a single transaction
+func (r *DBAccountRepository) CommitTransfer(ctx context.Context, accounts ...*models.Account) error {
+	return r.CommitTransferWithOutbox(ctx, nil, accounts...)
+}
+
+// CommitTransferWithOutbox writes the accounts and appends the records to the
+// outbox in a single transaction
+func (r *DBAccountRepository) CommitTransferWithOutbox(ctx context.Context, records []*OutboxRecord, accounts ...*models.Account) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storageError("begin", err)
+	}
+	defer tx.Rollback()
+
+	for _, acc := range accounts {
+		if err := updateVersioned(ctx, tx, acc); err != nil {
+			return err
+		}
+	}
+	sequences, err := appendOutbox(ctx, tx, records)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return storageError("commit", err)
+	}
+	for _, acc := range accounts {
+		bumpVersion(acc)
+	}
+	for i, r := range records {
+		r.Sequence = sequences[i]
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccount(row rowScanner) (*models.Account, error) {
+	var (
+		acc      models.Account
+		minor    int64
+		held     int64
+		currency string
+		kind     string
+		status   string
+	)
+	if err := row.Scan(&acc.ID, &acc.Name, &minor, &held, &currency, &kind, &status, &acc.Version); err != nil {
+		return nil, err
+	}
+	acc.Balance = models.NewMoney(minor, currency)
+	if held != 0 {
+		acc.Held = models.NewMoney(held, currency)
+	}
+	acc.Currency = currency
+	acc.Type = models.AccountType(kind)
+	acc.Status = models.AccountStatus(status)
+	return &acc, nil
+}
+
+// updateVersioned writes account if its row still carries account.Version
+func updateVersioned(ctx context.Context, tx *sql.Tx, account *models.Account) error {
+	account.Mutex.RLock()
+	balance, held, version := account.Balance, account.HeldLocked(), account.Version
+	account.Mutex.RUnlock()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE accounts SET name = ?, balance_minor = ?, held_minor = ?, currency = ?, type = ?, status = ?, version = version + 1
+		 WHERE id = ? AND version = ?`,
+		account.Name, balance.Minor(), held.Minor(), balance.Currency(), account.GetType(), account.GetStatus(), account.ID, version)
+	if err != nil {
+		return storageError("update account", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return storageError("update account", err)
+	}
+	if n == 1 {
+		return nil
+	}
+
+	var exists int
+	err = tx.QueryRowContext(ctx, `SELECT 1 FROM accounts WHERE id = ?`, account.ID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.NewAccountNotFoundError(account.ID)
+	}
+	if err != nil {
+		return storageError("update account", err)
+	}
+	return models.NewConcurrentModificationError(account.ID, version)
+}
+
+func bumpVersion(account *models.Account) {
+	account.Mutex.Lock()
+	account.Version++
+	account.Mutex.Unlock()
+}
+
+// storageError keeps context cancellation distinguishable from driver failures
+func storageError(operation string, err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return models.NewCancelledError()
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.NewTimeoutError()
+	}
+	return models.NewStorageError(operation, err)
+}
@@ -0,0 +1,139 @@
+// File: repository/db_hold_repository.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"transfer-service/models"
+)
+
+// The holds table lives next to the accounts, so a DBAccountRepository is
+// also a HoldRepository
+
+const holdColumns = `id, request_id, from_account_id, to_account_id, amount_minor, captured_minor, currency,
+	captures, status, created_at, expires_at, updated_at`
+
+func (r *DBAccountRepository) SaveHold(ctx context.Context, hold *models.Hold) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storageError("begin", err)
+	}
+	defer tx.Rollback()
+
+	id := hold.ID
+	if id == "" {
+		// ids follow the table's rowid, so they are allocated inside the
+		// transaction that claims it
+		var next int64
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(rowid), 0) + 1 FROM holds`).Scan(&next); err != nil {
+			return storageError("save hold", err)
+		}
+		id = fmt.Sprintf("HLD-%08d", next)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO holds (`+holdColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, hold.RequestId, hold.FromAccountId, hold.ToAccountId, hold.Amount.Minor(), hold.Captured.Minor(),
+		hold.Amount.Currency(), hold.Captures, hold.Status,
+		formatTime(hold.CreatedAt), formatTime(hold.ExpiresAt), formatTime(hold.UpdatedAt))
+	if err != nil {
+		return storageError("save hold", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return storageError("commit", err)
+	}
+	hold.ID = id
+	return nil
+}
+
+func (r *DBAccountRepository) GetHold(ctx context.Context, holdId string) (*models.Hold, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+holdColumns+` FROM holds WHERE id = ?`, holdId)
+	h, err := scanHold(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.NewHoldNotFoundError(holdId)
+	}
+	if err != nil {
+		return nil, storageError("get hold", err)
+	}
+	return h, nil
+}
+
+func (r *DBAccountRepository) UpdateHold(ctx context.Context, hold *models.Hold) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE holds SET captured_minor = ?, captures = ?, status = ?, expires_at = ?, updated_at = ? WHERE id = ?`,
+		hold.Captured.Minor(), hold.Captures, hold.Status, formatTime(hold.ExpiresAt), formatTime(hold.UpdatedAt), hold.ID)
+	if err != nil {
+		return storageError("update hold", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return storageError("update hold", err)
+	} else if n == 0 {
+		return models.NewHoldNotFoundError(hold.ID)
+	}
+	return nil
+}
+
+func (r *DBAccountRepository) ExpiredHolds(ctx context.Context, asOf time.Time, limit int) ([]*models.Hold, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+holdColumns+` FROM holds WHERE status IN (?, ?) AND expires_at <= ? ORDER BY expires_at, id LIMIT ?`,
+		models.HoldAuthorized, models.HoldPartial, formatTime(asOf), limit)
+	if err != nil {
+		return nil, storageError("read holds", err)
+	}
+	defer rows.Close()
+
+	var holds []*models.Hold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, storageError("read holds", err)
+		}
+		holds = append(holds, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, storageError("read holds", err)
+	}
+	return holds, nil
+}
+
+func scanHold(row rowScanner) (*models.Hold, error) {
+	var (
+		h                            models.Hold
+		amount, captured             int64
+		currency, status             string
+		createdAt, expiresAt, update string
+	)
+	if err := row.Scan(&h.ID, &h.RequestId, &h.FromAccountId, &h.ToAccountId, &amount, &captured, &currency,
+		&h.Captures, &status, &createdAt, &expiresAt, &update); err != nil {
+		return nil, err
+	}
+	h.Amount, h.Captured = models.NewMoney(amount, currency), models.NewMoney(captured, currency)
+	h.Status = models.HoldStatus(status)
+	var err error
+	if h.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if h.ExpiresAt, err = parseTime(expiresAt); err != nil {
+		return nil, err
+	}
+	if h.UpdatedAt, err = parseTime(update); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// times are stored as fixed-width UTC text so that they compare correctly
+// as strings
+const storedTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(storedTimeLayout)
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(storedTimeLayout, s)
+}
@@ -0,0 +1,97 @@
+// File: repository/db_outbox.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Append stores records in the outbox table, which lives in the same
+// database as the accounts so that CommitTransferWithOutbox can share a
+// transaction with them
+func (r *DBAccountRepository) Append(ctx context.Context, records ...*OutboxRecord) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storageError("begin", err)
+	}
+	defer tx.Rollback()
+
+	sequences, err := appendOutbox(ctx, tx, records)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return storageError("commit", err)
+	}
+	for i, rec := range records {
+		rec.Sequence = sequences[i]
+	}
+	return nil
+}
+
+func (r *DBAccountRepository) Pending(ctx context.Context, limit int) ([]*OutboxRecord, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT sequence, type, payload, created_at FROM outbox WHERE published_at IS NULL ORDER BY sequence LIMIT ?`, limit)
+	if err != nil {
+		return nil, storageError("read outbox", err)
+	}
+	defer rows.Close()
+
+	var records []*OutboxRecord
+	for rows.Next() {
+		var (
+			rec       OutboxRecord
+			createdAt string
+		)
+		if err := rows.Scan(&rec.Sequence, &rec.Type, &rec.Payload, &createdAt); err != nil {
+			return nil, storageError("read outbox", err)
+		}
+		if rec.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+			return nil, storageError("read outbox", err)
+		}
+		records = append(records, &rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, storageError("read outbox", err)
+	}
+	return records, nil
+}
+
+func (r *DBAccountRepository) MarkPublished(ctx context.Context, sequences ...int64) error {
+	if len(sequences) == 0 {
+		return nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(sequences)), ",")
+	args := []interface{}{time.Now().UTC().Format(time.RFC3339Nano)}
+	for _, seq := range sequences {
+		args = append(args, seq)
+	}
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET published_at = ? WHERE sequence IN (`+placeholders+`)`, args...); err != nil {
+		return storageError("mark published", err)
+	}
+	return nil
+}
+
+// appendOutbox inserts records inside tx and returns the sequences assigned,
+// which the caller applies once tx has committed
+func appendOutbox(ctx context.Context, tx *sql.Tx, records []*OutboxRecord) ([]int64, error) {
+	sequences := make([]int64, len(records))
+	for i, rec := range records {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO outbox (type, payload, created_at) VALUES (?, ?, ?)`,
+			rec.Type, rec.Payload, rec.CreatedAt.UTC().Format(time.RFC3339Nano))
+		if err != nil {
+			return nil, storageError("append outbox", err)
+		}
+		if sequences[i], err = res.LastInsertId(); err != nil {
+			return nil, storageError("append outbox", err)
+		}
+	}
+	return sequences, nil
+}
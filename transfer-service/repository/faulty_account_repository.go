@@ -0,0 +1,361 @@
+// File: repository/faulty_account_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+	"transfer-service/models"
+)
+
+var (
+	_ AccountRepository              = (*FaultyRepository)(nil)
+	_ AccountLister                  = (*FaultyRepository)(nil)
+	_ TransactionalAccountRepository = (*FaultyTransactionalRepository)(nil)
+	_ TransactionalOutboxRepository  = (*FaultyOutboxRepository)(nil)
+)
+
+// Method names faults are configured under. GetMultipleAccounts is faulted
+// as one call of its own, apart from GetAccountById.
+const (
+	MethodGetAccountById      = "GetAccountById"
+	MethodCreateAccount       = "CreateAccount"
+	MethodUpdateAccount       = "UpdateAccount"
+	MethodGetMultipleAccounts = "GetMultipleAccounts"
+	MethodCommitTransfer      = "CommitTransfer"
+	MethodListAccountIds      = "ListAccountIds"
+
+	MethodCommitTransferWithOutbox = "CommitTransferWithOutbox"
+	MethodAppend                   = "Append"
+	MethodPending                  = "Pending"
+	MethodMarkPublished            = "MarkPublished"
+)
+
+// Latency draws the delay of one call
+type Latency func(r *rand.Rand) time.Duration
+
+// FixedLatency delays every call by d
+func FixedLatency(d time.Duration) Latency {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// UniformLatency delays calls by anything from min up to max
+func UniformLatency(min, max time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)+1))
+	}
+}
+
+// NormalLatency delays calls around mean; draws below zero mean no delay
+func NormalLatency(mean, stddev time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		if d := time.Duration(r.NormFloat64()*float64(stddev)) + mean; d > 0 {
+			return d
+		}
+		return 0
+	}
+}
+
+// Fault describes how calls to one method misbehave
+type Fault struct {
+	Latency   Latency // delay before each call; nil for none
+	ErrorRate float64 // share of calls that fail with Err
+	HangRate  float64 // share of calls that block until their context ends
+	// FailCalls lists call numbers, counted from 1 since the fault was set,
+	// that fail whatever ErrorRate says; {2} fails only the second call
+	FailCalls []int
+	// Accounts restricts the fault to calls naming one of these accounts;
+	// other calls pass untouched and are not counted
+	Accounts []string
+	// Err is what failing calls return; default is a STORAGE_ERROR
+	Err error
+	// AfterCall lets failing calls reach the store first, as when a write
+	// lands but its acknowledgement is lost
+	AfterCall bool
+}
+
+// FaultConfig sets the faults of every method
+type FaultConfig struct {
+	// Timeout bounds each call, injected delays and hangs included, the way
+	// a driver's query timeout would; zero leaves only the caller's deadline
+	Timeout time.Duration
+	Methods map[string]Fault
+}
+
+// ErrInjectedFault is the cause of the default error failing calls return
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultyRepository wraps an AccountRepository and makes its calls slow,
+// fail or hang as configured. Faults may be changed while calls are running,
+// so a test can break the store in the middle of a workload.
+type FaultyRepository struct {
+	inner  AccountRepository
+	config FaultConfig
+	calls  map[string]int
+	rand   *rand.Rand
+	mutex  sync.Mutex
+}
+
+// NewFaultyRepository wraps inner without any fault; seed fixes every
+// random draw, so a run can be repeated
+func NewFaultyRepository(inner AccountRepository, seed int64) *FaultyRepository {
+	return &FaultyRepository{
+		inner: inner,
+		calls: make(map[string]int),
+		rand:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// SetFaults replaces the whole configuration and restarts every call count
+func (r *FaultyRepository) SetFaults(config FaultConfig) {
+	methods := make(map[string]Fault, len(config.Methods))
+	for method, fault := range config.Methods {
+		methods[method] = fault
+	}
+	config.Methods = methods
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.config = config
+	r.calls = make(map[string]int)
+}
+
+// SetFault replaces the fault of one method and restarts its call count
+func (r *FaultyRepository) SetFault(method string, fault Fault) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	methods := make(map[string]Fault, len(r.config.Methods)+1)
+	for m, f := range r.config.Methods {
+		methods[m] = f
+	}
+	methods[method] = fault
+	r.config.Methods = methods
+	r.calls[method] = 0
+}
+
+// SetTimeout changes the bound on each call
+func (r *FaultyRepository) SetTimeout(timeout time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.config.Timeout = timeout
+}
+
+// ClearFaults lets every call through untouched
+func (r *FaultyRepository) ClearFaults() {
+	r.SetFaults(FaultConfig{})
+}
+
+// Calls reports how many calls of method the current fault has seen
+func (r *FaultyRepository) Calls(method string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.calls[method]
+}
+
+// plan is what happens to one call, decided when it starts
+type plan struct {
+	delay     time.Duration
+	timeout   time.Duration
+	hang      bool
+	err       error
+	afterCall bool
+}
+
+func (r *FaultyRepository) plan(method string, accountIds ...string) plan {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	p := plan{timeout: r.config.Timeout}
+	fault, ok := r.config.Methods[method]
+	if !ok || !fault.matches(accountIds) {
+		return p
+	}
+	r.calls[method]++
+	n := r.calls[method]
+
+	if fault.Latency != nil {
+		p.delay = fault.Latency(r.rand)
+	}
+	p.hang = fault.HangRate > 0 && r.rand.Float64() < fault.HangRate
+	failed := fault.ErrorRate > 0 && r.rand.Float64() < fault.ErrorRate
+	for _, call := range fault.FailCalls {
+		failed = failed || call == n
+	}
+	if failed {
+		p.err = fault.Err
+		if p.err == nil {
+			p.err = models.NewStorageError(method, ErrInjectedFault)
+		}
+		p.afterCall = fault.AfterCall
+	}
+	return p
+}
+
+func (f Fault) matches(accountIds []string) bool {
+	if len(f.Accounts) == 0 {
+		return true
+	}
+	for _, want := range f.Accounts {
+		for _, id := range accountIds {
+			if id == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// do runs call under the faults planned for it
+func (r *FaultyRepository) do(ctx context.Context, p plan, call func(context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return models.WrapContextError(err)
+	}
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	if p.delay > 0 {
+		timer := time.NewTimer(p.delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return models.WrapContextError(ctx.Err())
+		case <-timer.C:
+		}
+	}
+	if p.hang {
+		<-ctx.Done()
+		return models.WrapContextError(ctx.Err())
+	}
+	if p.err != nil && !p.afterCall {
+		return p.err
+	}
+	if err := call(ctx); err != nil {
+		return err
+	}
+	return p.err
+}
+
+func (r *FaultyRepository) GetAccountById(ctx context.Context, accountId string) (*models.Account, error) {
+	var account *models.Account
+	err := r.do(ctx, r.plan(MethodGetAccountById, accountId), func(ctx context.Context) (err error) {
+		account, err = r.inner.GetAccountById(ctx, accountId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (r *FaultyRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	return r.do(ctx, r.plan(MethodCreateAccount, account.ID), func(ctx context.Context) error {
+		return r.inner.CreateAccount(ctx, account)
+	})
+}
+
+func (r *FaultyRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+	return r.do(ctx, r.plan(MethodUpdateAccount, account.ID), func(ctx context.Context) error {
+		return r.inner.UpdateAccount(ctx, account)
+	})
+}
+
+func (r *FaultyRepository) GetMultipleAccounts(ctx context.Context, accountIds []string) ([]*models.Account, error) {
+	var accounts []*models.Account
+	err := r.do(ctx, r.plan(MethodGetMultipleAccounts, accountIds...), func(ctx context.Context) (err error) {
+		accounts, err = r.inner.GetMultipleAccounts(ctx, accountIds)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// ListAccountIds lists the wrapped store's accounts; it names no account, so
+// a fault restricted to some accounts leaves it alone
+func (r *FaultyRepository) ListAccountIds(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.do(ctx, r.plan(MethodListAccountIds), func(ctx context.Context) (err error) {
+		ids, err = ListAccountIds(ctx, r.inner)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FaultyTransactionalRepository is a FaultyRepository over a store that
+// commits transfers atomically; CommitTransfer takes faults of its own
+type FaultyTransactionalRepository struct {
+	*FaultyRepository
+	inner TransactionalAccountRepository
+}
+
+func NewFaultyTransactionalRepository(inner TransactionalAccountRepository, seed int64) *FaultyTransactionalRepository {
+	return &FaultyTransactionalRepository{FaultyRepository: NewFaultyRepository(inner, seed), inner: inner}
+}
+
+func (r *FaultyTransactionalRepository) CommitTransfer(ctx context.Context, accounts ...*models.Account) error {
+	return r.do(ctx, r.plan(MethodCommitTransfer, accountIds(accounts)...), func(ctx context.Context) error {
+		return r.inner.CommitTransfer(ctx, accounts...)
+	})
+}
+
+// FaultyOutboxRepository is a FaultyTransactionalRepository over a store that
+// keeps the outbox next to the accounts, so that a transfer's events still
+// commit with it. CommitTransferWithOutbox and the Outbox methods take faults
+// of their own; the Outbox methods name no accounts, so a fault restricted
+// to some accounts leaves them alone.
+type FaultyOutboxRepository struct {
+	*FaultyTransactionalRepository
+	inner TransactionalOutboxRepository
+}
+
+func NewFaultyOutboxRepository(inner TransactionalOutboxRepository, seed int64) *FaultyOutboxRepository {
+	return &FaultyOutboxRepository{FaultyTransactionalRepository: NewFaultyTransactionalRepository(inner, seed), inner: inner}
+}
+
+func (r *FaultyOutboxRepository) CommitTransferWithOutbox(ctx context.Context, records []*OutboxRecord, accounts ...*models.Account) error {
+	return r.do(ctx, r.plan(MethodCommitTransferWithOutbox, accountIds(accounts)...), func(ctx context.Context) error {
+		return r.inner.CommitTransferWithOutbox(ctx, records, accounts...)
+	})
+}
+
+func (r *FaultyOutboxRepository) Append(ctx context.Context, records ...*OutboxRecord) error {
+	return r.do(ctx, r.plan(MethodAppend), func(ctx context.Context) error {
+		return r.inner.Append(ctx, records...)
+	})
+}
+
+func (r *FaultyOutboxRepository) Pending(ctx context.Context, limit int) ([]*OutboxRecord, error) {
+	var records []*OutboxRecord
+	err := r.do(ctx, r.plan(MethodPending), func(ctx context.Context) (err error) {
+		records, err = r.inner.Pending(ctx, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *FaultyOutboxRepository) MarkPublished(ctx context.Context, sequences ...int64) error {
+	return r.do(ctx, r.plan(MethodMarkPublished), func(ctx context.Context) error {
+		return r.inner.MarkPublished(ctx, sequences...)
+	})
+}
+
+func accountIds(accounts []*models.Account) []string {
+	ids := make([]string, len(accounts))
+	for i, acc := range accounts {
+		ids[i] = acc.ID
+	}
+	return ids
+}
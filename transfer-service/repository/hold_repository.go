@@ -0,0 +1,22 @@
+// File: repository/hold_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+	"transfer-service/models"
+)
+
+// HoldRepository stores authorizations. The funds a hold reserves are
+// tracked on the sender's account (Account.Held); the hold itself records
+// what was reserved, what has been captured and when it lapses.
+type HoldRepository interface {
+	// SaveHold stores a new hold, assigning ID if unset
+	SaveHold(ctx context.Context, hold *models.Hold) error
+	GetHold(ctx context.Context, holdId string) (*models.Hold, error)
+	// UpdateHold replaces a stored hold
+	UpdateHold(ctx context.Context, hold *models.Hold) error
+	// ExpiredHolds returns up to limit open holds whose ExpiresAt is at or
+	// before asOf, soonest to expire first; limit <= 0 means all of them
+	ExpiredHolds(ctx context.Context, asOf time.Time, limit int) ([]*models.Hold, error)
+}
@@ -0,0 +1,90 @@
+// File: repository/memory_hold_repository.go
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"transfer-service/models"
+)
+
+// InMemoryHoldRepository keeps holds in process memory
+type InMemoryHoldRepository struct {
+	holds map[string]*models.Hold
+	seq   int64
+	mutex sync.RWMutex
+}
+
+func NewInMemoryHoldRepository() *InMemoryHoldRepository {
+	return &InMemoryHoldRepository{holds: make(map[string]*models.Hold)}
+}
+
+func (r *InMemoryHoldRepository) SaveHold(ctx context.Context, hold *models.Hold) error {
+	select {
+	case <-ctx.Done():
+		return models.WrapContextError(ctx.Err())
+	default:
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.seq++
+	if hold.ID == "" {
+		hold.ID = fmt.Sprintf("HLD-%08d", r.seq)
+	}
+	stored := *hold
+	r.holds[stored.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryHoldRepository) GetHold(ctx context.Context, holdId string) (*models.Hold, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if h, ok := r.holds[holdId]; ok {
+		copied := *h
+		return &copied, nil
+	}
+	return nil, models.NewHoldNotFoundError(holdId)
+}
+
+func (r *InMemoryHoldRepository) UpdateHold(ctx context.Context, hold *models.Hold) error {
+	select {
+	case <-ctx.Done():
+		return models.WrapContextError(ctx.Err())
+	default:
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.holds[hold.ID]; !ok {
+		return models.NewHoldNotFoundError(hold.ID)
+	}
+	stored := *hold
+	r.holds[stored.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryHoldRepository) ExpiredHolds(ctx context.Context, asOf time.Time, limit int) ([]*models.Hold, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var out []*models.Hold
+	for _, h := range r.holds {
+		if h.Status.Open() && !h.ExpiresAt.After(asOf) {
+			copied := *h
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].ExpiresAt.Equal(out[j].ExpiresAt) {
+			return out[i].ExpiresAt.Before(out[j].ExpiresAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,69 @@
+// File: repository/memory_outbox.go
+package repository
+
+import (
+	"context"
+	"sync"
+	"transfer-service/models"
+)
+
+// InMemoryOutbox keeps unpublished events in process memory
+type InMemoryOutbox struct {
+	pending []*OutboxRecord
+	next    int64
+	mutex   sync.Mutex
+}
+
+func NewInMemoryOutbox() *InMemoryOutbox {
+	return &InMemoryOutbox{}
+}
+
+func (o *InMemoryOutbox) Append(ctx context.Context, records ...*OutboxRecord) error {
+	select {
+	case <-ctx.Done():
+		return models.WrapContextError(ctx.Err())
+	default:
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	for _, r := range records {
+		o.next++
+		r.Sequence = o.next
+		stored := *r
+		o.pending = append(o.pending, &stored)
+	}
+	return nil
+}
+
+func (o *InMemoryOutbox) Pending(ctx context.Context, limit int) ([]*OutboxRecord, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if limit <= 0 || limit > len(o.pending) {
+		limit = len(o.pending)
+	}
+	out := make([]*OutboxRecord, limit)
+	for i, r := range o.pending[:limit] {
+		copied := *r
+		out[i] = &copied
+	}
+	return out, nil
+}
+
+func (o *InMemoryOutbox) MarkPublished(ctx context.Context, sequences ...int64) error {
+	published := make(map[int64]bool, len(sequences))
+	for _, seq := range sequences {
+		published[seq] = true
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	kept := o.pending[:0]
+	for _, r := range o.pending {
+		if !published[r.Sequence] {
+			kept = append(kept, r)
+		}
+	}
+	o.pending = kept
+	return nil
+}
@@ -0,0 +1,123 @@
+// File: repository/memory_transfer_repository.go
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"transfer-service/models"
+)
+
+// InMemoryTransferRepository keeps transfer history in process memory
+type InMemoryTransferRepository struct {
+	transfers []*models.Transfer
+	byId      map[string]*models.Transfer
+	byAccount map[string][]*models.Transfer
+	mutex     sync.RWMutex
+}
+
+func NewInMemoryTransferRepository() *InMemoryTransferRepository {
+	return &InMemoryTransferRepository{
+		byId:      make(map[string]*models.Transfer),
+		byAccount: make(map[string][]*models.Transfer),
+	}
+}
+
+func (r *InMemoryTransferRepository) SaveTransfer(ctx context.Context, transfer *models.Transfer) error {
+	select {
+	case <-ctx.Done():
+		return models.WrapContextError(ctx.Err())
+	default:
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	transfer.Sequence = int64(len(r.transfers) + 1)
+	if transfer.ID == "" {
+		transfer.ID = fmt.Sprintf("TXN-%08d", transfer.Sequence)
+	}
+	stored := *transfer
+	stored.Refunds = append([]string(nil), transfer.Refunds...)
+	r.transfers = append(r.transfers, &stored)
+	r.byId[stored.ID] = &stored
+	for _, id := range touchedAccounts(&stored) {
+		r.byAccount[id] = append(r.byAccount[id], &stored)
+	}
+	return nil
+}
+
+// touchedAccounts lists each account whose balance the transfer changed:
+// sender, recipient, and the revenue account of any fee charged or returned
+func touchedAccounts(t *models.Transfer) []string {
+	ids := []string{t.FromAccountId}
+	add := func(id string) {
+		for _, seen := range ids {
+			if seen == id {
+				return
+			}
+		}
+		ids = append(ids, id)
+	}
+	add(t.ToAccountId)
+	if t.Fees != nil {
+		add(t.Fees.RevenueAccountId)
+	}
+	if t.FeesReturned != nil {
+		add(t.FeesReturned.RevenueAccountId)
+	}
+	return ids
+}
+
+func (r *InMemoryTransferRepository) GetTransfer(ctx context.Context, transferId string) (*models.Transfer, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if t, ok := r.byId[transferId]; ok {
+		copied := *t
+		return &copied, nil
+	}
+	return nil, models.NewTransferNotFoundError(transferId)
+}
+
+// UpdateTransfer overwrites the stored record in place, so the account
+// indexes keep pointing at it, provided nobody updated it since transfer was
+// read; transfer.Version moves on with the stored one
+func (r *InMemoryTransferRepository) UpdateTransfer(ctx context.Context, transfer *models.Transfer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	stored, ok := r.byId[transfer.ID]
+	if !ok {
+		return models.NewTransferNotFoundError(transfer.ID)
+	}
+	if stored.Version != transfer.Version {
+		return models.NewConcurrentTransferUpdateError(transfer.ID, stored.Version)
+	}
+	transfer.Version++
+	*stored = *transfer
+	stored.Refunds = append([]string(nil), transfer.Refunds...)
+	return nil
+}
+
+func (r *InMemoryTransferRepository) QueryTransfers(ctx context.Context, query TransferQuery) ([]*models.Transfer, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var out []*models.Transfer
+	for _, t := range r.byAccount[query.AccountId] {
+		if t.Sequence <= query.AfterSeq {
+			continue
+		}
+		if !query.From.IsZero() && t.CreatedAt.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !t.CreatedAt.Before(query.To) {
+			continue
+		}
+		copied := *t
+		out = append(out, &copied)
+		if query.Limit > 0 && len(out) == query.Limit {
+			break
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,119 @@
+// File: repository/migrations.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migration is one forward-only schema change; versions must increase
+type migration struct {
+	version    int
+	statements []string
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS accounts (
+				id            TEXT    PRIMARY KEY,
+				name          TEXT    NOT NULL,
+				balance_minor INTEGER NOT NULL,
+				currency      TEXT    NOT NULL,
+				version       INTEGER NOT NULL DEFAULT 0
+			)`,
+		},
+	},
+	{
+		version: 2,
+		statements: []string{
+			`ALTER TABLE accounts ADD COLUMN status TEXT NOT NULL DEFAULT 'ACTIVE'`,
+		},
+	},
+	{
+		version: 3,
+		statements: []string{
+			`ALTER TABLE accounts ADD COLUMN type TEXT NOT NULL DEFAULT 'PERSONAL'`,
+		},
+	},
+	{
+		version: 4,
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS outbox (
+				sequence     INTEGER PRIMARY KEY AUTOINCREMENT,
+				type         TEXT    NOT NULL,
+				payload      BLOB    NOT NULL,
+				created_at   TEXT    NOT NULL,
+				published_at TEXT
+			)`,
+			`CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (sequence) WHERE published_at IS NULL`,
+		},
+	},
+	{
+		version: 5,
+		statements: []string{
+			`ALTER TABLE accounts ADD COLUMN held_minor INTEGER NOT NULL DEFAULT 0`,
+			`CREATE TABLE IF NOT EXISTS holds (
+				id              TEXT    PRIMARY KEY,
+				request_id      TEXT    NOT NULL,
+				from_account_id TEXT    NOT NULL,
+				to_account_id   TEXT    NOT NULL,
+				amount_minor    INTEGER NOT NULL,
+				captured_minor  INTEGER NOT NULL,
+				currency        TEXT    NOT NULL,
+				captures        INTEGER NOT NULL,
+				status          TEXT    NOT NULL,
+				created_at      TEXT    NOT NULL,
+				expires_at      TEXT    NOT NULL,
+				updated_at      TEXT    NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS holds_open ON holds (expires_at) WHERE status IN ('AUTHORIZED', 'PARTIALLY_CAPTURED')`,
+		},
+	},
+}
+
+// Migrate brings the schema up to date, applying each pending migration in
+// its own transaction and recording it in schema_migrations
+func Migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, db, m); err != nil {
+			return fmt.Errorf("migration %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, m.version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
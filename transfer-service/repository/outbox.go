@@ -0,0 +1,36 @@
+// File: repository/outbox.go
+package repository
+
+import (
+	"context"
+	"time"
+	"transfer-service/models"
+)
+
+// OutboxRecord is an encoded event waiting to be published
+type OutboxRecord struct {
+	Sequence  int64 // assigned by the outbox, increasing in append order
+	Type      string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// Outbox stores events until they have been published, so that an event is
+// only ever released once the change it describes is stored
+type Outbox interface {
+	// Append stores records, assigning their Sequence
+	Append(ctx context.Context, records ...*OutboxRecord) error
+	// Pending returns up to limit unpublished records ordered by Sequence
+	Pending(ctx context.Context, limit int) ([]*OutboxRecord, error)
+	MarkPublished(ctx context.Context, sequences ...int64) error
+}
+
+// TransactionalOutboxRepository is implemented by stores that keep the
+// outbox next to the accounts. UPITransferService then appends a transfer's
+// events in the transaction that commits it.
+type TransactionalOutboxRepository interface {
+	TransactionalAccountRepository
+	Outbox
+	// CommitTransferWithOutbox is CommitTransfer also appending records
+	CommitTransferWithOutbox(ctx context.Context, records []*OutboxRecord, accounts ...*models.Account) error
+}
@@ -4,14 +4,64 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 	"transfer-service/models"
 )
 
+// SqlAccountRepository keeps accounts in process memory, optionally slowed
+// down and made to fail like a remote database would. Each instance owns its
+// state, so tests can build one per case and run in parallel.
 type SqlAccountRepository struct {
-	accounts map[string]*models.Account
-	mutex    sync.RWMutex
+	accounts     map[string]*models.Account
+	seed         *Snapshot
+	readLatency  time.Duration
+	writeLatency time.Duration
+	failure      func(method, accountId string) error
+	mutex        sync.RWMutex
+}
+
+// SqlOption configures a SqlAccountRepository
+type SqlOption func(*SqlAccountRepository)
+
+// WithLatency delays every read and write, as a round trip to a database
+// would. A delay is cut short when the caller's context ends.
+func WithLatency(read, write time.Duration) SqlOption {
+	return func(r *SqlAccountRepository) { r.readLatency, r.writeLatency = read, write }
+}
+
+// WithFailureInjector consults fn before every call with the method and the
+// account it touches. The methods are MethodGetAccountById,
+// MethodCreateAccount, MethodUpdateAccount and MethodListAccountIds, which
+// names no account. A non-nil error fails the call without touching any
+// state.
+func WithFailureInjector(fn func(method, accountId string) error) SqlOption {
+	return func(r *SqlAccountRepository) { r.failure = fn }
+}
+
+// NewSqlAccountRepository holds copies of seed, so the caller's accounts are
+// never changed by transfers. Reset returns to them.
+func NewSqlAccountRepository(seed []*models.Account, opts ...SqlOption) *SqlAccountRepository {
+	r := &SqlAccountRepository{accounts: make(map[string]*models.Account, len(seed))}
+	for _, acc := range seed {
+		r.accounts[acc.ID] = cloneAccount(acc)
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.seed = r.Snapshot()
+	return r
+}
+
+// DemoAccounts are the accounts the console demo and the shared instance
+// start with
+func DemoAccounts() []*models.Account {
+	return []*models.Account{
+		{ID: "1", Name: "Alice", Balance: models.MustParseMoney("1000.00", models.DefaultCurrency)},
+		{ID: "2", Name: "Bob", Balance: models.MustParseMoney("500.00", models.DefaultCurrency)},
+		{ID: "3", Name: "Charlie", Balance: models.MustParseMoney("750.00", models.DefaultCurrency)},
+	}
 }
 
 var (
@@ -19,34 +69,120 @@ var (
 	once            sync.Once
 )
 
+// GetSqlAccountRepository returns a process-wide instance seeded with
+// DemoAccounts and simulated database latency. It suits the demo; tests
+// should build their own with NewSqlAccountRepository.
 func GetSqlAccountRepository() *SqlAccountRepository {
 	once.Do(func() {
-		fmt.Println("[REPO] Creating SqlAccountRepository singleton with sync.Once")
-		sqlRepoInstance = &SqlAccountRepository{
-			accounts: initializeTestData(),
-		}
-		fmt.Println("[REPO] SqlAccountRepository singleton created")
+		fmt.Println("[REPO] Creating shared SqlAccountRepository")
+		sqlRepoInstance = NewSqlAccountRepository(DemoAccounts(), WithLatency(30*time.Millisecond, 20*time.Millisecond))
 	})
 	return sqlRepoInstance
 }
 
-func initializeTestData() map[string]*models.Account {
-	accounts := make(map[string]*models.Account)
-	accounts["1"] = &models.Account{ID: "1", Name: "Alice", Balance: 1000.00}
-	accounts["2"] = &models.Account{ID: "2", Name: "Bob", Balance: 500.00}
-	accounts["3"] = &models.Account{ID: "3", Name: "Charlie", Balance: 750.00}
-	return accounts
+// Snapshot is a copy of every account at one point in time
+type Snapshot struct {
+	accounts map[string]*models.Account
 }
 
-func (r *SqlAccountRepository) GetAccountById(ctx context.Context, accountId string) (*models.Account, error) {
+// AccountIds lists the snapshot's accounts in ID order
+func (s *Snapshot) AccountIds() []string {
+	ids := make([]string, 0, len(s.accounts))
+	for id := range s.accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Account returns a copy of the account as it was, or nil
+func (s *Snapshot) Account(accountId string) *models.Account {
+	if acc, ok := s.accounts[accountId]; ok {
+		return cloneAccount(acc)
+	}
+	return nil
+}
 
-	select {
-	case <-ctx.Done():
-		return nil, models.WrapContextError(ctx.Err())
-	default:
+// Snapshot copies the current state of every account
+func (r *SqlAccountRepository) Snapshot() *Snapshot {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	snap := &Snapshot{accounts: make(map[string]*models.Account, len(r.accounts))}
+	for id, acc := range r.accounts {
+		snap.accounts[id] = cloneAccount(acc)
 	}
+	return snap
+}
 
-	time.Sleep(30 * time.Millisecond) // simulate db latency
+// Restore puts every account back as snap recorded it and drops accounts
+// created since. Accounts are restored in place, so pointers handed out
+// earlier see the restored state. Call it while no transfer is running.
+func (r *SqlAccountRepository) Restore(snap *Snapshot) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for id, acc := range r.accounts {
+		saved, ok := snap.accounts[id]
+		if !ok {
+			delete(r.accounts, id)
+			continue
+		}
+		acc.Mutex.Lock()
+		copyAccount(acc, saved)
+		acc.Mutex.Unlock()
+	}
+	for id, saved := range snap.accounts {
+		if _, ok := r.accounts[id]; !ok {
+			r.accounts[id] = cloneAccount(saved)
+		}
+	}
+}
+
+// Reset restores the accounts the repository was created with
+func (r *SqlAccountRepository) Reset() {
+	r.Restore(r.seed)
+}
+
+// cloneAccount copies acc's state into a new account with its own mutex
+func cloneAccount(acc *models.Account) *models.Account {
+	acc.Mutex.RLock()
+	defer acc.Mutex.RUnlock()
+	out := &models.Account{}
+	copyAccount(out, acc)
+	return out
+}
+
+// copyAccount copies every field but the mutex; the caller holds the locks
+func copyAccount(dst, src *models.Account) {
+	dst.ID, dst.Name = src.ID, src.Name
+	dst.Balance, dst.Held = src.Balance, src.Held
+	dst.Currency, dst.Type, dst.Status = src.Currency, src.Type, src.Status
+	dst.Version = src.Version
+}
+
+// simulate waits out the latency of one call and applies any injected failure
+func (r *SqlAccountRepository) simulate(ctx context.Context, latency time.Duration, method, accountId string) error {
+	if err := ctx.Err(); err != nil {
+		return models.WrapContextError(err)
+	}
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return models.WrapContextError(ctx.Err())
+		case <-timer.C:
+		}
+	}
+	if r.failure != nil {
+		return r.failure(method, accountId)
+	}
+	return nil
+}
+
+func (r *SqlAccountRepository) GetAccountById(ctx context.Context, accountId string) (*models.Account, error) {
+	if err := r.simulate(ctx, r.readLatency, MethodGetAccountById, accountId); err != nil {
+		return nil, err
+	}
 
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
@@ -56,15 +192,24 @@ func (r *SqlAccountRepository) GetAccountById(ctx context.Context, accountId str
 	return nil, models.NewAccountNotFoundError(accountId)
 }
 
-func (r *SqlAccountRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+func (r *SqlAccountRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	if err := r.simulate(ctx, r.writeLatency, MethodCreateAccount, account.ID); err != nil {
+		return err
+	}
 
-	select {
-	case <-ctx.Done():
-		return models.WrapContextError(ctx.Err())
-	default:
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, exists := r.accounts[account.ID]; exists {
+		return models.NewAccountAlreadyExistsError(account.ID)
 	}
+	r.accounts[account.ID] = account
+	return nil
+}
 
-	time.Sleep(20 * time.Millisecond)
+func (r *SqlAccountRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+	if err := r.simulate(ctx, r.writeLatency, MethodUpdateAccount, account.ID); err != nil {
+		return err
+	}
 
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -75,6 +220,21 @@ func (r *SqlAccountRepository) UpdateAccount(ctx context.Context, account *model
 	return models.NewAccountNotFoundError(account.ID)
 }
 
+func (r *SqlAccountRepository) ListAccountIds(ctx context.Context) ([]string, error) {
+	if err := r.simulate(ctx, r.readLatency, MethodListAccountIds, ""); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	ids := make([]string, 0, len(r.accounts))
+	for id := range r.accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
 func (r *SqlAccountRepository) GetMultipleAccounts(ctx context.Context, accountIds []string) ([]*models.Account, error) {
 
 	type result struct {
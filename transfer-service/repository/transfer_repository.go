@@ -0,0 +1,29 @@
+// File: repository/transfer_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+	"transfer-service/models"
+)
+
+// TransferQuery selects history records touching one account, as sender,
+// recipient or the revenue account of its fees
+type TransferQuery struct {
+	AccountId string
+	From      time.Time // inclusive; zero means unbounded
+	To        time.Time // exclusive; zero means unbounded
+	AfterSeq  int64     // only records with a larger Sequence
+	Limit     int
+}
+
+type TransferRepository interface {
+	// SaveTransfer stores a new record, assigning ID and Sequence if unset
+	SaveTransfer(ctx context.Context, transfer *models.Transfer) error
+	GetTransfer(ctx context.Context, transferId string) (*models.Transfer, error)
+	// UpdateTransfer replaces a stored record; only refund bookkeeping changes.
+	// It fails with CONCURRENT_MODIFICATION when the record's Version is stale.
+	UpdateTransfer(ctx context.Context, transfer *models.Transfer) error
+	// QueryTransfers returns matching records ordered by Sequence
+	QueryTransfers(ctx context.Context, query TransferQuery) ([]*models.Transfer, error)
+}
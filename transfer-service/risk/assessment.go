@@ -0,0 +1,70 @@
+// File: risk/assessment.go
+
+// Package risk screens transfers for fraud before any money moves. An Engine
+// runs a set of rules against the history of completed transfers and
+// combines what they find into an allow, deny or review decision; transfers
+// sent for review wait in a ReviewQueue until someone approves or rejects
+// them.
+package risk
+
+import "fmt"
+
+type Decision string
+
+const (
+	DecisionAllow  Decision = "ALLOW"
+	DecisionReview Decision = "REVIEW"
+	DecisionDeny   Decision = "DENY"
+)
+
+// severity orders decisions so the strictest finding wins
+var severity = map[Decision]int{
+	DecisionAllow:  0,
+	DecisionReview: 1,
+	DecisionDeny:   2,
+}
+
+func ParseDecision(s string) (Decision, error) {
+	switch d := Decision(s); d {
+	case DecisionAllow, DecisionReview, DecisionDeny:
+		return d, nil
+	}
+	return "", fmt.Errorf("risk: unknown decision %q", s)
+}
+
+// Finding is one rule's objection to a transfer
+type Finding struct {
+	Rule     string                 `json:"rule"`
+	RuleType string                 `json:"ruleType"`
+	Decision Decision               `json:"decision"`
+	Reason   string                 `json:"reason"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// Assessment is the verdict on one transfer: the strictest decision among
+// its findings, or allow when there are none
+type Assessment struct {
+	Decision Decision  `json:"decision"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Allow is the assessment of a transfer nothing objected to
+func Allow() Assessment {
+	return Assessment{Decision: DecisionAllow}
+}
+
+func (a *Assessment) add(f Finding) {
+	a.Findings = append(a.Findings, f)
+	if severity[f.Decision] > severity[a.Decision] {
+		a.Decision = f.Decision
+	}
+}
+
+// Rules names the rules that found something, in the order they ran
+func (a Assessment) Rules() []string {
+	names := make([]string, len(a.Findings))
+	for i, f := range a.Findings {
+		names[i] = f.Rule
+	}
+	return names
+}
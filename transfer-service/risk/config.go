@@ -0,0 +1,168 @@
+// File: risk/config.go
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"transfer-service/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the declarative form of a risk rule set, e.g.
+//
+//	rules:
+//	  - name: first-payment
+//	    type: new_payee
+//	    minAmount: "10000.00"
+//	  - name: spike
+//	    type: amount_spike
+//	    multiplier: 5
+//	    window: 720h
+//	    minHistory: 3
+//	  - name: burst
+//	    type: rapid_fire
+//	    count: 10
+//	    window: 10m
+//	    decision: DENY
+//	  - name: boomerang
+//	    type: round_trip
+//	    window: 1h
+//
+// A rule that fires sends the transfer for review unless its decision says
+// otherwise.
+type Config struct {
+	Rules []RuleConfig `json:"rules" yaml:"rules"`
+}
+
+type RuleConfig struct {
+	Name       string `json:"name" yaml:"name"`
+	Type       string `json:"type" yaml:"type"`
+	Decision   string `json:"decision,omitempty" yaml:"decision,omitempty"`
+	MinAmount  string `json:"minAmount,omitempty" yaml:"minAmount,omitempty"`
+	Currency   string `json:"currency,omitempty" yaml:"currency,omitempty"`
+	Multiplier int64  `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	MinHistory int    `json:"minHistory,omitempty" yaml:"minHistory,omitempty"`
+	Count      int    `json:"count,omitempty" yaml:"count,omitempty"`
+	Window     string `json:"window,omitempty" yaml:"window,omitempty"`
+}
+
+// LoadConfig reads a YAML (.yaml, .yml) or JSON (.json) rule file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	case ".json":
+		return ParseJSON(data)
+	}
+	return nil, fmt.Errorf("risk: unsupported config file %s", path)
+}
+
+func ParseYAML(data []byte) (*Config, error) {
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("risk: %w", err)
+	}
+	return &c, nil
+}
+
+func ParseJSON(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("risk: %w", err)
+	}
+	return &c, nil
+}
+
+// BuildRules builds the configured rules, rejecting any that are incomplete
+func (c *Config) BuildRules() ([]Rule, error) {
+	seen := make(map[string]bool)
+	rules := make([]Rule, 0, len(c.Rules))
+	for _, r := range c.Rules {
+		if r.Name == "" {
+			return nil, NewInvalidRuleError(r.Name, "missing name")
+		}
+		if seen[r.Name] {
+			return nil, NewInvalidRuleError(r.Name, "duplicate name")
+		}
+		seen[r.Name] = true
+
+		rule, err := r.rule()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r RuleConfig) rule() (Rule, error) {
+	decision := DecisionReview
+	if r.Decision != "" {
+		var err error
+		if decision, err = ParseDecision(r.Decision); err != nil || decision == DecisionAllow {
+			return nil, NewInvalidRuleError(r.Name, fmt.Sprintf("decision must be %s or %s", DecisionReview, DecisionDeny))
+		}
+	}
+
+	switch r.Type {
+	case "new_payee":
+		rule := &NewPayeeRule{RuleName: r.Name, Decision: decision}
+		if r.MinAmount != "" {
+			min, err := models.ParseMoney(r.MinAmount, r.currency())
+			if err != nil {
+				return nil, NewInvalidRuleError(r.Name, err.Error())
+			}
+			rule.MinAmount = min
+		}
+		return rule, nil
+	case "amount_spike":
+		if r.Multiplier <= 0 {
+			return nil, NewInvalidRuleError(r.Name, "multiplier must be positive")
+		}
+		window, err := r.window()
+		if err != nil {
+			return nil, err
+		}
+		return &AmountSpikeRule{RuleName: r.Name, Multiplier: r.Multiplier, Window: window, MinHistory: r.MinHistory, Decision: decision}, nil
+	case "rapid_fire":
+		if r.Count <= 0 {
+			return nil, NewInvalidRuleError(r.Name, "count must be positive")
+		}
+		window, err := r.window()
+		if err != nil {
+			return nil, err
+		}
+		return &RapidFireRule{RuleName: r.Name, Count: r.Count, Window: window, Decision: decision}, nil
+	case "round_trip":
+		window, err := r.window()
+		if err != nil {
+			return nil, err
+		}
+		return &RoundTripRule{RuleName: r.Name, Window: window, Decision: decision}, nil
+	}
+	return nil, NewInvalidRuleError(r.Name, fmt.Sprintf("unknown type %q", r.Type))
+}
+
+func (r RuleConfig) currency() string {
+	if r.Currency == "" {
+		return models.DefaultCurrency
+	}
+	return r.Currency
+}
+
+func (r RuleConfig) window() (time.Duration, error) {
+	window, err := time.ParseDuration(r.Window)
+	if err != nil || window <= 0 {
+		return 0, NewInvalidRuleError(r.Name, fmt.Sprintf("invalid window %q", r.Window))
+	}
+	return window, nil
+}
@@ -0,0 +1,116 @@
+// File: risk/engine.go
+package risk
+
+import (
+	"context"
+	"sync"
+	"time"
+	"transfer-service/models"
+)
+
+// Engine evaluates every rule against the history of completed transfers it
+// has been told about through Observe. It judges each transfer on its own:
+// transfers evaluated concurrently do not see each other until they complete.
+type Engine struct {
+	rules    []Rule
+	sent     map[string][]Transfer
+	payees   map[string]map[string]bool // kept for good; never pruned
+	lookback time.Duration
+	now      func() time.Time
+	mutex    sync.Mutex
+}
+
+type EngineOption func(*Engine)
+
+// WithClock replaces time.Now, mainly so tests can control windows
+func WithClock(now func() time.Time) EngineOption {
+	return func(e *Engine) { e.now = now }
+}
+
+func NewEngine(rules []Rule, opts ...EngineOption) *Engine {
+	e := &Engine{
+		rules:  rules,
+		sent:   make(map[string][]Transfer),
+		payees: make(map[string]map[string]bool),
+		now:    time.Now,
+	}
+	for _, r := range rules {
+		if r.Lookback() > e.lookback {
+			e.lookback = r.Lookback()
+		}
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Evaluate runs every rule against req and combines their findings
+func (e *Engine) Evaluate(ctx context.Context, req models.TransferRequest) (Assessment, error) {
+	select {
+	case <-ctx.Done():
+		return Assessment{}, models.WrapContextError(ctx.Err())
+	default:
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	attempt := Attempt{FromAccountId: req.FromAccountId, ToAccountId: req.ToAccountId, Amount: req.Amount, At: e.now()}
+	assessment := Allow()
+	for _, r := range e.rules {
+		if f := r.Evaluate(attempt, history{e}); f != nil {
+			assessment.add(*f)
+		}
+	}
+	return assessment, nil
+}
+
+// Observe adds a completed transfer to the history
+func (e *Engine) Observe(ctx context.Context, req models.TransferRequest) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	now := e.now()
+	e.prune(req.FromAccountId, now)
+	e.sent[req.FromAccountId] = append(e.sent[req.FromAccountId], Transfer{
+		FromAccountId: req.FromAccountId,
+		ToAccountId:   req.ToAccountId,
+		Amount:        req.Amount,
+		At:            now,
+	})
+	if e.payees[req.FromAccountId] == nil {
+		e.payees[req.FromAccountId] = make(map[string]bool)
+	}
+	e.payees[req.FromAccountId][req.ToAccountId] = true
+}
+
+// prune drops sent history no rule can see any more; the caller holds the lock
+func (e *Engine) prune(accountId string, now time.Time) {
+	entries := e.sent[accountId]
+	cutoff := now.Add(-e.lookback)
+	i := 0
+	for i < len(entries) && entries[i].At.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		e.sent[accountId] = append([]Transfer(nil), entries[i:]...)
+	}
+}
+
+// history is the engine's view handed to rules; the engine's lock is held
+type history struct{ e *Engine }
+
+func (h history) Paid(from, to string) bool {
+	return h.e.payees[from][to]
+}
+
+func (h history) Sent(from string, since time.Time) []Transfer {
+	var out []Transfer
+	for _, t := range h.e.sent[from] {
+		if !t.At.Before(since) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+// File: risk/errors.go
+package risk
+
+import (
+	"fmt"
+	"transfer-service/models"
+)
+
+// NewRiskDeniedError lists the rules that fired in Details so callers can
+// tell them apart
+func NewRiskDeniedError(accountId string, a Assessment) *models.TransferError {
+	return &models.TransferError{
+		Code:    "RISK_DENIED",
+		Message: fmt.Sprintf("Transfer from %s was declined by risk checks", accountId),
+		Details: map[string]interface{}{"accountId": accountId, "rules": a.Rules(), "findings": a.Findings},
+	}
+}
+
+// NewReviewRequiredError is returned for a transfer held for manual review;
+// reviewId is empty when the transfer could not be held, as in a dry run
+func NewReviewRequiredError(reviewId, accountId string, a Assessment) *models.TransferError {
+	d := map[string]interface{}{"accountId": accountId, "rules": a.Rules(), "findings": a.Findings}
+	if reviewId != "" {
+		d["reviewId"] = reviewId
+	}
+	return &models.TransferError{
+		Code:    "RISK_REVIEW_REQUIRED",
+		Message: fmt.Sprintf("Transfer from %s needs manual review before it can proceed", accountId),
+		Details: d,
+	}
+}
+
+func NewReviewNotFoundError(id string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "REVIEW_NOT_FOUND",
+		Message: fmt.Sprintf("Risk review %s not found", id),
+		Details: map[string]interface{}{"reviewId": id},
+	}
+}
+
+func NewInvalidReviewStateError(id string, status ReviewStatus, op string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "INVALID_REVIEW_STATE",
+		Message: fmt.Sprintf("Cannot %s review %s while it is %s", op, id, status),
+		Details: map[string]interface{}{"reviewId": id, "status": status, "operation": op},
+	}
+}
+
+func NewInvalidRuleError(rule, reason string) error {
+	return fmt.Errorf("risk: rule %q: %s", rule, reason)
+}
+
+func NewReviewRejectedError(reviewId, note string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "RISK_REJECTED",
+		Message: fmt.Sprintf("Transfer held as %s was rejected on review", reviewId),
+		Details: map[string]interface{}{"reviewId": reviewId, "note": note},
+	}
+}
@@ -0,0 +1,121 @@
+// File: risk/review.go
+package risk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"transfer-service/models"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "PENDING"
+	ReviewApproved ReviewStatus = "APPROVED"
+	ReviewRejected ReviewStatus = "REJECTED"
+)
+
+// Review is a transfer held until someone decides on it. Once approved,
+// TransferId and ErrorCode report how the transfer then went.
+type Review struct {
+	ID         string                 `json:"id"`
+	Request    models.TransferRequest `json:"request"`
+	Assessment Assessment             `json:"assessment"`
+	Status     ReviewStatus           `json:"status"`
+	Note       string                 `json:"note,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	DecidedAt  time.Time              `json:"decidedAt,omitempty"`
+	TransferId string                 `json:"transferId,omitempty"`
+	ErrorCode  string                 `json:"errorCode,omitempty"`
+}
+
+// ReviewQueue keeps held transfers in memory. Deciding is a one-way claim, so
+// two reviewers approving the same transfer cannot both run it.
+type ReviewQueue struct {
+	reviews map[string]*Review
+	order   []string
+	seq     int64
+	mutex   sync.Mutex
+}
+
+func NewReviewQueue() *ReviewQueue {
+	return &ReviewQueue{reviews: make(map[string]*Review)}
+}
+
+// Hold queues req for review and returns the stored review
+func (q *ReviewQueue) Hold(req models.TransferRequest, a Assessment, at time.Time) *Review {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.seq++
+	r := &Review{
+		ID:         fmt.Sprintf("RV-%06d", q.seq),
+		Request:    req,
+		Assessment: a,
+		Status:     ReviewPending,
+		CreatedAt:  at,
+	}
+	q.reviews[r.ID] = r
+	q.order = append(q.order, r.ID)
+	copied := *r
+	return &copied
+}
+
+func (q *ReviewQueue) Get(id string) (*Review, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	r, ok := q.reviews[id]
+	if !ok {
+		return nil, NewReviewNotFoundError(id)
+	}
+	copied := *r
+	return &copied, nil
+}
+
+// Pending returns the reviews still waiting for a decision, oldest first
+func (q *ReviewQueue) Pending() []*Review {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	var list []*Review
+	for _, id := range q.order {
+		if r := q.reviews[id]; r.Status == ReviewPending {
+			copied := *r
+			list = append(list, &copied)
+		}
+	}
+	return list
+}
+
+// Decide moves a pending review to approved or rejected and returns it
+func (q *ReviewQueue) Decide(id string, status ReviewStatus, note string, at time.Time) (*Review, error) {
+	op := "approve"
+	if status == ReviewRejected {
+		op = "reject"
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	r, ok := q.reviews[id]
+	if !ok {
+		return nil, NewReviewNotFoundError(id)
+	}
+	if r.Status != ReviewPending {
+		return nil, NewInvalidReviewStateError(id, r.Status, op)
+	}
+	r.Status, r.Note, r.DecidedAt = status, note, at
+	copied := *r
+	return &copied, nil
+}
+
+// Settle records how an approved review's transfer went
+func (q *ReviewQueue) Settle(id string, result models.TransferResult) (*Review, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	r, ok := q.reviews[id]
+	if !ok {
+		return nil, NewReviewNotFoundError(id)
+	}
+	r.TransferId, r.ErrorCode = result.TransferId, models.ErrorCode(result.Error)
+	copied := *r
+	return &copied, nil
+}
@@ -0,0 +1,180 @@
+// File: risk/rules.go
+package risk
+
+import (
+	"fmt"
+	"time"
+	"transfer-service/models"
+)
+
+// Attempt is the transfer a rule is asked to judge
+type Attempt struct {
+	FromAccountId string
+	ToAccountId   string
+	Amount        models.Money
+	At            time.Time
+}
+
+// Transfer is one completed transfer in the history rules see
+type Transfer struct {
+	FromAccountId string
+	ToAccountId   string
+	Amount        models.Money
+	At            time.Time
+}
+
+// History is what the engine remembers of completed transfers
+type History interface {
+	// Paid reports whether from has ever completed a transfer to to
+	Paid(from, to string) bool
+	// Sent returns from's completed transfers at or after since, oldest first
+	Sent(from string, since time.Time) []Transfer
+}
+
+// Rule is one risk check. Evaluate returns a finding when the attempt looks
+// suspicious, or nil when the rule does not object.
+type Rule interface {
+	Name() string
+	Evaluate(attempt Attempt, history History) *Finding
+	// Lookback is how much of the sent history the rule needs to see
+	Lookback() time.Duration
+}
+
+// outcome is what a rule decides when it fires; review unless configured otherwise
+func outcome(d Decision) Decision {
+	if d == "" {
+		return DecisionReview
+	}
+	return d
+}
+
+// NewPayeeRule fires on a transfer to an account the sender has never paid
+// before, when the amount is at least MinAmount (any amount if unset)
+type NewPayeeRule struct {
+	RuleName  string
+	MinAmount models.Money
+	Decision  Decision
+}
+
+func (r *NewPayeeRule) Name() string            { return r.RuleName }
+func (r *NewPayeeRule) Lookback() time.Duration { return 0 }
+
+func (r *NewPayeeRule) Evaluate(a Attempt, h History) *Finding {
+	if h.Paid(a.FromAccountId, a.ToAccountId) {
+		return nil
+	}
+	if r.MinAmount.Currency() != "" {
+		if cmp, err := a.Amount.Cmp(r.MinAmount); err != nil || cmp < 0 {
+			return nil
+		}
+	}
+	return &Finding{
+		Rule:     r.RuleName,
+		RuleType: "new_payee",
+		Decision: outcome(r.Decision),
+		Reason:   fmt.Sprintf("%s has never paid %s", a.FromAccountId, a.ToAccountId),
+		Details:  map[string]interface{}{"payee": a.ToAccountId},
+	}
+}
+
+// AmountSpikeRule fires when a transfer is more than Multiplier times the
+// average of what the sender sent in the same currency over Window. Senders
+// with fewer than MinHistory such transfers have no baseline and are skipped.
+type AmountSpikeRule struct {
+	RuleName   string
+	Multiplier int64
+	Window     time.Duration
+	MinHistory int
+	Decision   Decision
+}
+
+func (r *AmountSpikeRule) Name() string            { return r.RuleName }
+func (r *AmountSpikeRule) Lookback() time.Duration { return r.Window }
+
+func (r *AmountSpikeRule) Evaluate(a Attempt, h History) *Finding {
+	total := models.Zero(a.Amount.Currency())
+	n := 0
+	for _, t := range h.Sent(a.FromAccountId, a.At.Add(-r.Window)) {
+		if t.Amount.Currency() != a.Amount.Currency() {
+			continue
+		}
+		var err error
+		if total, err = total.Add(t.Amount); err != nil {
+			return nil
+		}
+		n++
+	}
+	if n == 0 || n < r.MinHistory {
+		return nil
+	}
+	// Multiplier × total / n, rounded up so an amount exactly at the
+	// threshold does not fire
+	threshold, err := total.MulRat(r.Multiplier, int64(n), models.RoundUp)
+	if err != nil {
+		return nil
+	}
+	if cmp, _ := a.Amount.Cmp(threshold); cmp <= 0 {
+		return nil
+	}
+	average, _ := total.MulRat(1, int64(n), models.RoundHalfEven)
+	return &Finding{
+		Rule:     r.RuleName,
+		RuleType: "amount_spike",
+		Decision: outcome(r.Decision),
+		Reason:   fmt.Sprintf("%s is more than %d times the average of %s", a.Amount, r.Multiplier, average),
+		Details:  map[string]interface{}{"average": average, "threshold": threshold, "attempted": a.Amount, "history": n},
+	}
+}
+
+// RapidFireRule fires when the sender has already completed Count transfers
+// within Window
+type RapidFireRule struct {
+	RuleName string
+	Count    int
+	Window   time.Duration
+	Decision Decision
+}
+
+func (r *RapidFireRule) Name() string            { return r.RuleName }
+func (r *RapidFireRule) Lookback() time.Duration { return r.Window }
+
+func (r *RapidFireRule) Evaluate(a Attempt, h History) *Finding {
+	recent := len(h.Sent(a.FromAccountId, a.At.Add(-r.Window)))
+	if recent < r.Count {
+		return nil
+	}
+	return &Finding{
+		Rule:     r.RuleName,
+		RuleType: "rapid_fire",
+		Decision: outcome(r.Decision),
+		Reason:   fmt.Sprintf("%s already sent %d transfers in the last %s", a.FromAccountId, recent, r.Window),
+		Details:  map[string]interface{}{"count": recent, "window": r.Window.String()},
+	}
+}
+
+// RoundTripRule fires when money goes back where it came from: the recipient
+// paid the sender within Window (B→A, then A→B)
+type RoundTripRule struct {
+	RuleName string
+	Window   time.Duration
+	Decision Decision
+}
+
+func (r *RoundTripRule) Name() string            { return r.RuleName }
+func (r *RoundTripRule) Lookback() time.Duration { return r.Window }
+
+func (r *RoundTripRule) Evaluate(a Attempt, h History) *Finding {
+	for _, t := range h.Sent(a.ToAccountId, a.At.Add(-r.Window)) {
+		if t.ToAccountId != a.FromAccountId {
+			continue
+		}
+		return &Finding{
+			Rule:     r.RuleName,
+			RuleType: "round_trip",
+			Decision: outcome(r.Decision),
+			Reason:   fmt.Sprintf("%s paid %s %s at %s", t.FromAccountId, t.ToAccountId, t.Amount, t.At.Format(time.RFC3339)),
+			Details:  map[string]interface{}{"returnOf": t.Amount, "returnOfAt": t.At},
+		}
+	}
+	return nil
+}
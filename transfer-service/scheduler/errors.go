@@ -0,0 +1,31 @@
+// File: scheduler/errors.go
+package scheduler
+
+import (
+	"fmt"
+	"transfer-service/models"
+)
+
+func NewInstructionNotFoundError(id string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "INSTRUCTION_NOT_FOUND",
+		Message: fmt.Sprintf("Scheduled instruction %s not found", id),
+		Details: map[string]interface{}{"instructionId": id},
+	}
+}
+
+func NewInvalidInstructionError(reason string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "INVALID_INSTRUCTION",
+		Message: "Invalid scheduled instruction: " + reason,
+		Details: map[string]interface{}{"reason": reason},
+	}
+}
+
+func NewInvalidInstructionStateError(id string, status InstructionStatus, op string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "INVALID_INSTRUCTION_STATE",
+		Message: fmt.Sprintf("Cannot %s instruction %s while it is %s", op, id, status),
+		Details: map[string]interface{}{"instructionId": id, "status": status, "operation": op},
+	}
+}
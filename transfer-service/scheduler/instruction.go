@@ -0,0 +1,136 @@
+// File: scheduler/instruction.go
+package scheduler
+
+import (
+	"fmt"
+	"time"
+	"transfer-service/models"
+)
+
+// Frequency says how often an instruction repeats
+type Frequency string
+
+const (
+	Once    Frequency = "ONCE" // a single future-dated transfer
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY" // same day of month, clamped to the month's last day
+)
+
+// InstructionStatus is where an instruction is in its lifecycle
+type InstructionStatus string
+
+const (
+	InstructionActive    InstructionStatus = "ACTIVE"
+	InstructionPaused    InstructionStatus = "PAUSED"
+	InstructionCancelled InstructionStatus = "CANCELLED" // terminal; stopped by the customer
+	InstructionCompleted InstructionStatus = "COMPLETED" // terminal; no occurrences left
+)
+
+// Instruction is a one-off future-dated transfer or a recurring standing
+// instruction. Occurrence n falls at StartAt advanced n periods; an
+// instruction with an EndAt has no occurrences after it.
+type Instruction struct {
+	ID            string
+	FromAccountId string
+	ToAccountId   string
+	Amount        models.Money
+	Frequency     Frequency
+	StartAt       time.Time
+	EndAt         time.Time // zero means no end date
+	Status        InstructionStatus
+	CreatedAt     time.Time
+
+	// Occurrence is the index of the next occurrence to run and Attempts how
+	// many times it has been tried; NextRunAt is when it runs next, which is
+	// later than its scheduled time while a retry is pending
+	Occurrence int
+	Attempts   int
+	NextRunAt  time.Time
+}
+
+// OccurrenceAt returns when occurrence n is scheduled, and false if the
+// instruction has no such occurrence
+func (in *Instruction) OccurrenceAt(n int) (time.Time, bool) {
+	if n < 0 {
+		return time.Time{}, false
+	}
+	var at time.Time
+	switch in.Frequency {
+	case Once:
+		if n > 0 {
+			return time.Time{}, false
+		}
+		at = in.StartAt
+	case Daily:
+		at = in.StartAt.AddDate(0, 0, n)
+	case Weekly:
+		at = in.StartAt.AddDate(0, 0, 7*n)
+	case Monthly:
+		at = addMonths(in.StartAt, n)
+	default:
+		return time.Time{}, false
+	}
+	if !in.EndAt.IsZero() && at.After(in.EndAt) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// addMonths moves t forward n calendar months, keeping its day of month where
+// the target month has it and using the month's last day otherwise. Every
+// occurrence is computed from the start date, so a 31st that falls on the
+// 28th in February is back on the 31st in March.
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	if d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// RequestId is the idempotency key of an occurrence's first attempt; it is
+// the same every time the occurrence is run, so a scheduler that restarts
+// mid-run cannot pay an occurrence twice
+func (in *Instruction) RequestId(occurrence int) string {
+	return fmt.Sprintf("%s-%04d", in.ID, occurrence+1)
+}
+
+func (in *Instruction) validate() error {
+	switch {
+	case in.FromAccountId == "" || in.ToAccountId == "":
+		return NewInvalidInstructionError("both account ids are required")
+	case in.FromAccountId == in.ToAccountId:
+		return NewInvalidInstructionError("cannot schedule a transfer to the same account")
+	case !in.Amount.IsPositive():
+		return NewInvalidInstructionError(fmt.Sprintf("amount must be positive, got %s", in.Amount))
+	case in.StartAt.IsZero():
+		return NewInvalidInstructionError("start time is required")
+	case !in.EndAt.IsZero() && in.EndAt.Before(in.StartAt):
+		return NewInvalidInstructionError("end time is before start time")
+	}
+	switch in.Frequency {
+	case Once, Daily, Weekly, Monthly:
+		return nil
+	}
+	return NewInvalidInstructionError(fmt.Sprintf("unknown frequency %q", in.Frequency))
+}
+
+// Execution is one attempt at one occurrence of an instruction
+type Execution struct {
+	InstructionId string
+	Occurrence    int
+	Attempt       int
+	RequestId     string
+	ScheduledFor  time.Time
+	ExecutedAt    time.Time
+	Success       bool
+	TransferId    string
+	ErrorCode     string
+	WillRetry     bool // a transient failure that will be tried again
+	// Unresolved marks a failure that may still have moved money; it is
+	// never retried, and the transfer under RequestId needs checking
+	Unresolved bool
+}
@@ -0,0 +1,351 @@
+// File: scheduler/scheduler.go
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"transfer-service/models"
+)
+
+// Executor runs a transfer; service.TransferService satisfies it. The
+// scheduler goes through ProcessTransfer rather than Transfer so that every
+// occurrence carries its RequestId.
+type Executor interface {
+	ProcessTransfer(ctx context.Context, req models.TransferRequest) models.TransferResult
+}
+
+// transientCodes are failures known to end an attempt before anything is
+// committed, so a later attempt under a new RequestId cannot pay twice.
+// CONCURRENT_MODIFICATION is the store rejecting the commit itself, still
+// failing once the service's own retries ran out; STORAGE_READ_FAILED is the
+// store failing while the accounts were read. REQUEST_IN_PROGRESS is absent
+// because the other run may yet succeed under the same RequestId.
+var transientCodes = map[string]bool{
+	"CONCURRENT_MODIFICATION": true,
+	"STORAGE_READ_FAILED":     true,
+}
+
+// unresolvedCodes are failures after which money may have moved: the commit
+// may have landed with its acknowledgement lost, or a rollback may itself
+// have failed. Retrying under a new RequestId would bypass idempotency and
+// could pay twice, so the attempt is flagged for someone to check the
+// transfer under its RequestId instead. PARTIAL_FAILURE_COMPENSATED is
+// absent: every write it made was rolled back.
+var unresolvedCodes = map[string]bool{
+	"TIMEOUT":             true,
+	"CANCELLED":           true,
+	"STORAGE_ERROR":       true,
+	"COMPENSATION_FAILED": true,
+}
+
+const (
+	defaultMaxAttempts = 3
+	defaultBackoff     = time.Minute
+)
+
+// Scheduler stores scheduled instructions in memory and runs the ones that
+// are due. RunDue does one pass; Run calls it on a ticker.
+type Scheduler struct {
+	executor     Executor
+	instructions map[string]*Instruction
+	order        []string
+	executions   []Execution
+	seq          int64
+	now          func() time.Time
+	maxAttempts  int
+	backoff      time.Duration
+	mutex        sync.Mutex
+	runMutex     sync.Mutex // one RunDue at a time
+}
+
+type Option func(*Scheduler)
+
+// WithClock replaces time.Now so tests can move time forward
+func WithClock(now func() time.Time) Option {
+	return func(s *Scheduler) { s.now = now }
+}
+
+// WithRetry sets how many times an occurrence is tried and the delay before
+// the first retry, which doubles for each one after that
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(s *Scheduler) {
+		if maxAttempts > 0 {
+			s.maxAttempts = maxAttempts
+		}
+		if backoff > 0 {
+			s.backoff = backoff
+		}
+	}
+}
+
+func NewScheduler(executor Executor, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		executor:     executor,
+		instructions: make(map[string]*Instruction),
+		now:          time.Now,
+		maxAttempts:  defaultMaxAttempts,
+		backoff:      defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Schedule validates and stores an instruction, assigning its id; the first
+// occurrence runs at StartAt
+func (s *Scheduler) Schedule(ctx context.Context, in Instruction) (*Instruction, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.WrapContextError(ctx.Err())
+	default:
+	}
+	if err := in.validate(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seq++
+	stored := in
+	stored.ID = fmt.Sprintf("SI-%06d", s.seq)
+	stored.Status = InstructionActive
+	stored.CreatedAt = s.now()
+	stored.Occurrence, stored.Attempts = 0, 0
+	stored.NextRunAt = stored.StartAt
+	s.instructions[stored.ID] = &stored
+	s.order = append(s.order, stored.ID)
+
+	copied := stored
+	return &copied, nil
+}
+
+func (s *Scheduler) Get(id string) (*Instruction, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	in, ok := s.instructions[id]
+	if !ok {
+		return nil, NewInstructionNotFoundError(id)
+	}
+	copied := *in
+	return &copied, nil
+}
+
+// List returns every instruction in the order it was scheduled
+func (s *Scheduler) List() []*Instruction {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := make([]*Instruction, 0, len(s.order))
+	for _, id := range s.order {
+		copied := *s.instructions[id]
+		list = append(list, &copied)
+	}
+	return list
+}
+
+// Pause stops an active instruction; occurrences that fall due while it is
+// paused are skipped, not caught up
+func (s *Scheduler) Pause(id string) error {
+	return s.transition(id, "pause", func(in *Instruction) bool {
+		if in.Status != InstructionActive {
+			return false
+		}
+		in.Status = InstructionPaused
+		return true
+	})
+}
+
+// Resume reactivates a paused instruction from its next occurrence that is
+// not already in the past
+func (s *Scheduler) Resume(id string) error {
+	return s.transition(id, "resume", func(in *Instruction) bool {
+		if in.Status != InstructionPaused {
+			return false
+		}
+		in.Status = InstructionActive
+		now := s.now()
+		for {
+			at, ok := in.OccurrenceAt(in.Occurrence)
+			if !ok {
+				in.Status, in.NextRunAt = InstructionCompleted, time.Time{}
+				return true
+			}
+			if !at.Before(now) {
+				in.Attempts, in.NextRunAt = 0, at
+				return true
+			}
+			in.Occurrence++
+		}
+	})
+}
+
+// Cancel stops an instruction for good
+func (s *Scheduler) Cancel(id string) error {
+	return s.transition(id, "cancel", func(in *Instruction) bool {
+		if in.Status != InstructionActive && in.Status != InstructionPaused {
+			return false
+		}
+		in.Status, in.NextRunAt = InstructionCancelled, time.Time{}
+		return true
+	})
+}
+
+func (s *Scheduler) transition(id, op string, apply func(*Instruction) bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	in, ok := s.instructions[id]
+	if !ok {
+		return NewInstructionNotFoundError(id)
+	}
+	if status := in.Status; !apply(in) {
+		return NewInvalidInstructionStateError(id, status, op)
+	}
+	return nil
+}
+
+// Executions returns the execution log of one instruction, oldest first
+func (s *Scheduler) Executions(instructionId string) []Execution {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var log []Execution
+	for _, e := range s.executions {
+		if e.InstructionId == instructionId {
+			log = append(log, e)
+		}
+	}
+	return log
+}
+
+// RunDue executes every occurrence that is due, oldest first, including ones
+// missed while the scheduler was not running. It returns what it executed.
+func (s *Scheduler) RunDue(ctx context.Context) []Execution {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	var ran []Execution
+	for ctx.Err() == nil {
+		job, ok := s.nextDue()
+		if !ok {
+			break
+		}
+		result := s.executor.ProcessTransfer(ctx, job.req)
+		ran = append(ran, s.finish(job, result))
+	}
+	return ran
+}
+
+// Run calls RunDue every interval until ctx ends
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.RunDue(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// dueJob is a snapshot of the occurrence being run, taken under the lock
+type dueJob struct {
+	instructionId string
+	occurrence    int
+	attempt       int
+	scheduledFor  time.Time
+	req           models.TransferRequest
+}
+
+func (s *Scheduler) nextDue() (dueJob, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	var due []*Instruction
+	for _, in := range s.instructions {
+		if in.Status == InstructionActive && !in.NextRunAt.After(now) {
+			due = append(due, in)
+		}
+	}
+	if len(due) == 0 {
+		return dueJob{}, false
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextRunAt.Equal(due[j].NextRunAt) {
+			return due[i].NextRunAt.Before(due[j].NextRunAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+
+	in := due[0]
+	scheduledFor, _ := in.OccurrenceAt(in.Occurrence)
+	attempt := in.Attempts + 1
+	requestId := in.RequestId(in.Occurrence)
+	if attempt > 1 {
+		// the first attempt's failure is stored under the occurrence's
+		// RequestId, so a retry needs a key of its own; it is still
+		// deterministic, so a rerun of the retry is deduplicated too
+		requestId = fmt.Sprintf("%s-r%d", requestId, attempt)
+	}
+	return dueJob{
+		instructionId: in.ID,
+		occurrence:    in.Occurrence,
+		attempt:       attempt,
+		scheduledFor:  scheduledFor,
+		req: models.TransferRequest{
+			FromAccountId: in.FromAccountId,
+			ToAccountId:   in.ToAccountId,
+			Amount:        in.Amount,
+			RequestId:     requestId,
+		},
+	}, true
+}
+
+// finish logs an attempt and moves the instruction on to its retry or its
+// next occurrence
+func (s *Scheduler) finish(job dueJob, result models.TransferResult) Execution {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	exec := Execution{
+		InstructionId: job.instructionId,
+		Occurrence:    job.occurrence,
+		Attempt:       job.attempt,
+		RequestId:     job.req.RequestId,
+		ScheduledFor:  job.scheduledFor,
+		ExecutedAt:    now,
+		Success:       result.Success,
+		TransferId:    result.TransferId,
+		ErrorCode:     models.ErrorCode(result.Error),
+	}
+
+	in := s.instructions[job.instructionId]
+	// a pause that landed mid-run still moves past this occurrence, so
+	// resuming does not run it again; a cancelled instruction stays as it is
+	if in.Status != InstructionCancelled && in.Occurrence == job.occurrence {
+		if !result.Success && transientCodes[exec.ErrorCode] && job.attempt < s.maxAttempts {
+			exec.WillRetry = true
+			in.Attempts = job.attempt
+			in.NextRunAt = now.Add(s.backoff << (job.attempt - 1))
+		} else {
+			in.Occurrence++
+			in.Attempts = 0
+			if next, ok := in.OccurrenceAt(in.Occurrence); ok {
+				in.NextRunAt = next
+			} else {
+				in.Status, in.NextRunAt = InstructionCompleted, time.Time{}
+			}
+		}
+	}
+	if !result.Success && unresolvedCodes[exec.ErrorCode] {
+		exec.Unresolved = true
+		fmt.Printf("[SCHEDULER] %s ended with %s and may have moved money; check it before paying it again\n", exec.RequestId, exec.ErrorCode)
+	}
+	s.executions = append(s.executions, exec)
+	return exec
+}
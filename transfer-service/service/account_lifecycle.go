@@ -0,0 +1,109 @@
+// File: service/account_lifecycle.go
+package service
+
+import (
+	"context"
+	"transfer-service/ledger"
+	"transfer-service/models"
+)
+
+// allowedTransitions lists the status changes each lifecycle call may make
+var allowedTransitions = map[models.AccountStatus][]models.AccountStatus{
+	models.AccountActive: {models.AccountFrozen, models.AccountClosed},
+	models.AccountFrozen: {models.AccountActive, models.AccountClosed},
+}
+
+func (s *UPITransferService) OpenAccount(ctx context.Context, accountId, name string, openingBalance models.Money) (*models.Account, error) {
+	if accountId == "" {
+		return nil, models.NewEmptyAccountIdError()
+	}
+	if _, ok := models.CurrencyExponent(openingBalance.Currency()); !ok {
+		return nil, models.NewUnknownCurrencyError(openingBalance.Currency())
+	}
+	if openingBalance.IsNegative() {
+		return nil, models.NewInvalidAmountError(openingBalance)
+	}
+
+	account := &models.Account{
+		ID:       accountId,
+		Name:     name,
+		Balance:  openingBalance,
+		Currency: openingBalance.Currency(),
+		Status:   models.AccountActive,
+	}
+	if err := s.accountRepo.CreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	if s.ledger != nil {
+		if err := ledger.RecordOpeningBalances(context.WithoutCancel(ctx), s.ledger, []*models.Account{account}); err != nil {
+			return nil, err
+		}
+	}
+	return account, nil
+}
+
+// FreezeAccount stops money leaving the account; credits still land
+func (s *UPITransferService) FreezeAccount(ctx context.Context, accountId string) error {
+	return s.changeStatus(ctx, accountId, models.AccountFrozen)
+}
+
+func (s *UPITransferService) UnfreezeAccount(ctx context.Context, accountId string) error {
+	return s.changeStatus(ctx, accountId, models.AccountActive)
+}
+
+// CloseAccount permanently closes an account; it must be empty first
+func (s *UPITransferService) CloseAccount(ctx context.Context, accountId string) error {
+	return s.changeStatus(ctx, accountId, models.AccountClosed)
+}
+
+func (s *UPITransferService) changeStatus(ctx context.Context, accountId string, target models.AccountStatus) error {
+	for attempt := 1; ; attempt++ {
+		err := s.applyStatus(ctx, accountId, target)
+		if !isConcurrentModification(err) || attempt == maxCommitAttempts {
+			return err
+		}
+	}
+}
+
+func (s *UPITransferService) applyStatus(ctx context.Context, accountId string, target models.AccountStatus) error {
+	acc, err := s.accountRepo.GetAccountById(ctx, accountId)
+	if err != nil {
+		return err
+	}
+
+	// decide and change under the account lock so a concurrent transfer sees
+	// either the old status or the new one, never a half-made decision
+	acc.Mutex.Lock()
+	current := acc.Status
+	if current == "" {
+		current = models.AccountActive
+	}
+	if !transitionAllowed(current, target) {
+		acc.Mutex.Unlock()
+		return models.NewInvalidStatusTransitionError(accountId, current, target)
+	}
+	if target == models.AccountClosed && !acc.Balance.IsZero() {
+		balance := acc.Balance
+		acc.Mutex.Unlock()
+		return models.NewAccountNotEmptyError(accountId, balance)
+	}
+	acc.Status = target
+	acc.Mutex.Unlock()
+
+	if err := s.accountRepo.UpdateAccount(ctx, acc); err != nil {
+		acc.Mutex.Lock()
+		acc.Status = current
+		acc.Mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+func transitionAllowed(from, to models.AccountStatus) bool {
+	for _, allowed := range allowedTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+// File: service/atomic_batch.go
+package service
+
+import (
+	"context"
+	"transfer-service/ledger"
+	"transfer-service/limits"
+	"transfer-service/models"
+	"transfer-service/repository"
+)
+
+// noCulprit marks a batch failure that no single item is to blame for, such
+// as a storage error while committing
+const noCulprit = -1
+
+// atomicBatch applies every transfer or none of them. Balances are checked
+// for the batch as a whole before anything moves, so an account may spend
+// money it receives earlier or later in the same batch (1→2, 2→3). If any
+// item fails, its result carries the cause and every other item fails with
+// BATCH_ABORTED naming it.
+//
+// Items are not run through the idempotency store: replaying some of them
+// while applying the rest would break the all-or-nothing guarantee.
+func (s *UPITransferService) atomicBatch(ctx context.Context, transfers []models.TransferRequest) []models.TransferResult {
+	started := s.now()
+	for _, req := range transfers {
+		s.incrementTransferCount()
+		s.emitInitiated(ctx, req)
+	}
+
+	mvs, culprit, err := s.settleBatch(ctx, transfers)
+
+	results := make([]models.TransferResult, len(transfers))
+	for i, req := range transfers {
+		result := models.TransferResult{RequestId: req.RequestId, Success: err == nil}
+		switch {
+		case err == nil:
+			result.Conversion, result.Fees = mvs[i].conversion, mvs[i].fees
+			s.incrementSuccessCount()
+			s.observe(ctx, req)
+		case culprit == noCulprit || culprit == i:
+			result.Error = err
+		default:
+			result.Error = models.NewBatchAbortedError(culprit, transfers[culprit].RequestId, err)
+		}
+		if result.Error != nil {
+			s.emitFailed(ctx, req, result.Error)
+		}
+		result.TransferId = s.recordHistory(ctx, req, result, started, "")
+		results[i] = result
+	}
+	return results
+}
+
+// settleBatch validates the batch, reserves its limits and commits it,
+// retrying the commit on a version conflict like move does. On failure it
+// returns the index of the item to blame, or noCulprit.
+func (s *UPITransferService) settleBatch(ctx context.Context, transfers []models.TransferRequest) ([]*movement, int, error) {
+	for i, req := range transfers {
+		if err := s.validateInput(req.FromAccountId, req.ToAccountId, req.Amount); err != nil {
+			return nil, i, err
+		}
+	}
+	// a batch cannot wait on one item's review, so review fails it like a denial
+	for i, req := range transfers {
+		if err := s.screen(ctx, req, false); err != nil {
+			return nil, i, err
+		}
+	}
+
+	var reservations []*limits.Reservation
+	release := func() {
+		for _, r := range reservations {
+			r.Release()
+		}
+	}
+	if s.limits != nil {
+		for i, req := range transfers {
+			r, err := s.limits.Reserve(ctx, req)
+			if err != nil {
+				release()
+				return nil, i, err
+			}
+			reservations = append(reservations, r)
+		}
+	}
+
+	var (
+		mvs     []*movement
+		culprit int
+		err     error
+	)
+	for attempt := 1; attempt <= maxCommitAttempts; attempt++ {
+		mvs, culprit, err = s.executeBatch(ctx, transfers)
+		if !isConcurrentModification(err) {
+			break
+		}
+	}
+	if err != nil {
+		// a failed rollback may have left money moved, so its usage still counts
+		if models.ErrorCode(err) != "COMPENSATION_FAILED" {
+			release()
+		}
+		return nil, culprit, err
+	}
+	return mvs, noCulprit, nil
+}
+
+// executeBatch loads every account the batch touches, prices each item,
+// checks and applies them all under one lock of every account, persists the
+// accounts together and journals each item. Each item's journal entry is
+// built and checked before anything moves.
+func (s *UPITransferService) executeBatch(ctx context.Context, transfers []models.TransferRequest) ([]*movement, int, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, req := range transfers {
+		add(req.FromAccountId)
+		add(req.ToAccountId)
+		add(s.revenueAccountFor(req))
+	}
+	loaded, err := s.accountRepo.GetMultipleAccounts(ctx, ids)
+	if err != nil {
+		return nil, missingAccountCulprit(transfers, err), err
+	}
+	byId := make(map[string]*models.Account, len(loaded))
+	for _, acc := range loaded {
+		byId[acc.ID] = acc
+	}
+
+	mvs := make([]*movement, len(transfers))
+	entries := make([]*ledger.JournalEntry, len(transfers))
+	for i, req := range transfers {
+		if mvs[i], err = s.price(ctx, req, byId[req.FromAccountId], byId[req.ToAccountId], byId[s.revenueAccountFor(req)]); err != nil {
+			return nil, i, err
+		}
+		if entries[i], err = s.prepareJournal(req, mvs[i]); err != nil {
+			return nil, i, err
+		}
+	}
+
+	unlock := lockAccounts(loaded...)
+	if culprit, err := checkBatch(mvs); err != nil {
+		unlock()
+		return nil, culprit, err
+	}
+	for i, mv := range mvs {
+		if err := mv.apply(false); err != nil {
+			revertBatch(mvs[:i])
+			unlock()
+			return nil, i, err
+		}
+	}
+	unlock()
+
+	revert := func() error {
+		unlock := lockAccounts(loaded...)
+		defer unlock()
+		return revertBatch(mvs)
+	}
+	var records []*repository.OutboxRecord
+	for i, req := range transfers {
+		records = append(records, s.completionRecords(req, mvs[i])...)
+	}
+	if err := s.persist(ctx, loaded, records, revert); err != nil {
+		return nil, noCulprit, err
+	}
+
+	// every item has moved money now, so the batch has succeeded whatever
+	// the ledger does; an entry it fails to record is left to reconciliation
+	for _, entry := range entries {
+		s.journal(ctx, entry)
+	}
+	return mvs, noCulprit, nil
+}
+
+// missingAccountCulprit blames an ACCOUNT_NOT_FOUND on the first item that
+// names the account
+func missingAccountCulprit(transfers []models.TransferRequest, err error) int {
+	te, ok := err.(*models.TransferError)
+	if !ok || te.Code != "ACCOUNT_NOT_FOUND" {
+		return noCulprit
+	}
+	for i, req := range transfers {
+		if req.FromAccountId == te.Details["accountId"] || req.ToAccountId == te.Details["accountId"] {
+			return i
+		}
+	}
+	return noCulprit
+}
+
+// checkBatch checks that every account can take part and that no sender ends
+// the batch overdrawn. Each sender may spend its available balance plus
+// everything the batch credits to it; the item blamed for an overdraft is the
+// first whose debit exceeds what is left of that. The caller holds every
+// account's lock.
+func checkBatch(mvs []*movement) (int, error) {
+	available := make(map[string]models.Money)
+	credit := func(acc *models.Account, amt models.Money) error {
+		bal, ok := available[acc.ID]
+		if !ok {
+			bal = acc.AvailableLocked()
+		}
+		next, err := bal.Add(amt)
+		if err != nil {
+			return err
+		}
+		available[acc.ID] = next
+		return nil
+	}
+
+	for i, mv := range mvs {
+		if err := mv.from.CanDebit(); err != nil {
+			return i, err
+		}
+		if err := mv.to.CanCredit(); err != nil {
+			return i, err
+		}
+		if err := credit(mv.to, mv.credit); err != nil {
+			return i, err
+		}
+		if mv.revenue != nil {
+			if err := mv.revenue.CanCredit(); err != nil {
+				return i, err
+			}
+			if err := credit(mv.revenue, mv.fee); err != nil {
+				return i, err
+			}
+		}
+	}
+
+	for i, mv := range mvs {
+		debit, err := mv.amount.Add(mv.fee)
+		if err != nil {
+			return i, err
+		}
+		bal, ok := available[mv.from.ID]
+		if !ok {
+			bal = mv.from.AvailableLocked()
+		}
+		left, err := bal.Sub(debit)
+		if err != nil {
+			return i, err
+		}
+		if left.IsNegative() {
+			return i, models.NewInsufficientBalanceError(mv.from.ID, bal, mv.amount, mv.fee)
+		}
+		available[mv.from.ID] = left
+	}
+	return noCulprit, nil
+}
+
+// revertBatch undoes applied movements, latest first. The caller holds every
+// account's lock.
+func revertBatch(mvs []*movement) error {
+	for i := len(mvs) - 1; i >= 0; i-- {
+		if err := mvs[i].apply(true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// File: service/bulk.go
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+	"transfer-service/models"
+)
+
+const defaultBulkWorkers = 3
+
+// BulkOptions controls how a batch runs. The zero value runs three workers,
+// reports results as they complete and never stops early. Atomic runs the
+// batch all-or-nothing instead; the other options do not apply to it.
+type BulkOptions struct {
+	Atomic           bool          // apply every transfer or none, see atomicBatch
+	Workers          int           // concurrent transfers; 0 means 3
+	Ordered          bool          // report results in input order
+	StopOnFirstError bool          // same as MaxFailures: 1
+	MaxFailures      int           // stop dispatching after this many failures; 0 means never
+	ItemTimeout      time.Duration // deadline for each transfer; 0 means none beyond the service's own
+}
+
+func (o BulkOptions) workers() int {
+	if o.Workers <= 0 {
+		return defaultBulkWorkers
+	}
+	return o.Workers
+}
+
+func (o BulkOptions) failureLimit() int {
+	if o.StopOnFirstError {
+		return 1
+	}
+	return o.MaxFailures
+}
+
+// BulkResult is one item's outcome together with its position in the input
+type BulkResult struct {
+	Index int
+	models.TransferResult
+}
+
+// BulkTransfer runs every request and returns one result per request. Items
+// that were never attempted because the batch stopped early fail with
+// BULK_ABORTED, or with the context's error if ctx ended first.
+func (s *UPITransferService) BulkTransfer(ctx context.Context, transfers []models.TransferRequest, opts BulkOptions) []models.TransferResult {
+	results := make([]models.TransferResult, 0, len(transfers))
+	s.BulkTransferEach(ctx, transfers, opts, func(r BulkResult) {
+		results = append(results, r.TransferResult)
+	})
+	return results
+}
+
+// BulkTransferStream is BulkTransfer delivering results on a channel as they
+// become available, so a large batch is never held in memory all at once.
+// The channel is closed after the last result; the caller must drain it.
+func (s *UPITransferService) BulkTransferStream(ctx context.Context, transfers []models.TransferRequest, opts BulkOptions) <-chan BulkResult {
+	out := make(chan BulkResult, opts.workers())
+	go func() {
+		defer close(out)
+		s.BulkTransferEach(ctx, transfers, opts, func(r BulkResult) { out <- r })
+	}()
+	return out
+}
+
+// BulkTransferEach is BulkTransfer handing each result to fn as it becomes
+// available. fn is called from a single goroutine, once per request, and
+// BulkTransferEach returns after the last call.
+func (s *UPITransferService) BulkTransferEach(ctx context.Context, transfers []models.TransferRequest, opts BulkOptions, fn func(BulkResult)) {
+	if opts.Atomic {
+		for i, r := range s.atomicBatch(ctx, transfers) {
+			fn(BulkResult{Index: i, TransferResult: r})
+		}
+		return
+	}
+
+	var (
+		workers  = opts.workers()
+		limit    = opts.failureLimit()
+		failures atomic.Int64
+		stopped  atomic.Bool
+		next     int // first index not yet dispatched, owned by the feeder until jobs is closed
+	)
+
+	// in ordered mode a result can only be reported once every earlier one
+	// has been, so the feeder stays at most a window ahead of the reporter
+	// to bound the reorder buffer
+	var window chan struct{}
+	if opts.Ordered {
+		window = make(chan struct{}, workers*4)
+	}
+
+	// skipped reports an item that was never attempted
+	skipped := func(idx int) BulkResult {
+		var err error = models.NewBulkAbortedError(int(failures.Load()))
+		if ctx.Err() != nil && !stopped.Load() {
+			err = models.WrapContextError(ctx.Err())
+		}
+		return BulkResult{Index: idx, TransferResult: models.TransferResult{RequestId: transfers[idx].RequestId, Error: err}}
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for ; next < len(transfers); next++ {
+			if window != nil {
+				select {
+				case window <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if stopped.Load() || ctx.Err() != nil {
+				return
+			}
+			select {
+			case jobs <- next:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	done := make(chan BulkResult, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				// the feeder may have handed this item over just before the
+				// batch stopped
+				if stopped.Load() || ctx.Err() != nil {
+					done <- skipped(idx)
+					continue
+				}
+				result := s.bulkItem(ctx, transfers[idx], opts.ItemTimeout)
+				if !result.Success {
+					if n := failures.Add(1); limit > 0 && n >= int64(limit) {
+						stopped.Store(true)
+					}
+				}
+				done <- BulkResult{Index: idx, TransferResult: result}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	pending := make(map[int]BulkResult)
+	reported := 0
+	for r := range done {
+		if !opts.Ordered {
+			fn(r)
+			continue
+		}
+		pending[r.Index] = r
+		for {
+			ready, ok := pending[reported]
+			if !ok {
+				break
+			}
+			delete(pending, reported)
+			fn(ready)
+			reported++
+			<-window
+		}
+	}
+
+	// done is closed only after the feeder has closed jobs, so next is final
+	for idx := next; idx < len(transfers); idx++ {
+		fn(skipped(idx))
+	}
+}
+
+func (s *UPITransferService) bulkItem(ctx context.Context, req models.TransferRequest, timeout time.Duration) models.TransferResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return s.ProcessTransfer(ctx, req)
+}
@@ -0,0 +1,45 @@
+// File: service/conversion.go
+package service
+
+import (
+	"context"
+	"transfer-service/models"
+)
+
+// convert prices a transfer between from and to. amt is in the sender's
+// currency; the returned credit is what the recipient receives, and the
+// conversion is nil when both accounts share a currency.
+func (s *UPITransferService) convert(ctx context.Context, from, to *models.Account, amt models.Money) (models.Money, *models.Conversion, error) {
+	source, target := from.GetCurrency(), to.GetCurrency()
+	if amt.Currency() != source {
+		return models.Money{}, nil, models.NewCurrencyMismatchError(source, amt.Currency())
+	}
+	if source == target {
+		return amt, nil, nil
+	}
+	if s.fx == nil {
+		return models.Money{}, nil, models.NewFXRateUnavailableError(source, target)
+	}
+
+	rate, err := s.fx.Rate(ctx, source, target)
+	if err != nil {
+		return models.Money{}, nil, err
+	}
+	fee, err := amt.MulRat(s.fxMarkupBps, 10000, models.RoundUp)
+	if err != nil {
+		return models.Money{}, nil, err
+	}
+	net, err := amt.Sub(fee)
+	if err != nil {
+		return models.Money{}, nil, err
+	}
+	// round in the house's favour so the FX position never pays out more than it took in
+	converted, err := net.Convert(target, rate.Value, models.RoundDown)
+	if err != nil {
+		return models.Money{}, nil, err
+	}
+	if !converted.IsPositive() {
+		return models.Money{}, nil, models.NewInvalidAmountError(amt)
+	}
+	return converted, &models.Conversion{Rate: rate.String(), Source: amt, Fee: fee, Converted: converted}, nil
+}
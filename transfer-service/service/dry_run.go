@@ -0,0 +1,36 @@
+// File: service/dry_run.go
+package service
+
+import (
+	"context"
+	"transfer-service/models"
+)
+
+// CheckTransfer reports the error ProcessTransfer would fail req with if it
+// ran now, without moving money or using up any limit. Each request is
+// checked against the balances as they stand, without what earlier requests
+// of the same batch would have moved.
+func (s *UPITransferService) CheckTransfer(ctx context.Context, req models.TransferRequest) error {
+	if err := s.validateInput(req.FromAccountId, req.ToAccountId, req.Amount); err != nil {
+		return err
+	}
+	if err := s.screen(ctx, req, false); err != nil {
+		return err
+	}
+	if s.limits != nil {
+		reservation, err := s.limits.Reserve(ctx, req)
+		if err != nil {
+			return err
+		}
+		reservation.Release()
+	}
+
+	mv, err := s.load(ctx, req, true)
+	if err != nil {
+		return err
+	}
+
+	unlock := lockAccounts(mv.accounts()...)
+	defer unlock()
+	return mv.check()
+}
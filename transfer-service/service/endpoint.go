@@ -0,0 +1,222 @@
+// File: service/endpoint.go
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"transfer-service/models"
+	"transfer-service/risk"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Transfer
+type TransferRequest struct {
+	FromAccountId string       `json:"fromAccountId"`
+	ToAccountId   string       `json:"toAccountId"`
+	Amount        models.Money `json:"amount"`
+	RequestId     string       `json:"requestId,omitempty"`
+}
+
+type TransferResponse struct {
+	RequestId  string               `json:"requestId,omitempty"`
+	Success    bool                 `json:"success"`
+	Replayed   bool                 `json:"replayed,omitempty"`
+	Conversion *models.Conversion   `json:"conversion,omitempty"`
+	Fees       *models.FeeBreakdown `json:"fees,omitempty"`
+	// Review is set when the transfer is held for manual review
+	Review *PendingReview `json:"review,omitempty"`
+}
+
+// PendingReview is the review a held transfer waits on. Once it is decided,
+// a retry of the same RequestId reports how the transfer went instead.
+type PendingReview struct {
+	ReviewId string            `json:"reviewId"`
+	Status   risk.ReviewStatus `json:"status"`
+	Rules    []string          `json:"rules,omitempty"`
+}
+
+// StatusCode answers 202 for a transfer held for review, which has been
+// accepted but has not moved any money yet
+func (r TransferResponse) StatusCode() int {
+	if r.Review != nil {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+func (r TransferRequest) toModel() models.TransferRequest {
+	return models.TransferRequest{
+		FromAccountId: r.FromAccountId,
+		ToAccountId:   r.ToAccountId,
+		Amount:        r.Amount,
+		RequestId:     r.RequestId,
+	}
+}
+
+// MakeTransferEndpoint converts ProcessTransfer into a Go Kit endpoint; a
+// failed transfer is returned as the endpoint error so the transport can map
+// it, except for one held for review, which is answered with its review
+func MakeTransferEndpoint(s TransferService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(TransferRequest)
+		result := s.ProcessTransfer(ctx, req.toModel())
+		if review := heldForReview(result.Error); review != nil {
+			return TransferResponse{RequestId: result.RequestId, Replayed: result.Replayed, Review: review}, nil
+		}
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		return TransferResponse{
+			RequestId:  result.RequestId,
+			Success:    true,
+			Replayed:   result.Replayed,
+			Conversion: result.Conversion,
+			Fees:       result.Fees,
+		}, nil
+	}
+}
+
+// heldForReview returns the review err says the transfer waits on, or nil
+// when err is not a hold; a transfer that could not be held stays an error
+func heldForReview(err error) *PendingReview {
+	te, ok := err.(*models.TransferError)
+	if !ok || te.Code != "RISK_REVIEW_REQUIRED" {
+		return nil
+	}
+	reviewId, _ := te.Details["reviewId"].(string)
+	if reviewId == "" {
+		return nil
+	}
+	rules, _ := te.Details["rules"].([]string)
+	return &PendingReview{ReviewId: reviewId, Status: risk.ReviewPending, Rules: rules}
+}
+
+// Balance
+type BalanceRequest struct {
+	AccountId string `json:"accountId"`
+}
+
+// BalanceResponse reports the ledger balance as balance, alongside what is
+// held by open authorizations and what is available to spend
+type BalanceResponse struct {
+	AccountId string       `json:"accountId"`
+	Balance   models.Money `json:"balance"`
+	Held      models.Money `json:"held"`
+	Available models.Money `json:"available"`
+}
+
+func MakeBalanceEndpoint(s TransferService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(BalanceRequest)
+		balance, err := s.GetAccountBalance(ctx, req.AccountId)
+		if err != nil {
+			return nil, err
+		}
+		return BalanceResponse{AccountId: req.AccountId, Balance: balance.Ledger, Held: balance.Held, Available: balance.Available}, nil
+	}
+}
+
+// Bulk transfer
+type BulkTransferRequest struct {
+	Transfers []TransferRequest   `json:"transfers"`
+	Options   *BulkOptionsRequest `json:"options,omitempty"`
+}
+
+// BulkOptionsRequest is the wire form of BulkOptions. Results always come
+// back in input order, since the response is buffered anyway.
+type BulkOptionsRequest struct {
+	Atomic           bool   `json:"atomic,omitempty"`
+	Workers          int    `json:"workers,omitempty"`
+	StopOnFirstError bool   `json:"stopOnFirstError,omitempty"`
+	MaxFailures      int    `json:"maxFailures,omitempty"`
+	ItemTimeout      string `json:"itemTimeout,omitempty"` // Go duration, e.g. "500ms"
+}
+
+func (r BulkTransferRequest) options() (BulkOptions, error) {
+	opts := BulkOptions{Ordered: true}
+	if r.Options == nil {
+		return opts, nil
+	}
+	opts.Atomic = r.Options.Atomic
+	opts.Workers = r.Options.Workers
+	opts.StopOnFirstError = r.Options.StopOnFirstError
+	opts.MaxFailures = r.Options.MaxFailures
+	if r.Options.ItemTimeout != "" {
+		timeout, err := time.ParseDuration(r.Options.ItemTimeout)
+		if err != nil || timeout <= 0 {
+			return BulkOptions{}, invalidRequest("Invalid itemTimeout", map[string]interface{}{"itemTimeout": r.Options.ItemTimeout})
+		}
+		opts.ItemTimeout = timeout
+	}
+	if opts.Workers < 0 || opts.MaxFailures < 0 {
+		return BulkOptions{}, invalidRequest("workers and maxFailures must not be negative", nil)
+	}
+	return opts, nil
+}
+
+type BulkTransferItem struct {
+	RequestId  string               `json:"requestId,omitempty"`
+	Success    bool                 `json:"success"`
+	Replayed   bool                 `json:"replayed,omitempty"`
+	Conversion *models.Conversion   `json:"conversion,omitempty"`
+	Fees       *models.FeeBreakdown `json:"fees,omitempty"`
+	Error      *ErrorResponse       `json:"error,omitempty"`
+}
+
+type BulkTransferResponse struct {
+	Results   []BulkTransferItem `json:"results"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+}
+
+func MakeBulkTransferEndpoint(s TransferService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(BulkTransferRequest)
+		transfers := make([]models.TransferRequest, len(req.Transfers))
+		for i, t := range req.Transfers {
+			transfers[i] = t.toModel()
+		}
+
+		opts, err := req.options()
+		if err != nil {
+			return nil, err
+		}
+
+		resp := BulkTransferResponse{Results: []BulkTransferItem{}}
+		for _, r := range s.BulkTransfer(ctx, transfers, opts) {
+			item := BulkTransferItem{
+				RequestId:  r.RequestId,
+				Success:    r.Success,
+				Replayed:   r.Replayed,
+				Conversion: r.Conversion,
+				Fees:       r.Fees,
+			}
+			if r.Error != nil {
+				errResp := NewErrorResponse(r.Error)
+				item.Error = &errResp
+				resp.Failed++
+			} else {
+				resp.Succeeded++
+			}
+			resp.Results = append(resp.Results, item)
+		}
+		return resp, nil
+	}
+}
+
+// Stats
+type StatsRequest struct{}
+
+type StatsResponse struct {
+	Total   int64 `json:"total"`
+	Success int64 `json:"success"`
+}
+
+func MakeStatsEndpoint(s TransferService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		total, success := s.GetStats()
+		return StatsResponse{Total: total, Success: success}, nil
+	}
+}
@@ -0,0 +1,151 @@
+// File: service/event_publishing.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"transfer-service/events"
+	"transfer-service/models"
+	"transfer-service/repository"
+)
+
+// WithEventBus publishes transfer lifecycle events to bus. Events go through
+// an outbox first: a repository that implements
+// TransactionalOutboxRepository stores a transfer's completion events in the
+// transaction that commits it; otherwise they are kept in memory once the
+// write has succeeded. Either way nothing is published for a transfer that
+// was rolled back.
+//
+// Transfers only append to the outbox and nudge the relay; publishing is
+// up to EventRelay's Run, which must be started for events to reach bus.
+func WithEventBus(bus *events.Bus) Option {
+	return func(s *UPITransferService) {
+		if outbox, ok := s.accountRepo.(repository.TransactionalOutboxRepository); ok {
+			s.outbox = outbox
+		} else {
+			s.outbox = repository.NewInMemoryOutbox()
+		}
+		s.relay = events.NewRelay(s.outbox, bus)
+	}
+}
+
+// EventRelay returns the relay publishing the service's outbox, or nil
+// without an event bus. Run it to publish what transfers append.
+func (s *UPITransferService) EventRelay() *events.Relay {
+	return s.relay
+}
+
+// emitInitiated publishes that req has been accepted for processing
+func (s *UPITransferService) emitInitiated(ctx context.Context, req models.TransferRequest) {
+	if s.outbox == nil {
+		return
+	}
+	s.emit(ctx, events.TransferInitiated{
+		Meta:          events.Meta{OccurredAt: s.now()},
+		Reference:     transferReference(req),
+		RequestId:     req.RequestId,
+		FromAccountId: req.FromAccountId,
+		ToAccountId:   req.ToAccountId,
+		Amount:        req.Amount,
+	})
+}
+
+// emitFailed publishes that req ended with err without moving money
+func (s *UPITransferService) emitFailed(ctx context.Context, req models.TransferRequest, err error) {
+	if s.outbox == nil {
+		return
+	}
+	s.emit(ctx, events.TransferFailed{
+		Meta:          events.Meta{OccurredAt: s.now()},
+		Reference:     transferReference(req),
+		RequestId:     req.RequestId,
+		FromAccountId: req.FromAccountId,
+		ToAccountId:   req.ToAccountId,
+		Amount:        req.Amount,
+		ErrorCode:     models.ErrorCode(err),
+		Message:       err.Error(),
+	})
+}
+
+// completionRecords encodes the events of an applied movement for the
+// outbox: the transfer itself, then every balance it changed
+func (s *UPITransferService) completionRecords(req models.TransferRequest, mv *movement) []*repository.OutboxRecord {
+	if s.outbox == nil {
+		return nil
+	}
+	now, ref := s.now(), transferReference(req)
+	debit, err := mv.amount.Add(mv.fee)
+	if err == nil {
+		debit, err = debit.Neg()
+	}
+	received, rerr := mv.received()
+	if err == nil {
+		err = rerr
+	}
+	revenueDelta := mv.fee
+	if err == nil && mv.returned != nil {
+		revenueDelta, err = mv.returned.Total.Neg()
+	}
+	if err != nil {
+		fmt.Printf("[SERVICE] dropping events of %s: %v\n", ref, err)
+		return nil
+	}
+
+	evs := []events.Event{events.TransferCompleted{
+		Meta:          events.Meta{OccurredAt: now},
+		Reference:     ref,
+		RequestId:     req.RequestId,
+		FromAccountId: mv.from.ID,
+		ToAccountId:   mv.to.ID,
+		Amount:        mv.amount,
+		Credit:        mv.credit,
+		Fee:           mv.fee,
+	}}
+	deltas := []models.Money{debit, received, revenueDelta}
+	for i, acc := range mv.accounts() {
+		evs = append(evs, events.AccountBalanceChanged{
+			Meta:      events.Meta{OccurredAt: now},
+			AccountId: acc.ID,
+			Reference: ref,
+			Delta:     deltas[i],
+			Balance:   mv.after[i],
+		})
+	}
+	return encodeEvents(evs...)
+}
+
+func encodeEvents(evs ...events.Event) []*repository.OutboxRecord {
+	records := make([]*repository.OutboxRecord, 0, len(evs))
+	for _, e := range evs {
+		rec, err := events.NewRecord(e)
+		if err != nil {
+			fmt.Printf("[SERVICE] dropping event: %v\n", err)
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// emit appends events that no stored change depends on, such as a transfer
+// starting or failing
+func (s *UPITransferService) emit(ctx context.Context, evs ...events.Event) {
+	s.stage(ctx, encodeEvents(evs...))
+}
+
+// stage appends records after the change they describe has been stored and
+// nudges the relay to publish them, so that neither listeners nor other
+// transfers' backlog hold up the caller. The change stands either way, so a
+// failed append is logged.
+func (s *UPITransferService) stage(ctx context.Context, records []*repository.OutboxRecord) {
+	if s.outbox == nil {
+		return
+	}
+	if len(records) > 0 {
+		if err := s.outbox.Append(context.WithoutCancel(ctx), records...); err != nil {
+			fmt.Printf("[SERVICE] failed to append %d events to the outbox: %v\n", len(records), err)
+			return
+		}
+	}
+	s.relay.Nudge()
+}
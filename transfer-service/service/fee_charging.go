@@ -0,0 +1,18 @@
+// File: service/fee_charging.go
+package service
+
+import "transfer-service/models"
+
+// revenueAccountFor returns the account that collects fees on req, or ""
+// when nothing can be charged. Money moving into or out of the revenue
+// account itself is never charged.
+func (s *UPITransferService) revenueAccountFor(req models.TransferRequest) string {
+	if s.fees == nil {
+		return ""
+	}
+	revenueId := s.fees.RevenueAccountId
+	if revenueId == "" || revenueId == req.FromAccountId || revenueId == req.ToAccountId {
+		return ""
+	}
+	return revenueId
+}
@@ -0,0 +1,374 @@
+// File: service/holds.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"transfer-service/limits"
+	"transfer-service/models"
+	"transfer-service/repository"
+)
+
+// defaultHoldExpiry is how long an authorization reserves funds unless
+// configured otherwise, in line with common card authorization windows
+const defaultHoldExpiry = 7 * 24 * time.Hour
+
+// expirySweepBatch bounds how many lapsed holds one read returns
+const expirySweepBatch = 100
+
+// WithHoldRepository stores authorizations in repo instead of process memory.
+// Use the account store when it is also a HoldRepository, so that holds
+// survive a restart together with the held amounts they account for.
+func WithHoldRepository(repo repository.HoldRepository) Option {
+	return func(s *UPITransferService) { s.holds = repo }
+}
+
+// WithHoldExpiry sets how long an authorization reserves funds before it
+// lapses and the remainder is released
+func WithHoldExpiry(d time.Duration) Option {
+	return func(s *UPITransferService) {
+		if d > 0 {
+			s.holdExpiry = d
+		}
+	}
+}
+
+// Authorize reserves req.Amount of the sender's available balance. It is
+// screened like a transfer, except that a review decision fails it rather
+// than holding it, and it counts towards the sender's limits until it is
+// voided or lapses without any capture.
+func (s *UPITransferService) Authorize(ctx context.Context, req models.TransferRequest) (*models.Hold, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if err := s.validateInput(req.FromAccountId, req.ToAccountId, req.Amount); err != nil {
+		return nil, err
+	}
+	if err := s.screen(ctx, req, false); err != nil {
+		return nil, err
+	}
+	to, err := s.accountRepo.GetAccountById(ctx, req.ToAccountId)
+	if err != nil {
+		return nil, err
+	}
+	to.Mutex.RLock()
+	err = to.CanCredit()
+	to.Mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var reservation *limits.Reservation
+	if s.limits != nil {
+		if reservation, err = s.limits.Reserve(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.changeHeld(ctx, req.FromAccountId, req.Amount, true); err != nil {
+		reservation.Release()
+		return nil, err
+	}
+
+	now := s.now()
+	hold := &models.Hold{
+		RequestId:     req.RequestId,
+		FromAccountId: req.FromAccountId,
+		ToAccountId:   req.ToAccountId,
+		Amount:        req.Amount,
+		Captured:      models.Zero(req.Amount.Currency()),
+		Status:        models.HoldAuthorized,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(s.holdExpiry),
+		UpdatedAt:     now,
+	}
+	// the funds are held by now, so a cancelled caller must not leave them
+	// held without a record that can release them
+	if err := s.holds.SaveHold(context.WithoutCancel(ctx), hold); err != nil {
+		reservation.Release()
+		if rerr := s.changeHeld(context.WithoutCancel(ctx), req.FromAccountId, req.Amount, false); rerr != nil {
+			return nil, models.NewCompensationFailedError(err, []string{req.FromAccountId}, rerr)
+		}
+		return nil, err
+	}
+	if reservation != nil {
+		s.recordMutex.Lock()
+		s.holdLimits[hold.ID] = reservation
+		s.recordMutex.Unlock()
+	}
+	return hold, nil
+}
+
+func (s *UPITransferService) GetHold(ctx context.Context, holdId string) (*models.Hold, error) {
+	return s.holds.GetHold(ctx, holdId)
+}
+
+// Capture pays the recipient everything the hold still reserves
+func (s *UPITransferService) Capture(ctx context.Context, holdId string) (models.TransferResult, error) {
+	return s.capture(ctx, holdId, nil)
+}
+
+// CapturePartial pays the recipient amount out of the hold. The rest stays
+// reserved for further captures until it is voided or the hold lapses.
+func (s *UPITransferService) CapturePartial(ctx context.Context, holdId string, amount models.Money) (models.TransferResult, error) {
+	return s.capture(ctx, holdId, &amount)
+}
+
+// capture runs the payment as a transfer that spends the held funds, so it
+// is priced, journaled and published like any other. The returned error
+// concerns the hold itself; why the payment failed is in the result.
+func (s *UPITransferService) capture(ctx context.Context, holdId string, amount *models.Money) (models.TransferResult, error) {
+	unlock := s.lockRecord(holdId)
+	defer unlock()
+
+	hold, err := s.openHold(ctx, holdId, "capture")
+	if err != nil {
+		return models.TransferResult{}, err
+	}
+	remaining := hold.Remaining()
+	amt := remaining
+	if amount != nil {
+		amt = *amount
+		if !amt.IsPositive() {
+			return models.TransferResult{}, models.NewInvalidAmountError(amt)
+		}
+		cmp, err := amt.Cmp(remaining)
+		if err != nil {
+			return models.TransferResult{}, err
+		}
+		if cmp > 0 {
+			return models.TransferResult{}, models.NewCaptureExceedsHoldError(holdId, remaining, amt)
+		}
+	}
+
+	req := models.TransferRequest{
+		FromAccountId: hold.FromAccountId,
+		ToAccountId:   hold.ToAccountId,
+		Amount:        amt,
+		RequestId:     fmt.Sprintf("%s-capture-%d", hold.ID, hold.Captures+1),
+	}
+	result := s.transfer(ctx, req, runMode{release: &amt})
+	if !result.Success {
+		return result, nil
+	}
+
+	if hold.Captured, err = hold.Captured.Add(amt); err != nil {
+		return result, err
+	}
+	hold.Captures++
+	hold.Status = models.HoldPartial
+	if hold.Remaining().IsZero() {
+		hold.Status = models.HoldCaptured
+		s.takeHoldLimit(hold.ID)
+	}
+	hold.UpdatedAt = s.now()
+	if err := s.holds.UpdateHold(context.WithoutCancel(ctx), hold); err != nil {
+		// the money has moved and its held funds are released; only the
+		// record lags behind
+		fmt.Printf("[SERVICE] failed to record capture of %s: %v\n", hold.ID, err)
+		return result, err
+	}
+	return result, nil
+}
+
+// Void releases what the hold still reserves. A hold that was partly
+// captured keeps what it paid.
+func (s *UPITransferService) Void(ctx context.Context, holdId string) (*models.Hold, error) {
+	unlock := s.lockRecord(holdId)
+	defer unlock()
+
+	hold, err := s.openHold(ctx, holdId, "void")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.releaseHold(ctx, hold, models.HoldVoided); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// ExpireHolds releases every open hold whose window has passed and returns
+// how many it released. A hold that fails to release is retried on the next
+// sweep; the first such error is returned.
+func (s *UPITransferService) ExpireHolds(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		holds, err := s.holds.ExpiredHolds(ctx, s.now(), expirySweepBatch)
+		if err != nil {
+			return expired, err
+		}
+		var firstErr error
+		for _, h := range holds {
+			ok, err := s.expireHold(ctx, h.ID)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if ok {
+				expired++
+			}
+		}
+		if firstErr != nil || len(holds) < expirySweepBatch {
+			return expired, firstErr
+		}
+	}
+}
+
+// RunHoldExpiry calls ExpireHolds every interval until ctx ends
+func (s *UPITransferService) RunHoldExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ExpireHolds(ctx); err != nil {
+				fmt.Printf("[SERVICE] hold expiry failed: %v\n", err)
+			}
+		}
+	}
+}
+
+func (s *UPITransferService) expireHold(ctx context.Context, holdId string) (bool, error) {
+	unlock := s.lockRecord(holdId)
+	defer unlock()
+
+	hold, err := s.holds.GetHold(ctx, holdId)
+	if err != nil {
+		return false, err
+	}
+	if !hold.Status.Open() || s.now().Before(hold.ExpiresAt) {
+		return false, nil // captured, voided or extended since it was listed
+	}
+	if err := s.releaseHold(ctx, hold, models.HoldExpired); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// openHold reads a hold that may still be captured or voided. A hold past
+// its window is expired on the spot, so a lagging sweep never lets a lapsed
+// authorization be captured. The caller holds the hold's lock.
+func (s *UPITransferService) openHold(ctx context.Context, holdId, op string) (*models.Hold, error) {
+	hold, err := s.holds.GetHold(ctx, holdId)
+	if err != nil {
+		return nil, err
+	}
+	if hold.Status.Open() && !s.now().Before(hold.ExpiresAt) {
+		if err := s.releaseHold(ctx, hold, models.HoldExpired); err != nil {
+			return nil, err
+		}
+	}
+	if !hold.Status.Open() {
+		return nil, models.NewHoldNotOpenError(holdId, hold.Status, op)
+	}
+	return hold, nil
+}
+
+// releaseHold closes a hold with status and hands what it still reserved
+// back to the sender's available balance. The record is closed first so
+// that a failure in between can never leave funds released by an open hold.
+// The caller holds the hold's lock.
+func (s *UPITransferService) releaseHold(ctx context.Context, hold *models.Hold, status models.HoldStatus) error {
+	prev := *hold
+	remaining := hold.Remaining()
+	hold.Status, hold.UpdatedAt = status, s.now()
+	if err := s.holds.UpdateHold(ctx, hold); err != nil {
+		*hold = prev
+		return err
+	}
+	if remaining.IsPositive() {
+		if err := s.changeHeld(ctx, hold.FromAccountId, remaining, false); err != nil {
+			*hold = prev
+			if rerr := s.holds.UpdateHold(context.WithoutCancel(ctx), hold); rerr != nil {
+				return models.NewCompensationFailedError(err, []string{hold.FromAccountId}, rerr)
+			}
+			return err
+		}
+	}
+
+	// usage only goes back to the limits if nothing was ever paid
+	if reservation := s.takeHoldLimit(hold.ID); prev.Captures == 0 {
+		reservation.Release()
+	}
+	return nil
+}
+
+// changeHeld reserves amount on an account, or releases it, and stores the
+// account, retrying from a fresh read on a version conflict. Reserving
+// requires the account to be able to send and to have amount available.
+func (s *UPITransferService) changeHeld(ctx context.Context, accountId string, amount models.Money, reserve bool) error {
+	var err error
+	for attempt := 1; attempt <= maxCommitAttempts; attempt++ {
+		err = s.tryChangeHeld(ctx, accountId, amount, reserve)
+		if !isConcurrentModification(err) {
+			break
+		}
+	}
+	return err
+}
+
+func (s *UPITransferService) tryChangeHeld(ctx context.Context, accountId string, amount models.Money, reserve bool) error {
+	acc, err := s.accountRepo.GetAccountById(ctx, accountId)
+	if err != nil {
+		return err
+	}
+
+	// adjust is called with the account's lock held
+	adjust := func(reserve bool) error {
+		held := acc.HeldLocked()
+		next, err := held.Add(amount)
+		if !reserve {
+			next, err = held.Sub(amount)
+		}
+		if err != nil {
+			return err
+		}
+		if next.IsNegative() {
+			// more released than is held: the records disagree, and
+			// holding a negative amount would inflate what is available
+			fmt.Printf("[SERVICE] releasing %s from %s which only holds %s\n", amount, accountId, held)
+			next = models.Zero(held.Currency())
+		}
+		acc.Held = next
+		return nil
+	}
+
+	acc.Mutex.Lock()
+	if reserve {
+		if err := acc.CanDebit(); err != nil {
+			acc.Mutex.Unlock()
+			return err
+		}
+		available := acc.AvailableLocked()
+		cmp, err := available.Cmp(amount)
+		if err == nil && cmp < 0 {
+			err = models.NewInsufficientBalanceError(accountId, available, amount, models.Zero(amount.Currency()))
+		}
+		if err != nil {
+			acc.Mutex.Unlock()
+			return err
+		}
+	}
+	err = adjust(reserve)
+	acc.Mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return s.persist(ctx, []*models.Account{acc}, nil, func() error {
+		acc.Mutex.Lock()
+		defer acc.Mutex.Unlock()
+		return adjust(!reserve)
+	})
+}
+
+// takeHoldLimit removes and returns the limit usage reserved when the hold
+// was authorized, if any
+func (s *UPITransferService) takeHoldLimit(holdId string) *limits.Reservation {
+	s.recordMutex.Lock()
+	defer s.recordMutex.Unlock()
+	r := s.holdLimits[holdId]
+	delete(s.holdLimits, holdId)
+	return r
+}
@@ -0,0 +1,226 @@
+// File: service/reversal.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"transfer-service/fx"
+	"transfer-service/models"
+)
+
+// Reverse pays back whatever of a completed transfer has not been refunded
+// yet. The original recipient must still have the money: a reversal is an
+// ordinary transfer in the opposite direction, linked to the original, that
+// is neither screened, limited nor charged fees. The sender gets back what
+// they paid, at the original's conversion and with its fees, which the
+// revenue account returns. A second Reverse of the same transfer replays the
+// first instead of failing.
+func (s *UPITransferService) Reverse(ctx context.Context, transferId string) (models.TransferResult, error) {
+	return s.refund(ctx, transferId, nil)
+}
+
+// Refund pays back amount of a completed transfer, in the currency its
+// recipient was credited. Refunds may repeat until the whole credit is paid
+// back, but never beyond it. The sender of a cross-currency transfer gets
+// back its share of what they paid, at no new rate or markup; fees are only
+// returned by Reverse.
+func (s *UPITransferService) Refund(ctx context.Context, transferId string, amount models.Money) (models.TransferResult, error) {
+	return s.refund(ctx, transferId, &amount)
+}
+
+// refund pays back amount, or everything refundable when amount is nil. The
+// returned error concerns the original transfer; why the payment failed is
+// in the result.
+func (s *UPITransferService) refund(ctx context.Context, transferId string, amount *models.Money) (models.TransferResult, error) {
+	if s.transfers == nil {
+		return models.TransferResult{}, models.NewHistoryNotConfiguredError()
+	}
+	unlock := s.lockRecord(transferId)
+	defer unlock()
+
+	original, err := s.transfers.GetTransfer(ctx, transferId)
+	if err != nil {
+		return models.TransferResult{}, err
+	}
+	switch {
+	case original.ReversalOf != "":
+		return models.TransferResult{}, models.NewNotReversibleError(transferId, "it is itself a refund of "+original.ReversalOf)
+	case original.Status != models.TransferCompleted:
+		return models.TransferResult{}, models.NewNotReversibleError(transferId, "it did not complete")
+	case original.ReversalId != "":
+		if amount == nil {
+			return s.replayReversal(ctx, original.ReversalId)
+		}
+		return models.TransferResult{}, models.NewNotReversibleError(transferId, "it was reversed by "+original.ReversalId)
+	}
+
+	refundable, err := original.Refundable()
+	if err != nil {
+		return models.TransferResult{}, err
+	}
+	amt := refundable
+	if amount != nil {
+		amt = *amount
+		if !amt.IsPositive() {
+			return models.TransferResult{}, models.NewInvalidAmountError(amt)
+		}
+		cmp, err := amt.Cmp(refundable)
+		if err != nil {
+			return models.TransferResult{}, err
+		}
+		if cmp > 0 {
+			return models.TransferResult{}, models.NewRefundExceedsOriginalError(transferId, refundable, amt)
+		}
+	} else if refundable.IsZero() {
+		return models.TransferResult{}, models.NewRefundExceedsOriginalError(transferId, refundable, original.Credited())
+	}
+	pb, err := paybackOf(original, amt, amount == nil)
+	if err != nil {
+		return models.TransferResult{}, err
+	}
+
+	// the refund is reserved on the original before any money moves, so a
+	// bookkeeping failure can only under-report what is left to refund
+	refunded := original.Refunded
+	if refunded.Currency() == "" {
+		refunded = models.Zero(amt.Currency())
+	}
+	if original.Refunded, err = refunded.Add(amt); err != nil {
+		return models.TransferResult{}, err
+	}
+	if err := s.transfers.UpdateTransfer(ctx, original); err != nil {
+		return models.TransferResult{}, err
+	}
+
+	req := models.TransferRequest{
+		FromAccountId: original.ToAccountId,
+		ToAccountId:   original.FromAccountId,
+		Amount:        amt,
+		RequestId:     fmt.Sprintf("%s-refund-%d", original.ID, len(original.Refunds)+1),
+	}
+	result := s.transfer(ctx, req, runMode{reversalOf: original.ID, payback: pb})
+	if !result.Success {
+		if unresolvedRefundCodes[models.ErrorCode(result.Error)] {
+			// the payment may have landed; keeping it reserved is the safe side
+			fmt.Printf("[SERVICE] refund of %s ended unresolved, %s stays reserved: %v\n", original.ID, amt, result.Error)
+			return result, nil
+		}
+		original.Refunded = refunded
+		if err := s.transfers.UpdateTransfer(context.WithoutCancel(ctx), original); err != nil {
+			fmt.Printf("[SERVICE] failed to release refund of %s, %s stays reserved: %v\n", original.ID, amt, err)
+		}
+		return result, nil
+	}
+
+	original.Refunds = append(original.Refunds, result.TransferId)
+	if amount == nil {
+		original.ReversalId = result.TransferId
+	}
+	if err := s.transfers.UpdateTransfer(context.WithoutCancel(ctx), original); err != nil {
+		// the money is back with the sender and counted as refunded; only
+		// the link to the refund lags behind
+		fmt.Printf("[SERVICE] failed to record refund of %s: %v\n", original.ID, err)
+		return result, err
+	}
+	return result, nil
+}
+
+// unresolvedRefundCodes are failures that may follow the commit of a refund,
+// whose reservation is therefore kept for reconciliation
+var unresolvedRefundCodes = map[string]bool{
+	"TIMEOUT":             true,
+	"CANCELLED":           true,
+	"STORAGE_ERROR":       true,
+	"COMPENSATION_FAILED": true,
+}
+
+// replayReversal rebuilds the result of a completed reversal from its record
+func (s *UPITransferService) replayReversal(ctx context.Context, reversalId string) (models.TransferResult, error) {
+	reversal, err := s.transfers.GetTransfer(ctx, reversalId)
+	if err != nil {
+		return models.TransferResult{}, err
+	}
+	return models.TransferResult{
+		RequestId:    reversal.RequestId,
+		TransferId:   reversal.ID,
+		Success:      true,
+		Replayed:     true,
+		Conversion:   reversal.Conversion,
+		Fees:         reversal.Fees,
+		FeesReturned: reversal.FeesReturned,
+	}, nil
+}
+
+// payback is what a refund returns to the original sender
+type payback struct {
+	credit     models.Money
+	conversion *models.Conversion   // nil unless the original was converted
+	fees       *models.FeeBreakdown // the original's fees, returned by a full reversal
+}
+
+// paybackOf prices the refund of amt of original. A converted original pays
+// back its recorded source pro rata, cumulatively so that the refunds of the
+// whole credit add up to exactly the source.
+func paybackOf(original *models.Transfer, amt models.Money, full bool) (*payback, error) {
+	pb := &payback{credit: amt}
+	if full {
+		pb.fees = original.Fees
+	}
+	c := original.Conversion
+	if c == nil {
+		return pb, nil
+	}
+	credited, refunded := c.Converted.Minor(), int64(0)
+	if original.Refunded.Currency() != "" {
+		refunded = original.Refunded.Minor()
+	}
+	before, err := c.Source.MulRat(refunded, credited, models.RoundDown)
+	if err != nil {
+		return nil, err
+	}
+	after, err := c.Source.MulRat(refunded+amt.Minor(), credited, models.RoundDown)
+	if err != nil {
+		return nil, err
+	}
+	if pb.credit, err = after.Sub(before); err != nil {
+		return nil, err
+	}
+	if !pb.credit.IsPositive() {
+		return nil, models.NewInvalidAmountError(amt)
+	}
+	source, _ := new(big.Rat).SetString(c.Source.Decimal())
+	converted, _ := new(big.Rat).SetString(c.Converted.Decimal())
+	rate := fx.Rate{Value: source.Quo(source, converted)}
+	pb.conversion = &models.Conversion{Rate: rate.String(), Source: amt, Fee: models.Zero(amt.Currency()), Converted: pb.credit}
+	return pb, nil
+}
+
+// loadPayback reads the accounts a refund touches: the revenue account too
+// when it returns fees
+func (s *UPITransferService) loadPayback(ctx context.Context, req models.TransferRequest, pb *payback) (*movement, error) {
+	ids := []string{req.FromAccountId, req.ToAccountId}
+	if pb.fees != nil {
+		ids = append(ids, pb.fees.RevenueAccountId)
+	}
+	accounts, err := s.accountRepo.GetMultipleAccounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	from, to := accounts[0], accounts[1]
+	if req.Amount.Currency() != from.GetCurrency() {
+		return nil, models.NewCurrencyMismatchError(from.GetCurrency(), req.Amount.Currency())
+	}
+	mv := &movement{
+		from:       from,
+		to:         to,
+		amount:     req.Amount,
+		fee:        models.Zero(req.Amount.Currency()),
+		credit:     pb.credit,
+		conversion: pb.conversion,
+	}
+	if pb.fees != nil {
+		mv.revenue, mv.returned = accounts[2], pb.fees
+	}
+	return mv, nil
+}
@@ -0,0 +1,109 @@
+// File: service/risk_screening.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"transfer-service/models"
+	"transfer-service/risk"
+)
+
+// WithRiskEvaluator screens every transfer before funds move. Denied
+// transfers fail with RISK_DENIED; transfers sent for review are held in a
+// queue and fail with RISK_REVIEW_REQUIRED until a reviewer approves them.
+func WithRiskEvaluator(ev RiskEvaluator) Option {
+	return func(s *UPITransferService) {
+		s.risk = ev
+		s.reviews = risk.NewReviewQueue()
+	}
+}
+
+// screen evaluates req and returns the error it fails with, if any. With
+// hold set, a transfer sent for review is queued; without it, as in a dry run
+// or an atomic batch, it just fails.
+func (s *UPITransferService) screen(ctx context.Context, req models.TransferRequest, hold bool) error {
+	if s.risk == nil {
+		return nil
+	}
+	a, err := s.risk.Evaluate(ctx, req)
+	if err != nil {
+		return err
+	}
+	switch a.Decision {
+	case risk.DecisionDeny:
+		return risk.NewRiskDeniedError(req.FromAccountId, a)
+	case risk.DecisionReview:
+		if !hold {
+			return risk.NewReviewRequiredError("", req.FromAccountId, a)
+		}
+		r := s.reviews.Hold(req, a, s.now())
+		fmt.Printf("[SERVICE] transfer %s held for review as %s: %v\n", transferReference(req), r.ID, a.Rules())
+		return risk.NewReviewRequiredError(r.ID, req.FromAccountId, a)
+	}
+	return nil
+}
+
+// observe feeds a completed transfer to the evaluator's history
+func (s *UPITransferService) observe(ctx context.Context, req models.TransferRequest) {
+	if s.risk != nil {
+		s.risk.Observe(context.WithoutCancel(ctx), req)
+	}
+}
+
+// PendingReviews returns the transfers waiting for a decision, oldest first
+func (s *UPITransferService) PendingReviews() []*risk.Review {
+	if s.reviews == nil {
+		return nil
+	}
+	return s.reviews.Pending()
+}
+
+func (s *UPITransferService) GetReview(id string) (*risk.Review, error) {
+	if s.reviews == nil {
+		return nil, risk.NewReviewNotFoundError(id)
+	}
+	return s.reviews.Get(id)
+}
+
+// ApproveReview runs a held transfer without screening it again; limits and
+// balances are still checked as they stand now. The result replaces the held
+// one in the idempotency store, so a retry of its RequestId sees the outcome.
+func (s *UPITransferService) ApproveReview(ctx context.Context, id string) (models.TransferResult, error) {
+	if s.reviews == nil {
+		return models.TransferResult{}, risk.NewReviewNotFoundError(id)
+	}
+	r, err := s.reviews.Decide(id, risk.ReviewApproved, "", s.now())
+	if err != nil {
+		return models.TransferResult{}, err
+	}
+
+	result := s.transfer(ctx, r.Request, runMode{reviewed: true})
+	s.settleReview(ctx, r, result)
+	if _, err := s.reviews.Settle(id, result); err != nil {
+		fmt.Printf("[SERVICE] failed to record outcome of review %s: %v\n", id, err)
+	}
+	return result, nil
+}
+
+// RejectReview drops a held transfer for good
+func (s *UPITransferService) RejectReview(ctx context.Context, id, note string) (*risk.Review, error) {
+	if s.reviews == nil {
+		return nil, risk.NewReviewNotFoundError(id)
+	}
+	r, err := s.reviews.Decide(id, risk.ReviewRejected, note, s.now())
+	if err != nil {
+		return nil, err
+	}
+	s.settleReview(ctx, r, models.TransferResult{RequestId: r.Request.RequestId, Error: risk.NewReviewRejectedError(id, note)})
+	return r, nil
+}
+
+// settleReview stores a review's final result under its RequestId
+func (s *UPITransferService) settleReview(ctx context.Context, r *risk.Review, result models.TransferResult) {
+	if s.idempotency == nil || r.Request.RequestId == "" {
+		return
+	}
+	if err := s.idempotency.Complete(context.WithoutCancel(ctx), r.Request.RequestId, result); err != nil {
+		fmt.Printf("[SERVICE] failed to store idempotency result for %s: %v\n", r.Request.RequestId, err)
+	}
+}
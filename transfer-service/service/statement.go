@@ -0,0 +1,223 @@
+// File: service/statement.go
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"transfer-service/models"
+	"transfer-service/repository"
+)
+
+const (
+	defaultStatementLimit = 50
+	maxStatementLimit     = 500
+)
+
+type StatementQuery struct {
+	AccountId string
+	From      time.Time // inclusive; zero means from the beginning
+	To        time.Time // exclusive; zero means up to now
+	Cursor    string    // NextCursor of the previous page
+	Limit     int
+}
+
+// StatementLine is one transfer seen from the statement account's side. Fee
+// is what the account paid on top of a debit, or got back with a credit, so
+// a completed line moves the balance by Amount plus Fee. The revenue
+// account sees the fees as lines of their own.
+type StatementLine struct {
+	TransferId   string                `json:"transferId"`
+	RequestId    string                `json:"requestId,omitempty"`
+	Direction    string                `json:"direction"`
+	Counterparty string                `json:"counterparty"`
+	Amount       models.Money          `json:"amount"`
+	Fee          *models.Money         `json:"fee,omitempty"`
+	Status       models.TransferStatus `json:"status"`
+	ErrorCode    string                `json:"errorCode,omitempty"`
+	CreatedAt    time.Time             `json:"createdAt"`
+	CompletedAt  time.Time             `json:"completedAt"`
+}
+
+type Statement struct {
+	AccountId  string          `json:"accountId"`
+	Lines      []StatementLine `json:"lines"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+}
+
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportJSON ExportFormat = "json"
+)
+
+// GetStatement returns one page of an account's transfers, oldest first
+func (s *UPITransferService) GetStatement(ctx context.Context, q StatementQuery) (*Statement, error) {
+	if s.transfers == nil {
+		return nil, models.NewHistoryNotConfiguredError()
+	}
+	if q.AccountId == "" {
+		return nil, models.NewEmptyAccountIdError()
+	}
+	afterSeq, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultStatementLimit
+	}
+	if limit > maxStatementLimit {
+		limit = maxStatementLimit
+	}
+
+	// ask for one extra record to learn whether another page exists
+	records, err := s.transfers.QueryTransfers(ctx, repository.TransferQuery{
+		AccountId: q.AccountId,
+		From:      q.From,
+		To:        q.To,
+		AfterSeq:  afterSeq,
+		Limit:     limit + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{AccountId: q.AccountId, Lines: []StatementLine{}}
+	if len(records) > limit {
+		records = records[:limit]
+		statement.NextCursor = encodeCursor(records[limit-1].Sequence)
+	}
+	for _, t := range records {
+		statement.Lines = append(statement.Lines, statementLine(q.AccountId, t))
+	}
+	return statement, nil
+}
+
+// ExportStatement writes every page of the statement, starting at q.Cursor, to w
+func (s *UPITransferService) ExportStatement(ctx context.Context, q StatementQuery, format ExportFormat, w io.Writer) error {
+	if format != ExportCSV && format != ExportJSON {
+		return &models.TransferError{
+			Code:    "UNSUPPORTED_FORMAT",
+			Message: fmt.Sprintf("Unsupported export format %q", format),
+			Details: map[string]interface{}{"format": format},
+		}
+	}
+
+	full := &Statement{AccountId: q.AccountId, Lines: []StatementLine{}}
+	for {
+		page, err := s.GetStatement(ctx, q)
+		if err != nil {
+			return err
+		}
+		full.Lines = append(full.Lines, page.Lines...)
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+
+	if format == ExportJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(full)
+	}
+	return writeStatementCSV(w, full)
+}
+
+var statementCSVHeader = []string{
+	"transfer_id", "request_id", "created_at", "completed_at", "direction",
+	"counterparty", "amount", "fee", "currency", "status", "error_code",
+}
+
+func writeStatementCSV(w io.Writer, statement *Statement) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(statementCSVHeader); err != nil {
+		return err
+	}
+	for _, l := range statement.Lines {
+		fee := ""
+		if l.Fee != nil {
+			fee = l.Fee.Decimal()
+		}
+		row := []string{
+			l.TransferId,
+			l.RequestId,
+			l.CreatedAt.UTC().Format(time.RFC3339Nano),
+			l.CompletedAt.UTC().Format(time.RFC3339Nano),
+			l.Direction,
+			l.Counterparty,
+			l.Amount.Decimal(),
+			fee,
+			l.Amount.Currency(),
+			string(l.Status),
+			l.ErrorCode,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func statementLine(accountId string, t *models.Transfer) StatementLine {
+	line := StatementLine{
+		TransferId:  t.ID,
+		RequestId:   t.RequestId,
+		Amount:      t.Amount,
+		Status:      t.Status,
+		ErrorCode:   t.ErrorCode,
+		CreatedAt:   t.CreatedAt,
+		CompletedAt: t.CompletedAt,
+	}
+	switch {
+	case t.FromAccountId == accountId:
+		line.Direction, line.Counterparty = "DEBIT", t.ToAccountId
+		if t.Fees != nil {
+			line.Fee = &t.Fees.Total
+		}
+	case t.ToAccountId == accountId:
+		line.Direction, line.Counterparty = "CREDIT", t.FromAccountId
+		// the recipient was credited in its own currency
+		if t.Conversion != nil {
+			line.Amount = t.Conversion.Converted
+		}
+		if t.FeesReturned != nil {
+			line.Fee = &t.FeesReturned.Total
+		}
+	case t.Fees != nil:
+		// the revenue account collected the sender's fees
+		line.Direction, line.Counterparty, line.Amount = "CREDIT", t.FromAccountId, t.Fees.Total
+	case t.FeesReturned != nil:
+		line.Direction, line.Counterparty, line.Amount = "DEBIT", t.ToAccountId, t.FeesReturned.Total
+	}
+	return line
+}
+
+// cursors are opaque to clients; they wrap the last sequence number seen
+func encodeCursor(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("seq:" + strconv.FormatInt(seq, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), "seq:") {
+		return 0, models.NewInvalidCursorError(cursor)
+	}
+	seq, err := strconv.ParseInt(strings.TrimPrefix(string(raw), "seq:"), 10, 64)
+	if err != nil || seq < 0 {
+		return 0, models.NewInvalidCursorError(cursor)
+	}
+	return seq, nil
+}
@@ -3,12 +3,78 @@ package service
 
 import (
 	"context"
+	"io"
+	"transfer-service/fx"
 	"transfer-service/models"
+	"transfer-service/risk"
+)
+
+var (
+	_ TransferService   = (*UPITransferService)(nil)
+	_ AccountService    = (*UPITransferService)(nil)
+	_ StatementService  = (*UPITransferService)(nil)
+	_ RiskReviewService = (*UPITransferService)(nil)
 )
 
 type TransferService interface {
-	Transfer(ctx context.Context, fromAccountId, toAccountId string, amount float64) error
-	GetAccountBalance(ctx context.Context, accountId string) (float64, error)
-	BulkTransfer(ctx context.Context, transfers []models.TransferRequest) []models.TransferResult
+	Transfer(ctx context.Context, fromAccountId, toAccountId string, amount models.Money) error
+	ProcessTransfer(ctx context.Context, req models.TransferRequest) models.TransferResult
+	GetAccountBalance(ctx context.Context, accountId string) (models.AccountBalance, error)
+	BulkTransfer(ctx context.Context, transfers []models.TransferRequest, opts BulkOptions) []models.TransferResult
 	GetStats() (int64, int64)
+
+	// Authorize reserves req.Amount of the sender's available balance for
+	// the recipient without moving it
+	Authorize(ctx context.Context, req models.TransferRequest) (*models.Hold, error)
+	// Capture pays the recipient everything a hold still reserves
+	Capture(ctx context.Context, holdId string) (models.TransferResult, error)
+	// CapturePartial pays the recipient part of a hold; the rest stays
+	// reserved for later captures until it is voided or expires
+	CapturePartial(ctx context.Context, holdId string, amount models.Money) (models.TransferResult, error)
+	// Void releases what a hold still reserves
+	Void(ctx context.Context, holdId string) (*models.Hold, error)
+
+	// Reverse pays back everything of a completed transfer not yet refunded
+	// through a linked opposite transfer. Reversing it again returns the
+	// first reversal.
+	Reverse(ctx context.Context, transferId string) (models.TransferResult, error)
+	// Refund pays back part of a completed transfer, in the currency its
+	// recipient was credited
+	Refund(ctx context.Context, transferId string, amount models.Money) (models.TransferResult, error)
+}
+
+// AccountService manages the account lifecycle
+type AccountService interface {
+	OpenAccount(ctx context.Context, accountId, name string, openingBalance models.Money) (*models.Account, error)
+	FreezeAccount(ctx context.Context, accountId string) error
+	UnfreezeAccount(ctx context.Context, accountId string) error
+	CloseAccount(ctx context.Context, accountId string) error
+}
+
+// StatementService answers transfer history queries
+type StatementService interface {
+	GetStatement(ctx context.Context, q StatementQuery) (*Statement, error)
+	ExportStatement(ctx context.Context, q StatementQuery, format ExportFormat, w io.Writer) error
+}
+
+// FXRateProvider quotes exchange rates for cross-currency transfers. It
+// returns an FX_RATE_UNAVAILABLE error for a pair it cannot price.
+type FXRateProvider interface {
+	Rate(ctx context.Context, from, to string) (fx.Rate, error)
+}
+
+// RiskEvaluator screens a transfer after its input is validated and before
+// any money moves. Observe is told about every transfer that completed, so
+// that rules based on history learn from it. *risk.Engine satisfies it.
+type RiskEvaluator interface {
+	Evaluate(ctx context.Context, req models.TransferRequest) (risk.Assessment, error)
+	Observe(ctx context.Context, req models.TransferRequest)
+}
+
+// RiskReviewService decides on transfers held for manual review
+type RiskReviewService interface {
+	PendingReviews() []*risk.Review
+	GetReview(id string) (*risk.Review, error)
+	ApproveReview(ctx context.Context, id string) (models.TransferResult, error)
+	RejectReview(ctx context.Context, id, note string) (*risk.Review, error)
 }
@@ -0,0 +1,215 @@
+// File: service/transport.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"transfer-service/models"
+
+	"github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+)
+
+// ErrorResponse is the JSON body returned for any failed call
+type ErrorResponse struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+func NewErrorResponse(err error) ErrorResponse {
+	if te, ok := err.(*models.TransferError); ok {
+		return ErrorResponse{Code: te.Code, Message: te.Message, Details: te.Details}
+	}
+	return ErrorResponse{Code: "INTERNAL_ERROR", Message: err.Error()}
+}
+
+// errorStatus maps TransferError codes to HTTP status codes; unknown codes are 500
+var errorStatus = map[string]int{
+	"MALFORMED_REQUEST":           http.StatusBadRequest,
+	"INVALID_REQUEST":             http.StatusBadRequest,
+	"INVALID_AMOUNT":              http.StatusBadRequest,
+	"EMPTY_ACCOUNT_ID":            http.StatusBadRequest,
+	"SAME_ACCOUNT_TRANSFER":       http.StatusBadRequest,
+	"UNKNOWN_CURRENCY":            http.StatusBadRequest,
+	"MALFORMED_AMOUNT":            http.StatusBadRequest,
+	"PRECISION_LOSS":              http.StatusBadRequest,
+	"CURRENCY_MISMATCH":           http.StatusBadRequest,
+	"ACCOUNT_NOT_FOUND":           http.StatusNotFound,
+	"TRANSFER_NOT_FOUND":          http.StatusNotFound,
+	"INVALID_CURSOR":              http.StatusBadRequest,
+	"ACCOUNT_ALREADY_EXISTS":      http.StatusConflict,
+	"ACCOUNT_NOT_EMPTY":           http.StatusConflict,
+	"INVALID_STATUS_TRANSITION":   http.StatusConflict,
+	"ACCOUNT_FROZEN":              http.StatusUnprocessableEntity,
+	"ACCOUNT_CLOSED":              http.StatusUnprocessableEntity,
+	"IDEMPOTENCY_CONFLICT":        http.StatusConflict,
+	"REQUEST_IN_PROGRESS":         http.StatusConflict,
+	"CONCURRENT_MODIFICATION":     http.StatusConflict,
+	"INSUFFICIENT_BALANCE":        http.StatusUnprocessableEntity,
+	"LIMIT_EXCEEDED":              http.StatusUnprocessableEntity,
+	"FX_RATE_UNAVAILABLE":         http.StatusUnprocessableEntity,
+	"RISK_DENIED":                 http.StatusUnprocessableEntity,
+	"RISK_REJECTED":               http.StatusUnprocessableEntity,
+	"RISK_REVIEW_REQUIRED":        http.StatusConflict, // only when no review holds the transfer
+	"REVIEW_NOT_FOUND":            http.StatusNotFound,
+	"INVALID_REVIEW_STATE":        http.StatusConflict,
+	"HOLD_NOT_FOUND":              http.StatusNotFound,
+	"HOLD_NOT_OPEN":               http.StatusConflict,
+	"HOLD_EXPIRED":                http.StatusConflict,
+	"CAPTURE_EXCEEDS_HOLD":        http.StatusUnprocessableEntity,
+	"TRANSFER_NOT_REVERSIBLE":     http.StatusConflict,
+	"REFUND_EXCEEDS_ORIGINAL":     http.StatusUnprocessableEntity,
+	"REVERSAL_INSUFFICIENT_FUNDS": http.StatusUnprocessableEntity,
+	"AMOUNT_OVERFLOW":             http.StatusUnprocessableEntity,
+	"TIMEOUT":                     http.StatusGatewayTimeout,
+	"CANCELLED":                   http.StatusServiceUnavailable,
+	"STORAGE_ERROR":               http.StatusServiceUnavailable,
+	"STORAGE_READ_FAILED":         http.StatusServiceUnavailable,
+	"PARTIAL_FAILURE_COMPENSATED": http.StatusServiceUnavailable,
+	"COMPENSATION_FAILED":         http.StatusInternalServerError,
+}
+
+// HTTPStatus returns the status code used for err
+func HTTPStatus(err error) int {
+	if te, ok := err.(*models.TransferError); ok {
+		if status, ok := errorStatus[te.Code]; ok {
+			return status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(HTTPStatus(err))
+	json.NewEncoder(w).Encode(NewErrorResponse(err))
+}
+
+// EncodeResponse writes response as JSON, with the status it asks for when it
+// implements kithttp.StatusCoder and 200 otherwise
+func EncodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if sc, ok := response.(kithttp.StatusCoder); ok {
+		w.WriteHeader(sc.StatusCode())
+	}
+	return json.NewEncoder(w).Encode(response)
+}
+
+func decodeJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if te, ok := err.(*models.TransferError); ok {
+			return te
+		}
+		return &models.TransferError{Code: "MALFORMED_REQUEST", Message: "Request body is not valid JSON: " + err.Error()}
+	}
+	return nil
+}
+
+func invalidRequest(message string, details map[string]interface{}) error {
+	return &models.TransferError{Code: "INVALID_REQUEST", Message: message, Details: details}
+}
+
+func validateTransferRequest(req TransferRequest) error {
+	var missing []string
+	if req.FromAccountId == "" {
+		missing = append(missing, "fromAccountId")
+	}
+	if req.ToAccountId == "" {
+		missing = append(missing, "toAccountId")
+	}
+	if req.Amount.Currency() == "" {
+		missing = append(missing, "amount")
+	}
+	if len(missing) > 0 {
+		return invalidRequest("Missing required fields", map[string]interface{}{"missing": missing})
+	}
+	return nil
+}
+
+// Decode for Transfer
+func DecodeTransferRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req TransferRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return nil, err
+	}
+	if err := validateTransferRequest(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// Decode for Balance
+func DecodeBalanceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return BalanceRequest{AccountId: r.PathValue("id")}, nil
+}
+
+// Decode for Bulk transfer
+func DecodeBulkTransferRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req BulkTransferRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Transfers) == 0 {
+		return nil, invalidRequest("Bulk request has no transfers", nil)
+	}
+	if _, err := req.options(); err != nil {
+		return nil, err
+	}
+	for i, t := range req.Transfers {
+		if err := validateTransferRequest(t); err != nil {
+			te := err.(*models.TransferError)
+			te.Details["index"] = i
+			return nil, te
+		}
+	}
+	return req, nil
+}
+
+// Decode for Stats
+func DecodeStatsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return StatsRequest{}, nil
+}
+
+// NewHTTPHandler with multiple endpoints
+func NewHTTPHandler(transferEndpoint, balanceEndpoint, bulkTransferEndpoint, statsEndpoint endpoint.Endpoint) http.Handler {
+
+	options := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(EncodeError),
+	}
+
+	mux := http.NewServeMux()
+
+	mux.Handle("POST /transfers", kithttp.NewServer(
+		transferEndpoint,
+		DecodeTransferRequest,
+		EncodeResponse,
+		options...,
+	))
+
+	mux.Handle("GET /accounts/{id}/balance", kithttp.NewServer(
+		balanceEndpoint,
+		DecodeBalanceRequest,
+		EncodeResponse,
+		options...,
+	))
+
+	mux.Handle("POST /transfers/bulk", kithttp.NewServer(
+		bulkTransferEndpoint,
+		DecodeBulkTransferRequest,
+		EncodeResponse,
+		options...,
+	))
+
+	mux.Handle("GET /stats", kithttp.NewServer(
+		statsEndpoint,
+		DecodeStatsRequest,
+		EncodeResponse,
+		options...,
+	))
+
+	return mux
+}
@@ -4,85 +4,692 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
+	"transfer-service/events"
+	"transfer-service/fees"
+	"transfer-service/idempotency"
+	"transfer-service/ledger"
+	"transfer-service/limits"
 	"transfer-service/models"
 	"transfer-service/repository"
+	"transfer-service/risk"
 )
 
 type UPITransferService struct {
 	accountRepo   repository.AccountRepository
+	ledger        ledger.Ledger
+	idempotency   idempotency.Store
+	transfers     repository.TransferRepository
+	limits        *limits.Engine
+	fx            FXRateProvider
+	fxMarkupBps   int64
+	fees          *fees.Schedule
+	risk          RiskEvaluator
+	reviews       *risk.ReviewQueue
+	holds         repository.HoldRepository
+	holdExpiry    time.Duration
+	holdLimits    map[string]*limits.Reservation
+	recordLocks   map[string]*recordLock
+	recordMutex   sync.Mutex // guards holdLimits and recordLocks
+	outbox        repository.Outbox
+	relay         *events.Relay
+	now           func() time.Time
 	transferCount int64
 	successCount  int64
 	mutex         sync.RWMutex
 }
 
-func NewUPITransferService(repo repository.AccountRepository) *UPITransferService {
+// Option configures optional collaborators of UPITransferService
+type Option func(*UPITransferService)
+
+// WithLedger records every transfer as a balanced journal entry
+func WithLedger(l ledger.Ledger) Option {
+	return func(s *UPITransferService) { s.ledger = l }
+}
+
+// WithIdempotencyStore makes requests carrying a RequestId safe to retry
+func WithIdempotencyStore(store idempotency.Store) Option {
+	return func(s *UPITransferService) { s.idempotency = store }
+}
+
+// WithTransferRepository keeps a history record of every transfer attempt
+func WithTransferRepository(repo repository.TransferRepository) Option {
+	return func(s *UPITransferService) { s.transfers = repo }
+}
+
+// WithLimits checks every transfer against the engine's policies before funds move
+func WithLimits(engine *limits.Engine) Option {
+	return func(s *UPITransferService) { s.limits = engine }
+}
+
+// WithFXRateProvider lets transfers move money between accounts held in
+// different currencies; without one such transfers fail with FX_RATE_UNAVAILABLE
+func WithFXRateProvider(p FXRateProvider) Option {
+	return func(s *UPITransferService) { s.fx = p }
+}
+
+// WithFXMarkup keeps a fee of bps basis points of the source amount on every conversion
+func WithFXMarkup(bps int64) Option {
+	return func(s *UPITransferService) { s.fxMarkupBps = bps }
+}
+
+// WithFees charges the schedule's fees on every transfer, crediting them to
+// its revenue account in the same write as the transfer itself
+func WithFees(schedule *fees.Schedule) Option {
+	return func(s *UPITransferService) { s.fees = schedule }
+}
+
+// WithClock replaces time.Now for timestamps, mainly so tests can control it
+func WithClock(now func() time.Time) Option {
+	return func(s *UPITransferService) { s.now = now }
+}
+
+func NewUPITransferService(repo repository.AccountRepository, opts ...Option) *UPITransferService {
 	fmt.Println("[SERVICE] Creating UPITransferService with concurrency support")
-	return &UPITransferService{accountRepo: repo}
+	s := &UPITransferService{
+		accountRepo: repo,
+		now:         time.Now,
+		holds:       repository.NewInMemoryHoldRepository(),
+		holdExpiry:  defaultHoldExpiry,
+		recordLocks: make(map[string]*recordLock),
+		holdLimits:  make(map[string]*limits.Reservation),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *UPITransferService) Transfer(ctx context.Context, fromId, toId string, amount models.Money) error {
+	return s.transfer(ctx, models.TransferRequest{FromAccountId: fromId, ToAccountId: toId, Amount: amount}, runMode{}).Error
 }
 
-func (s *UPITransferService) Transfer(ctx context.Context, fromId, toId string, amount float64) error {
+// ProcessTransfer runs a transfer request. When an idempotency store is
+// configured, a repeated RequestId returns the first result instead of moving
+// money again, and a RequestId reused with a different payload is rejected.
+func (s *UPITransferService) ProcessTransfer(ctx context.Context, req models.TransferRequest) models.TransferResult {
+	if s.idempotency == nil || req.RequestId == "" {
+		return s.transfer(ctx, req, runMode{})
+	}
+
+	fingerprint := idempotency.Fingerprint(req)
+	existing, err := s.idempotency.Begin(ctx, req.RequestId, fingerprint)
+	if err != nil {
+		return models.TransferResult{RequestId: req.RequestId, Error: err}
+	}
+	if existing != nil {
+		switch {
+		case existing.Fingerprint != fingerprint:
+			return models.TransferResult{RequestId: req.RequestId, Error: models.NewIdempotencyConflictError(req.RequestId)}
+		case !existing.Completed:
+			return models.TransferResult{RequestId: req.RequestId, Error: models.NewRequestInProgressError(req.RequestId)}
+		}
+		replay := existing.Result
+		replay.Replayed = true
+		return replay
+	}
+
+	// every outcome is stored, failures included: a timeout may already have
+	// moved money, so letting a retry run again could double-debit
+	result := s.transfer(ctx, req, runMode{})
+	if cerr := s.idempotency.Complete(context.WithoutCancel(ctx), req.RequestId, result); cerr != nil {
+		fmt.Printf("[SERVICE] failed to store idempotency result for %s: %v\n", req.RequestId, cerr)
+	}
+	return result
+}
+
+// runMode says how a transfer attempt deviates from an ordinary one; the
+// zero value is an ordinary transfer
+type runMode struct {
+	reviewed   bool          // approved by a reviewer, so not screened again
+	release    *models.Money // captures a hold: paid from this much of the sender's held funds, already screened and limited
+	reversalOf string        // pays back this transfer: not screened, limited or charged fees
+	payback    *payback      // with reversalOf: what its sender gets back, priced when it was made
+}
+
+// transfer runs one attempt and records it in the transfer history
+func (s *UPITransferService) transfer(ctx context.Context, req models.TransferRequest, mode runMode) models.TransferResult {
+	started := s.now()
+	s.emitInitiated(ctx, req)
+	mv, err := s.move(ctx, req, mode)
+	result := models.TransferResult{RequestId: req.RequestId, Success: err == nil, Error: err}
+	if mv != nil {
+		result.Conversion, result.Fees, result.FeesReturned = mv.conversion, mv.fees, mv.returned
+	}
+	if err != nil {
+		s.emitFailed(ctx, req, err)
+	}
+	result.TransferId = s.recordHistory(ctx, req, result, started, mode.reversalOf)
+	return result
+}
+
+// recordHistory stores the attempt and returns its transfer id. The money has
+// already moved (or not) by now, so a history failure is logged, not returned.
+func (s *UPITransferService) recordHistory(ctx context.Context, req models.TransferRequest, result models.TransferResult, started time.Time, reversalOf string) string {
+	if s.transfers == nil {
+		return ""
+	}
+	record := &models.Transfer{
+		RequestId:     req.RequestId,
+		FromAccountId: req.FromAccountId,
+		ToAccountId:   req.ToAccountId,
+		Amount:        req.Amount,
+		Conversion:    result.Conversion,
+		Fees:          result.Fees,
+		FeesReturned:  result.FeesReturned,
+		Status:        models.TransferCompleted,
+		ErrorCode:     models.ErrorCode(result.Error),
+		CreatedAt:     started,
+		CompletedAt:   s.now(),
+		ReversalOf:    reversalOf,
+	}
+	if result.Error != nil {
+		record.Status = models.TransferFailed
+	}
+	if serr := s.transfers.SaveTransfer(context.WithoutCancel(ctx), record); serr != nil {
+		fmt.Printf("[SERVICE] failed to record transfer history: %v\n", serr)
+		return ""
+	}
+	return record.ID
+}
+
+// move validates the request and moves the money, returning the movement
+// that was applied so the caller can report its conversion and fees
+func (s *UPITransferService) move(ctx context.Context, req models.TransferRequest, mode runMode) (*movement, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	s.incrementTransferCount()
-	if err := s.validateInput(fromId, toId, amount); err != nil {
-		return err
+	if err := s.validateInput(req.FromAccountId, req.ToAccountId, req.Amount); err != nil {
+		return nil, err
+	}
+	if !mode.reviewed && mode.release == nil && mode.reversalOf == "" {
+		if err := s.screen(ctx, req, true); err != nil {
+			return nil, err
+		}
 	}
 
-	accounts, err := s.accountRepo.GetMultipleAccounts(ctx, []string{fromId, toId})
+	var reservation *limits.Reservation
+	if s.limits != nil && mode.release == nil && mode.reversalOf == "" {
+		var err error
+		if reservation, err = s.limits.Reserve(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	// optimistic stores reject a commit if any row changed since it was
+	// read; such a conflict is safe to retry from a fresh read
+	var (
+		mv  *movement
+		err error
+	)
+	for attempt := 1; attempt <= maxCommitAttempts; attempt++ {
+		mv, err = s.executeTransfer(ctx, req, mode)
+		if !isConcurrentModification(err) {
+			break
+		}
+	}
 	if err != nil {
-		return err
+		// a failed rollback may have left money moved, so its usage still counts
+		if models.ErrorCode(err) != "COMPENSATION_FAILED" {
+			reservation.Release()
+		}
+		if te, ok := err.(*models.TransferError); ok && te.Code == "INSUFFICIENT_BALANCE" && mode.reversalOf != "" {
+			err = models.NewReversalInsufficientFundsError(mode.reversalOf, te)
+		}
+		return nil, err
+	}
+
+	s.incrementSuccessCount()
+	if mode.reversalOf == "" {
+		s.observe(ctx, req)
+	}
+	return mv, nil
+}
+
+const maxCommitAttempts = 3
+
+func isConcurrentModification(err error) bool {
+	te, ok := err.(*models.TransferError)
+	return ok && te.Code == "CONCURRENT_MODIFICATION"
+}
+
+// movement is everything one transfer changes. The sender pays amount plus
+// fee; the recipient receives credit, which differs from amount only when the
+// currencies differ; the revenue account, if any, receives the fee.
+type movement struct {
+	from, to   *models.Account
+	revenue    *models.Account
+	amount     models.Money
+	fee        models.Money
+	credit     models.Money
+	conversion *models.Conversion
+	fees       *models.FeeBreakdown
+	release    models.Money         // held funds of the sender a capture settles; zero value otherwise
+	returned   *models.FeeBreakdown // fees a full reversal pays from revenue back to the recipient
+	after      []models.Money       // balances of accounts() as the last apply left them
+}
+
+// accounts lists every account the movement touches
+func (mv *movement) accounts() []*models.Account {
+	if mv.revenue == nil {
+		return []*models.Account{mv.from, mv.to}
+	}
+	return []*models.Account{mv.from, mv.to, mv.revenue}
+}
+
+// executeTransfer loads the accounts, prices any currency conversion and
+// fees, moves the money and persists the result
+func (s *UPITransferService) executeTransfer(ctx context.Context, req models.TransferRequest, mode runMode) (*movement, error) {
+	var (
+		mv  *movement
+		err error
+	)
+	if mode.payback != nil {
+		mv, err = s.loadPayback(ctx, req, mode.payback)
+	} else {
+		mv, err = s.load(ctx, req, mode.reversalOf == "")
+	}
+	if err != nil {
+		return nil, readFailure(err)
+	}
+	if mode.release != nil {
+		mv.release = *mode.release
+	}
+	entry, err := s.prepareJournal(req, mv)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.atomicTransfer(mv); err != nil {
+		return nil, err
+	}
+	if err := s.persist(ctx, mv.accounts(), s.completionRecords(req, mv), func() error { return s.revertInMemory(mv) }); err != nil {
+		return nil, err
+	}
+	s.journal(ctx, entry)
+	return mv, nil
+}
+
+// readFailure tells a storage failure met while loading the accounts, when
+// nothing has been written yet, from one that may follow a commit
+func readFailure(err error) error {
+	if te, ok := err.(*models.TransferError); ok && te.Code == "STORAGE_ERROR" {
+		return models.NewStorageReadError(te)
+	}
+	return err
+}
+
+// prepareJournal builds and checks the entry for mv before any money moves,
+// so that an entry the ledger would reject fails the transfer, not the
+// journaling after it. It returns nil when no ledger is configured.
+func (s *UPITransferService) prepareJournal(req models.TransferRequest, mv *movement) (*ledger.JournalEntry, error) {
+	if s.ledger == nil {
+		return nil, nil
+	}
+	entry, err := journalEntry(req, mv)
+	if err != nil {
+		return nil, err
+	}
+	if err := ledger.ValidateEntry(entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// journal records an entry once its balances are stored, so that a rejected
+// commit leaves no postings behind. The money has moved by then, so neither
+// a cancelled caller nor a ledger failure may undo the transfer; a failure is
+// logged for reconciliation, which reports the account as drifted.
+func (s *UPITransferService) journal(ctx context.Context, entry *ledger.JournalEntry) {
+	if entry == nil {
+		return
+	}
+	if _, err := s.ledger.Record(context.WithoutCancel(ctx), *entry); err != nil {
+		fmt.Printf("[SERVICE] journal entry %s not recorded, needs reconciliation: %v\n", entry.Reference, err)
+	}
+}
+
+// load reads the accounts req touches and prices the movement between them,
+// with fees unless charge is false
+func (s *UPITransferService) load(ctx context.Context, req models.TransferRequest, charge bool) (*movement, error) {
+	ids := []string{req.FromAccountId, req.ToAccountId}
+	revenueId := ""
+	if charge {
+		revenueId = s.revenueAccountFor(req)
+	}
+	if revenueId != "" {
+		ids = append(ids, revenueId)
+	}
+	accounts, err := s.accountRepo.GetMultipleAccounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	var revenue *models.Account
+	if revenueId != "" {
+		revenue = accounts[2]
+	}
+	return s.price(ctx, req, accounts[0], accounts[1], revenue)
+}
+
+// price works out the credit and fee of moving req.Amount between the loaded
+// accounts. revenue is nil when no fee applies to the request.
+func (s *UPITransferService) price(ctx context.Context, req models.TransferRequest, from, to, revenue *models.Account) (*movement, error) {
+	mv := &movement{from: from, to: to, amount: req.Amount, fee: models.Zero(req.Amount.Currency())}
+	var err error
+	if mv.credit, mv.conversion, err = s.convert(ctx, from, to, req.Amount); err != nil {
+		return nil, err
+	}
+	if revenue != nil {
+		if mv.fees, err = s.fees.Calculate(from.GetType(), req.Amount); err != nil {
+			return nil, err
+		}
+		if mv.fees != nil {
+			mv.revenue, mv.fee = revenue, mv.fees.Total
+		}
+	}
+	return mv, nil
+}
+
+// journalEntry books the transfer, its conversion and its fee as one entry
+func journalEntry(req models.TransferRequest, mv *movement) (ledger.JournalEntry, error) {
+	ref := transferReference(req)
+	entry := ledger.NewTransferEntry(ref, mv.from.ID, mv.to.ID, mv.amount)
+	if mv.conversion != nil {
+		var err error
+		entry, err = ledger.NewConversionEntry(ref, mv.from.ID, mv.to.ID, mv.conversion.Source, mv.conversion.Fee, mv.conversion.Converted)
+		if err != nil {
+			return ledger.JournalEntry{}, err
+		}
 	}
-	from, to := accounts[0], accounts[1]
-	success := s.atomicTransfer(from, to, amount)
-	if !success {
-		return models.NewInsufficientBalanceError(fromId, from.GetBalance(), amount)
+	switch {
+	case mv.returned != nil:
+		entry.AddTransfer(mv.revenue.ID, mv.to.ID, mv.returned.Total)
+	case mv.revenue != nil:
+		entry.AddTransfer(mv.from.ID, mv.revenue.ID, mv.fee)
+	}
+	return entry, nil
+}
+
+// persist writes every touched account, in one transaction when the
+// repository supports it. Otherwise the writes run concurrently and, if any
+// of them fails, the ones that went through are compensated. revert undoes
+// the in-memory change whenever the write does not go through. The outbox
+// records describing the change are appended in the same transaction when
+// the repository holds the outbox, and right after the write otherwise.
+func (s *UPITransferService) persist(ctx context.Context, accounts []*models.Account, records []*repository.OutboxRecord, revert func() error) error {
+	if txRepo, ok := s.accountRepo.(repository.TransactionalAccountRepository); ok {
+		var err error
+		if outboxRepo, ok := txRepo.(repository.TransactionalOutboxRepository); ok && s.outbox == outboxRepo && len(records) > 0 {
+			err = outboxRepo.CommitTransferWithOutbox(ctx, records, accounts...)
+			records = nil
+		} else {
+			err = txRepo.CommitTransfer(ctx, accounts...)
+		}
+		if err != nil {
+			if rerr := revert(); rerr != nil {
+				return models.NewCompensationFailedError(err, accountIds(accounts), rerr)
+			}
+			return err
+		}
+		s.stage(ctx, records)
+		return nil
 	}
 
-	errChan := make(chan error, 2)
-	go func() { errChan <- s.accountRepo.UpdateAccount(ctx, from) }()
-	go func() { errChan <- s.accountRepo.UpdateAccount(ctx, to) }()
+	type writeOutcome struct {
+		account *models.Account
+		err     error
+	}
+	outcomes := make(chan writeOutcome, len(accounts))
+	for _, acc := range accounts {
+		go func(acc *models.Account) {
+			outcomes <- writeOutcome{account: acc, err: s.accountRepo.UpdateAccount(ctx, acc)}
+		}(acc)
+	}
 
-	for i := 0; i < 2; i++ {
+	var (
+		written  []*models.Account
+		unknown  = make(map[string]*models.Account, len(accounts))
+		firstErr error
+	)
+	for _, acc := range accounts {
+		unknown[acc.ID] = acc
+	}
+collect:
+	for range accounts {
 		select {
-		case e := <-errChan:
-			if e != nil {
-				return e
+		case o := <-outcomes:
+			delete(unknown, o.account.ID)
+			if o.err != nil {
+				if firstErr == nil {
+					firstErr = o.err
+				}
+				continue
 			}
+			written = append(written, o.account)
 		case <-ctx.Done():
-			return models.NewTimeoutError()
+			firstErr = models.WrapContextError(ctx.Err())
+			break collect
 		}
 	}
+	if firstErr == nil {
+		s.stage(ctx, records)
+		return nil
+	}
 
-	s.incrementSuccessCount()
+	// a write still in flight when the context ended may yet land, so it
+	// is compensated just like one that is known to have succeeded
+	for _, acc := range accounts {
+		if _, ok := unknown[acc.ID]; ok {
+			written = append(written, acc)
+		}
+	}
+	return s.compensate(ctx, firstErr, revert, written)
+}
+
+func accountIds(accounts []*models.Account) []string {
+	ids := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		ids = append(ids, acc.ID)
+	}
+	return ids
+}
+
+// compensationTimeout bounds the rollback writes, which must run even when
+// the caller's context has already ended
+const compensationTimeout = 2 * time.Second
+
+// compensate undoes the in-memory transfer and rewrites every account whose
+// earlier write may have been stored
+func (s *UPITransferService) compensate(ctx context.Context, cause error, revert func() error, written []*models.Account) error {
+	ids := accountIds(written)
+	if err := revert(); err != nil {
+		return models.NewCompensationFailedError(cause, ids, err)
+	}
+	if len(written) == 0 {
+		return cause
+	}
+
+	cctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), compensationTimeout)
+	defer cancel()
+
+	var (
+		unreconciled []string
+		lastErr      error
+	)
+	for _, acc := range written {
+		if err := s.accountRepo.UpdateAccount(cctx, acc); err != nil {
+			unreconciled = append(unreconciled, acc.ID)
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		fmt.Printf("[SERVICE] compensation failed for %v: %v\n", unreconciled, lastErr)
+		return models.NewCompensationFailedError(cause, unreconciled, lastErr)
+	}
+	return models.NewPartialFailureCompensatedError(cause, ids)
+}
+
+// revertInMemory hands the sender back amount and fee and takes the credit
+// and fee back off the other accounts. It applies the reverse deltas rather
+// than restoring a snapshot so that transfers which touched the same
+// accounts in the meantime are preserved.
+func (s *UPITransferService) revertInMemory(mv *movement) error {
+	unlock := lockAccounts(mv.accounts()...)
+	defer unlock()
+	return mv.apply(true)
+}
+
+// transferReference ties journal entries back to the client's request when there is one
+func transferReference(req models.TransferRequest) string {
+	if req.RequestId != "" {
+		return req.RequestId
+	}
+	return req.FromAccountId + "->" + req.ToAccountId
+}
+
+// lockAccounts locks the accounts in ID order so that every path locks them
+// the same way and two opposite transfers cannot deadlock. It returns the
+// matching unlock.
+func lockAccounts(accounts ...*models.Account) func() {
+	ordered := append([]*models.Account(nil), accounts...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].ID < ordered[j].ID })
+	for _, acc := range ordered {
+		acc.Mutex.Lock()
+	}
+	return func() {
+		for i := len(ordered) - 1; i >= 0; i-- {
+			ordered[i].Mutex.Unlock()
+		}
+	}
+}
+
+// atomicTransfer checks that the sender can cover amount plus fee and applies
+// the movement to every account under their locks
+func (s *UPITransferService) atomicTransfer(mv *movement) error {
+	unlock := lockAccounts(mv.accounts()...)
+	defer unlock()
+	if err := mv.check(); err != nil {
+		return err
+	}
+	return mv.apply(false)
+}
+
+// check reports whether the movement can be applied as the accounts stand.
+// The caller holds every account's lock.
+func (mv *movement) check() error {
+	if err := mv.from.CanDebit(); err != nil {
+		return err
+	}
+	if err := mv.to.CanCredit(); err != nil {
+		return err
+	}
+	if mv.returned != nil {
+		if err := mv.revenue.CanDebit(); err != nil {
+			return err
+		}
+		available := mv.revenue.AvailableLocked()
+		if cmp, err := available.Cmp(mv.returned.Total); err != nil || cmp < 0 {
+			return models.NewInsufficientBalanceError(mv.revenue.ID, available, mv.returned.Total, models.Zero(available.Currency()))
+		}
+	} else if mv.revenue != nil {
+		if err := mv.revenue.CanCredit(); err != nil {
+			return err
+		}
+	}
+	debit, err := mv.amount.Add(mv.fee)
+	if err != nil {
+		return err
+	}
+	// a capture spends its own held funds on top of what is available
+	available := mv.from.AvailableLocked()
+	if mv.release.Currency() != "" {
+		if cmp, err := mv.from.HeldLocked().Cmp(mv.release); err != nil || cmp < 0 {
+			return models.NewInsufficientBalanceError(mv.from.ID, available, mv.amount, mv.fee)
+		}
+		if available, err = available.Add(mv.release); err != nil {
+			return err
+		}
+	}
+	cmp, err := available.Cmp(debit)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return models.NewInsufficientBalanceError(mv.from.ID, available, mv.amount, mv.fee)
+	}
 	return nil
 }
 
-func (s *UPITransferService) atomicTransfer(from, to *models.Account, amt float64) bool {
-	var first, second *models.Account
-	if from.ID < to.ID {
-		first, second = from, to
-	} else {
-		first, second = to, from
+// apply moves the money, or moves it back when reverse is set. Every new
+// balance is computed before any is stored so an overflow leaves no trace.
+// The caller holds every account's lock.
+func (mv *movement) apply(reverse bool) error {
+	debit, err := mv.amount.Add(mv.fee)
+	if err != nil {
+		return err
 	}
-	first.Mutex.Lock()
-	second.Mutex.Lock()
-	defer func() {
-		second.Mutex.Unlock()
-		first.Mutex.Unlock()
-	}()
-	if from.Balance >= amt {
-		from.Balance -= amt
-		to.Balance += amt
-		return true
+	take, give := models.Money.Sub, models.Money.Add
+	if reverse {
+		take, give = give, take
 	}
-	return false
+
+	newFrom, err := take(mv.from.Balance, debit)
+	if err != nil {
+		return err
+	}
+	newHeld := mv.from.Held
+	if mv.release.Currency() != "" {
+		if newHeld, err = take(mv.from.HeldLocked(), mv.release); err != nil {
+			return err
+		}
+	}
+	received, err := mv.received()
+	if err != nil {
+		return err
+	}
+	newTo, err := give(mv.to.Balance, received)
+	if err != nil {
+		return err
+	}
+	var newRevenue models.Money
+	switch {
+	case mv.returned != nil:
+		newRevenue, err = take(mv.revenue.Balance, mv.returned.Total)
+	case mv.revenue != nil:
+		newRevenue, err = give(mv.revenue.Balance, mv.fee)
+	}
+	if err != nil {
+		return err
+	}
+	mv.from.Balance, mv.from.Held, mv.to.Balance = newFrom, newHeld, newTo
+	mv.after = []models.Money{newFrom, newTo}
+	if mv.revenue != nil {
+		mv.revenue.Balance = newRevenue
+		mv.after = append(mv.after, newRevenue)
+	}
+	return nil
+}
+
+// received is what the recipient is credited: the credit, plus any fees a
+// full reversal pays back
+func (mv *movement) received() (models.Money, error) {
+	if mv.returned == nil {
+		return mv.credit, nil
+	}
+	return mv.credit.Add(mv.returned.Total)
 }
 
-func (s *UPITransferService) validateInput(from, to string, amt float64) error {
-	if amt <= 0 {
+func (s *UPITransferService) validateInput(from, to string, amt models.Money) error {
+	if from == "" || to == "" {
+		return models.NewEmptyAccountIdError()
+	}
+	if _, ok := models.CurrencyExponent(amt.Currency()); !ok {
+		return models.NewUnknownCurrencyError(amt.Currency())
+	}
+	if !amt.IsPositive() {
 		return models.NewInvalidAmountError(amt)
 	}
 	if from == to {
@@ -91,47 +698,43 @@ func (s *UPITransferService) validateInput(from, to string, amt float64) error {
 	return nil
 }
 
-func (s *UPITransferService) GetAccountBalance(ctx context.Context, accountId string) (float64, error) {
+// GetAccountBalance reports the ledger balance together with how much of it
+// is held and how much is available to spend
+func (s *UPITransferService) GetAccountBalance(ctx context.Context, accountId string) (models.AccountBalance, error) {
 	acc, err := s.accountRepo.GetAccountById(ctx, accountId)
 	if err != nil {
-		return 0, err
+		return models.AccountBalance{}, err
 	}
-	return acc.GetBalance(), nil
+	return acc.Balances(), nil
 }
 
-func (s *UPITransferService) BulkTransfer(ctx context.Context, transfers []models.TransferRequest) []models.TransferResult {
-	const workers = 3
-	jobChan := make(chan models.TransferRequest, len(transfers))
-	resChan := make(chan models.TransferResult, len(transfers))
-
-	var wg sync.WaitGroup
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			for tr := range jobChan {
-				err := s.Transfer(ctx, tr.FromAccountId, tr.ToAccountId, tr.Amount)
-				resChan <- models.TransferResult{RequestId: tr.RequestId, Success: err == nil, Error: err}
-			}
-		}(i)
+// Reconcile checks sum(postings) == balance for every account the repository
+// holds, so an account the ledger never heard of must have a zero balance.
+// It needs a repository that can list its accounts; see ReconcileAccounts.
+func (s *UPITransferService) Reconcile(ctx context.Context) (*ledger.ReconciliationReport, error) {
+	if s.ledger == nil {
+		return nil, &models.TransferError{Code: "LEDGER_NOT_CONFIGURED", Message: "No ledger configured"}
 	}
-
-	for _, t := range transfers {
-		jobChan <- t
+	accountIds, err := repository.ListAccountIds(ctx, s.accountRepo)
+	if err != nil {
+		return nil, err
 	}
-	close(jobChan)
-
-	go func() {
-		wg.Wait()
-		close(resChan)
-	}()
+	return s.ReconcileAccounts(ctx, accountIds)
+}
 
-	var results []models.TransferResult
-	for r := range resChan {
-		results = append(results, r)
+// ReconcileAccounts checks sum(postings) == balance for the given accounts,
+// counting an account without postings as posted to zero
+func (s *UPITransferService) ReconcileAccounts(ctx context.Context, accountIds []string) (*ledger.ReconciliationReport, error) {
+	if s.ledger == nil {
+		return nil, &models.TransferError{Code: "LEDGER_NOT_CONFIGURED", Message: "No ledger configured"}
+	}
+	accounts, err := s.accountRepo.GetMultipleAccounts(ctx, accountIds)
+	if err != nil {
+		return nil, err
 	}
-	return results
+	return ledger.Reconcile(ctx, s.ledger, accounts)
 }
+
 func (s *UPITransferService) incrementTransferCount() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -147,3 +750,32 @@ func (s *UPITransferService) GetStats() (int64, int64) {
 	defer s.mutex.RUnlock()
 	return s.transferCount, s.successCount
 }
+
+// recordLock serialises the operations on one hold or one transfer's refunds,
+// which read a record, move money and write the record back. It is dropped
+// from the map once nobody is waiting on it.
+type recordLock struct {
+	sync.Mutex
+	users int
+}
+
+func (s *UPITransferService) lockRecord(id string) func() {
+	s.recordMutex.Lock()
+	l, ok := s.recordLocks[id]
+	if !ok {
+		l = &recordLock{}
+		s.recordLocks[id] = l
+	}
+	l.users++
+	s.recordMutex.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.recordMutex.Lock()
+		if l.users--; l.users == 0 {
+			delete(s.recordLocks, id)
+		}
+		s.recordMutex.Unlock()
+	}
+}
@@ -2,19 +2,99 @@
 package benchmark_test
 
 import (
+	"context"
 	"testing"
+	"time"
+	"transfer-service/models"
 	"transfer-service/repository"
 	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
 )
 
+// newMockService backs the service with accounts rich enough never to run out
+func newMockService() (*service.UPITransferService, *mocks.MockAccountRepository) {
+	mockRepo := new(mocks.MockAccountRepository)
+	mockRepo.ExpectAccounts(
+		helpers.CreateTestAccount("1", "Alice", helpers.INR("1000000000.00")),
+		helpers.CreateTestAccount("2", "Bob", helpers.INR("1000000000.00")),
+	)
+	mockRepo.ExpectUpdate()
+	return service.NewUPITransferService(mockRepo), mockRepo
+}
+
 func BenchmarkTransfer(b *testing.B) {
-	repo := repository.GetSqlAccountRepository()
-	upiService := service.NewUPITransferService(repo)
+	ctx := context.Background()
+	upiService, _ := newMockService()
+	amount := helpers.INR("1.00")
 
 	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		upiService.Transfer(ctx, "1", "2", amount)
+		upiService.Transfer(ctx, "2", "1", amount)
+	}
+}
+
+// BenchmarkTransfer_Parallel contends on the same two account locks
+func BenchmarkTransfer_Parallel(b *testing.B) {
+	ctx := context.Background()
+	upiService, _ := newMockService()
+	amount := helpers.INR("1.00")
 
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			upiService.Transfer(ctx, "1", "2", amount)
+			upiService.Transfer(ctx, "2", "1", amount)
+		}
+	})
+}
+
+// BenchmarkTransfer_CallerDeadline gives every transfer its own deadline,
+// as an HTTP handler with a request timeout would
+func BenchmarkTransfer_CallerDeadline(b *testing.B) {
+	upiService, _ := newMockService()
+	amount := helpers.INR("1.00")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		upiService.Transfer(ctx, "1", "2", amount)
+		cancel()
+	}
+}
+
+// BenchmarkTransfer_Cancelled measures how quickly a transfer whose caller
+// has gone away gives up on a store that never answers
+func BenchmarkTransfer_Cancelled(b *testing.B) {
+	mockRepo := new(mocks.MockAccountRepository)
+	mockRepo.ExpectAccountBlocked("1")
+	mockRepo.ExpectAccountBlocked("2")
+	upiService := service.NewUPITransferService(mockRepo)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	amount := helpers.INR("1.00")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := upiService.Transfer(ctx, "1", "2", amount); models.ErrorCode(err) != "CANCELLED" {
+			b.Fatalf("expected CANCELLED, got %v", err)
+		}
+	}
+}
+
+// BenchmarkTransfer_SqlRepository includes the in-memory store's simulated
+// latency. Every run starts from the demo balances.
+func BenchmarkTransfer_SqlRepository(b *testing.B) {
+	ctx := context.Background()
+	repo := repository.NewSqlAccountRepository(repository.DemoAccounts(),
+		repository.WithLatency(30*time.Millisecond, 20*time.Millisecond))
+	upiService := service.NewUPITransferService(repo)
+	amount := helpers.INR("1.00")
+
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		upiService.Transfer("1", "2", 1.00)
-		upiService.Transfer("2", "1", 1.00)
+		upiService.Transfer(ctx, "1", "2", amount)
+		upiService.Transfer(ctx, "2", "1", amount)
 	}
 }
@@ -3,7 +3,12 @@ package helpers
 
 import "transfer-service/models"
 
-func CreateTestAccount(id, name string, balance float64) *models.Account {
+// INR parses a decimal string into an exact INR amount
+func INR(amount string) models.Money {
+	return models.MustParseMoney(amount, "INR")
+}
+
+func CreateTestAccount(id, name string, balance models.Money) *models.Account {
 	return &models.Account{
 		ID:      id,
 		Name:    name,
@@ -13,8 +18,8 @@ func CreateTestAccount(id, name string, balance float64) *models.Account {
 
 func CreateTestAccounts() []*models.Account {
 	return []*models.Account{
-		CreateTestAccount("1", "Alice", 1000.00),
-		CreateTestAccount("2", "Bob", 500.00),
-		CreateTestAccount("3", "Charlie", 750.00),
+		CreateTestAccount("1", "Alice", INR("1000.00")),
+		CreateTestAccount("2", "Bob", INR("500.00")),
+		CreateTestAccount("3", "Charlie", INR("750.00")),
 	}
 }
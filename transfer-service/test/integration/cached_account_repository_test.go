@@ -0,0 +1,94 @@
+// File: test/integration/cached_account_repository_test.go
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"transfer-service/events"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCachedRepository_NoStaleBalanceForDebit caches account 1, drains it
+// through a second service that writes to the database directly, and checks
+// that debits through the cache see what is really left
+func TestCachedRepository_NoStaleBalanceForDebit(t *testing.T) {
+	testCases := []struct {
+		name  string
+		debit func(ctx context.Context, svc *service.UPITransferService) error
+	}{
+		{"transfer", func(ctx context.Context, svc *service.UPITransferService) error {
+			return svc.Transfer(ctx, "1", "3", helpers.INR("500.00"))
+		}},
+		{"authorize", func(ctx context.Context, svc *service.UPITransferService) error {
+			_, err := svc.Authorize(ctx, models.TransferRequest{
+				FromAccountId: "1", ToAccountId: "3", Amount: helpers.INR("500.00"), RequestId: "AUTH-1",
+			})
+			return err
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newSeededDBRepository(t)
+			cached := repository.NewCachedOutboxRepository(store)
+			cachedService := service.NewUPITransferService(cached)
+			directService := service.NewUPITransferService(store)
+
+			balance, err := cachedService.GetAccountBalance(ctx, "1")
+			require.NoError(t, err)
+			require.Equal(t, helpers.INR("1000.00"), balance.Ledger)
+			require.NoError(t, directService.Transfer(ctx, "1", "2", helpers.INR("900.00")))
+
+			// reads may lag behind the bypassing write until the TTL passes
+			balance, err = cachedService.GetAccountBalance(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, helpers.INR("1000.00"), balance.Ledger)
+			assert.Equal(t, int64(1), cached.Stats().Hits)
+
+			// but a debit never trusts the stale 1000.00
+			err = tc.debit(ctx, cachedService)
+			require.Equal(t, "INSUFFICIENT_BALANCE", models.ErrorCode(err))
+			assert.Equal(t, helpers.INR("100.00"), err.(*models.TransferError).Details["balance"])
+
+			stored, err := store.GetAccountById(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, helpers.INR("100.00"), stored.Balance)
+			assert.True(t, stored.Held.IsZero())
+			balance, err = cachedService.GetAccountBalance(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, helpers.INR("100.00"), balance.Ledger, "the debit refreshed the cache")
+		})
+	}
+}
+
+func TestCachedOutboxRepository_EventsCommitWithTheTransfer(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	store, err := repository.NewDBAccountRepository(ctx, db)
+	require.NoError(t, err)
+	require.NoError(t, store.SeedAccounts(ctx, helpers.CreateTestAccounts()...))
+	cached := repository.NewCachedOutboxRepository(store)
+	upiService := service.NewUPITransferService(cached, service.WithEventBus(events.NewBus()))
+
+	_, err = upiService.GetAccountBalance(ctx, "1")
+	require.NoError(t, err)
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("100.00")))
+
+	// the completion events went into the database outbox with the commit
+	var completed int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM outbox WHERE type = ?`, string(events.TypeTransferCompleted)).Scan(&completed))
+	assert.Equal(t, 1, completed)
+
+	// and the commit refreshed the cached account
+	misses := cached.Stats().Misses
+	balance, err := upiService.GetAccountBalance(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("900.00"), balance.Ledger)
+	assert.Equal(t, misses, cached.Stats().Misses)
+}
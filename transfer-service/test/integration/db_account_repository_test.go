@@ -0,0 +1,318 @@
+// File: test/integration/db_account_repository_test.go
+package integration_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"transfer-service/events"
+	"transfer-service/fees"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "accounts.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newSeededDBRepository(t *testing.T) *repository.DBAccountRepository {
+	t.Helper()
+	ctx := context.Background()
+	repo, err := repository.NewDBAccountRepository(ctx, openTestDB(t))
+	require.NoError(t, err)
+	require.NoError(t, repo.SeedAccounts(ctx, helpers.CreateTestAccounts()...))
+	return repo
+}
+
+func TestDBAccountRepository_MigrateIsRepeatable(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	require.NoError(t, repository.Migrate(ctx, db))
+	require.NoError(t, repository.Migrate(ctx, db))
+
+	var applied int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied))
+	assert.Equal(t, 5, applied)
+}
+
+func TestDBAccountRepository_GetAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	repo := newSeededDBRepository(t)
+
+	acc, err := repo.GetAccountById(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", acc.Name)
+	assert.Equal(t, helpers.INR("1000.00"), acc.Balance)
+
+	acc.Balance = helpers.INR("999.99")
+	require.NoError(t, repo.UpdateAccount(ctx, acc))
+	assert.Equal(t, int64(1), acc.Version)
+
+	reloaded, err := repo.GetAccountById(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("999.99"), reloaded.Balance)
+	assert.Equal(t, int64(1), reloaded.Version)
+
+	_, err = repo.GetAccountById(ctx, "404")
+	assert.Contains(t, err.Error(), "ACCOUNT_NOT_FOUND")
+
+	accounts, err := repo.GetMultipleAccounts(ctx, []string{"3", "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "3", accounts[0].ID)
+	assert.Equal(t, "1", accounts[1].ID)
+}
+
+func TestDBAccountRepository_CreateAndStatus(t *testing.T) {
+	ctx := context.Background()
+	repo := newSeededDBRepository(t)
+
+	acc := helpers.CreateTestAccount("4", "Dave", helpers.INR("0.00"))
+	require.NoError(t, repo.CreateAccount(ctx, acc))
+	err := repo.CreateAccount(ctx, helpers.CreateTestAccount("4", "Dup", helpers.INR("0.00")))
+	assert.Contains(t, err.Error(), "ACCOUNT_ALREADY_EXISTS")
+
+	reloaded, err := repo.GetAccountById(ctx, "4")
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountActive, reloaded.Status)
+	ids, err := repo.ListAccountIds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, ids)
+
+	reloaded.Status = models.AccountFrozen
+	require.NoError(t, repo.UpdateAccount(ctx, reloaded))
+	frozen, _ := repo.GetAccountById(ctx, "4")
+	assert.Equal(t, models.AccountFrozen, frozen.Status)
+}
+
+func TestDBAccountRepository_OptimisticLocking(t *testing.T) {
+	ctx := context.Background()
+	repo := newSeededDBRepository(t)
+
+	first, err := repo.GetAccountById(ctx, "1")
+	require.NoError(t, err)
+	stale, err := repo.GetAccountById(ctx, "1")
+	require.NoError(t, err)
+
+	first.Balance = helpers.INR("900.00")
+	require.NoError(t, repo.UpdateAccount(ctx, first))
+
+	stale.Balance = helpers.INR("800.00")
+	err = repo.UpdateAccount(ctx, stale)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONCURRENT_MODIFICATION")
+
+	reloaded, _ := repo.GetAccountById(ctx, "1")
+	assert.Equal(t, helpers.INR("900.00"), reloaded.Balance)
+}
+
+func TestDBAccountRepository_CommitTransferIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	repo := newSeededDBRepository(t)
+
+	from, err := repo.GetAccountById(ctx, "1")
+	require.NoError(t, err)
+	from.Balance = helpers.INR("0.00")
+	ghost := helpers.CreateTestAccount("404", "Nobody", helpers.INR("1000.00"))
+
+	err = repo.CommitTransfer(ctx, from, ghost)
+	assert.Contains(t, err.Error(), "ACCOUNT_NOT_FOUND")
+
+	reloaded, _ := repo.GetAccountById(ctx, "1")
+	assert.Equal(t, helpers.INR("1000.00"), reloaded.Balance, "the debit must roll back with the failed credit")
+	assert.Equal(t, int64(0), reloaded.Version)
+}
+
+func TestDBAccountRepository_ConcurrentTransfersConserveMoney(t *testing.T) {
+	ctx := context.Background()
+	repo := newSeededDBRepository(t)
+	upiService := service.NewUPITransferService(repo)
+
+	pairs := [][2]string{{"1", "2"}, {"2", "3"}, {"3", "1"}}
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p := pairs[i%len(pairs)]
+			// conflicts that outlast the retries are acceptable; lost updates are not
+			_ = upiService.Transfer(ctx, p[0], p[1], helpers.INR(fmt.Sprintf("%d.00", i+1)))
+		}(i)
+	}
+	wg.Wait()
+
+	accounts, err := repo.GetMultipleAccounts(ctx, []string{"1", "2", "3"})
+	require.NoError(t, err)
+	total := models.Zero("INR")
+	for _, acc := range accounts {
+		total, err = total.Add(acc.Balance)
+		require.NoError(t, err)
+		assert.False(t, acc.Balance.IsNegative())
+	}
+	assert.Equal(t, helpers.INR("2250.00"), total)
+}
+
+func TestDBAccountRepository_FeesCommitWithTransfer(t *testing.T) {
+	ctx := context.Background()
+	repo := newSeededDBRepository(t)
+	require.NoError(t, repo.SeedAccounts(ctx, helpers.CreateTestAccount("FEES", "Fee revenue", helpers.INR("0.00"))))
+	schedule := &fees.Schedule{
+		RevenueAccountId: "FEES",
+		Components:       []fees.Component{{Name: "flat", Currency: "INR", Rule: &fees.FlatFee{Amount: helpers.INR("5.00")}}},
+	}
+	upiService := service.NewUPITransferService(repo, service.WithFees(schedule))
+
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("100.00")))
+	err := upiService.Transfer(ctx, "2", "3", helpers.INR("597.00"))
+	assert.Contains(t, err.Error(), "INSUFFICIENT_BALANCE")
+
+	accounts, err := repo.GetMultipleAccounts(ctx, []string{"1", "2", "3", "FEES"})
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("895.00"), accounts[0].Balance)
+	assert.Equal(t, helpers.INR("600.00"), accounts[1].Balance)
+	assert.Equal(t, helpers.INR("750.00"), accounts[2].Balance)
+	assert.Equal(t, helpers.INR("5.00"), accounts[3].Balance)
+	assert.Equal(t, int64(1), accounts[3].Version, "the fee was written in the transfer's transaction")
+}
+
+func TestDBAccountRepository_AtomicBatchCommitsOrRollsBackTogether(t *testing.T) {
+	ctx := context.Background()
+	repo := newSeededDBRepository(t)
+	upiService := service.NewUPITransferService(repo)
+	atomic := service.BulkOptions{Atomic: true}
+
+	rejected := upiService.BulkTransfer(ctx, []models.TransferRequest{
+		{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("100.00"), RequestId: "B1"},
+		{FromAccountId: "3", ToAccountId: "1", Amount: helpers.INR("800.00"), RequestId: "B2"},
+	}, atomic)
+	assert.Equal(t, "BATCH_ABORTED", models.ErrorCode(rejected[0].Error))
+	assert.Equal(t, "INSUFFICIENT_BALANCE", models.ErrorCode(rejected[1].Error))
+
+	// Bob pays Charlie out of what Alice sends him in the same batch
+	results := upiService.BulkTransfer(ctx, []models.TransferRequest{
+		{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("300.00"), RequestId: "C1"},
+		{FromAccountId: "2", ToAccountId: "3", Amount: helpers.INR("800.00"), RequestId: "C2"},
+	}, atomic)
+	for _, r := range results {
+		require.True(t, r.Success, "%v", r.Error)
+	}
+
+	accounts, err := repo.GetMultipleAccounts(ctx, []string{"1", "2", "3"})
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("700.00"), accounts[0].Balance)
+	assert.Equal(t, helpers.INR("0.00"), accounts[1].Balance)
+	assert.Equal(t, helpers.INR("1550.00"), accounts[2].Balance)
+	for _, acc := range accounts {
+		assert.Equal(t, int64(1), acc.Version, "account %s is written once by the committed batch only", acc.ID)
+	}
+}
+
+func TestDBAccountRepository_OutboxSharesTheTransferTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo, err := repository.NewDBAccountRepository(ctx, db)
+	require.NoError(t, err)
+	require.NoError(t, repo.SeedAccounts(ctx, helpers.CreateTestAccounts()...))
+
+	from, err := repo.GetAccountById(ctx, "1")
+	require.NoError(t, err)
+	ghost := helpers.CreateTestAccount("404", "Nobody", helpers.INR("0.00"))
+	rec := &repository.OutboxRecord{Type: "TransferCompleted", Payload: []byte(`{}`)}
+	err = repo.CommitTransferWithOutbox(ctx, []*repository.OutboxRecord{rec}, from, ghost)
+	assert.Contains(t, err.Error(), "ACCOUNT_NOT_FOUND")
+	pending, err := repo.Pending(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "the records roll back with the accounts")
+
+	bus := events.NewBus()
+	sink := events.NewMemorySink()
+	bus.RegisterListener(sink)
+	upiService := service.NewUPITransferService(repo, service.WithEventBus(bus))
+
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("100.00")))
+	pending, err = repo.Pending(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, pending, 4, "the transfer appends and leaves publishing to the relay")
+	_, err = upiService.EventRelay().Flush(ctx)
+	require.NoError(t, err)
+	var types []events.Type
+	for _, e := range sink.Events() {
+		types = append(types, e.EventType())
+	}
+	assert.Equal(t, []events.Type{
+		events.TypeTransferInitiated,
+		events.TypeTransferCompleted,
+		events.TypeAccountBalanceChanged,
+		events.TypeAccountBalanceChanged,
+	}, types)
+
+	pending, err = repo.Pending(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "published records are marked")
+	var stored int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM outbox`).Scan(&stored))
+	assert.Equal(t, 4, stored)
+}
+
+func TestDBAccountRepository_HoldsSurviveReopen(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo, err := repository.NewDBAccountRepository(ctx, db)
+	require.NoError(t, err)
+	require.NoError(t, repo.SeedAccounts(ctx, helpers.CreateTestAccounts()...))
+	upiService := service.NewUPITransferService(repo, service.WithHoldRepository(repo))
+
+	hold, err := upiService.Authorize(ctx, models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("300.00")})
+	require.NoError(t, err)
+	_, err = upiService.CapturePartial(ctx, hold.ID, helpers.INR("100.00"))
+	require.NoError(t, err)
+
+	reopened, err := repository.NewDBAccountRepository(ctx, db)
+	require.NoError(t, err)
+	got, err := reopened.GetHold(ctx, hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.HoldPartial, got.Status)
+	assert.Equal(t, helpers.INR("100.00"), got.Captured)
+	assert.True(t, got.ExpiresAt.Equal(hold.ExpiresAt))
+
+	restarted := service.NewUPITransferService(reopened, service.WithHoldRepository(reopened))
+	balance, err := restarted.GetAccountBalance(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("900.00"), balance.Ledger)
+	assert.Equal(t, helpers.INR("200.00"), balance.Held)
+
+	_, err = restarted.Void(ctx, hold.ID)
+	require.NoError(t, err)
+	balance, err = restarted.GetAccountBalance(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("900.00"), balance.Available)
+}
+
+func TestDBAccountRepository_CancelledTransferChangesNothing(t *testing.T) {
+	repo := newSeededDBRepository(t)
+	upiService := service.NewUPITransferService(repo)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("100.00"))
+	assert.Equal(t, "CANCELLED", models.ErrorCode(err))
+
+	accounts, err := repo.GetMultipleAccounts(context.Background(), []string{"1", "2"})
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("1000.00"), accounts[0].Balance)
+	assert.Equal(t, helpers.INR("500.00"), accounts[1].Balance)
+}
@@ -0,0 +1,66 @@
+// File: test/integration/faulty_account_repository_test.go
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"transfer-service/events"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultyOutboxRepository_FaultsTheTransactionalOutbox(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	store, err := repository.NewDBAccountRepository(ctx, db)
+	require.NoError(t, err)
+	require.NoError(t, store.SeedAccounts(ctx, helpers.CreateTestAccounts()...))
+	repo := repository.NewFaultyOutboxRepository(store, 1)
+
+	bus := events.NewBus()
+	sink := events.NewMemorySink()
+	bus.RegisterListener(sink)
+	upiService := service.NewUPITransferService(repo, service.WithEventBus(bus))
+	repo.SetFault(repository.MethodCommitTransferWithOutbox, repository.Fault{FailCalls: []int{1}})
+
+	err = upiService.Transfer(ctx, "1", "2", helpers.INR("100.00"))
+	assert.Equal(t, "STORAGE_ERROR", models.ErrorCode(err))
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("100.00")))
+
+	// both attempts took the transactional path, and only the second one
+	// stored completion events
+	assert.Equal(t, 2, repo.Calls(repository.MethodCommitTransferWithOutbox))
+	var completed int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM outbox WHERE type = ?`, string(events.TypeTransferCompleted)).Scan(&completed))
+	assert.Equal(t, 1, completed)
+	stored, err := store.GetAccountById(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("900.00"), stored.Balance)
+}
+
+func TestFaultyOutboxRepository_FailedMarkKeepsRecordsPending(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewFaultyOutboxRepository(newSeededDBRepository(t), 1)
+	repo.SetFault(repository.MethodMarkPublished, repository.Fault{ErrorRate: 1})
+	bus := events.NewBus()
+	upiService := service.NewUPITransferService(repo, service.WithEventBus(bus))
+
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("100.00")))
+	_, err := upiService.EventRelay().Flush(ctx)
+	assert.Equal(t, "STORAGE_ERROR", models.ErrorCode(err))
+	pending, err := repo.Pending(ctx, 0)
+	require.NoError(t, err)
+	assert.NotEmpty(t, pending, "records stay until a relay marks them")
+
+	repo.ClearFaults()
+	_, err = upiService.EventRelay().Flush(ctx)
+	require.NoError(t, err)
+	pending, err = repo.Pending(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
@@ -2,28 +2,126 @@
 package integration_test
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
+	"transfer-service/models"
 	"transfer-service/repository"
 	"transfer-service/service"
+	"transfer-service/test/helpers"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// newDemoRepository gives each test its own copy of the demo accounts with
+// the latency of a database round trip
+func newDemoRepository(opts ...repository.SqlOption) *repository.SqlAccountRepository {
+	opts = append([]repository.SqlOption{repository.WithLatency(30*time.Millisecond, 20*time.Millisecond)}, opts...)
+	return repository.NewSqlAccountRepository(repository.DemoAccounts(), opts...)
+}
+
 func TestTransferIntegration_SuccessfulTransfer(t *testing.T) {
-	repo := repository.GetSqlAccountRepository()
+	t.Parallel()
+	ctx := context.Background()
+	upiService := service.NewUPITransferService(newDemoRepository())
+
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("200.00"))
+
+	assert.NoError(t, err)
+
+	finalBalance1, _ := upiService.GetAccountBalance(ctx, "1")
+	finalBalance2, _ := upiService.GetAccountBalance(ctx, "2")
+	assert.Equal(t, helpers.INR("800.00"), finalBalance1.Ledger)
+	assert.Equal(t, helpers.INR("700.00"), finalBalance2.Ledger)
+}
+
+func TestTransferIntegration_InjectedWriteFailureIsCompensated(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repo := newDemoRepository(repository.WithFailureInjector(func(method, accountId string) error {
+		if method == "UpdateAccount" && accountId == "2" {
+			return errors.New("disk full")
+		}
+		return nil
+	}))
 	upiService := service.NewUPITransferService(repo)
 
-	initialBalance1, _ := upiService.GetAccountBalance("1")
-	initialBalance2, _ := upiService.GetAccountBalance("2")
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("200.00"))
+
+	assert.Equal(t, "PARTIAL_FAILURE_COMPENSATED", models.ErrorCode(err))
+	balance, err := upiService.GetAccountBalance(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("1000.00"), balance.Ledger)
+}
 
-	transferAmount := 200.00
-	err := upiService.Transfer("1", "2", transferAmount)
+func TestTransferIntegration_RestoreUndoesTransfers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repo := newDemoRepository()
+	upiService := service.NewUPITransferService(repo)
+	snap := repo.Snapshot()
 
-	assert.NoError(t, err)
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("200.00")))
+	_, err := upiService.OpenAccount(ctx, "4", "Dave", helpers.INR("50.00"))
+	require.NoError(t, err)
+
+	repo.Restore(snap)
+
+	assert.Equal(t, []string{"1", "2", "3"}, repo.Snapshot().AccountIds())
+	for _, want := range repository.DemoAccounts() {
+		balance, err := upiService.GetAccountBalance(ctx, want.ID)
+		require.NoError(t, err)
+		assert.Equal(t, want.Balance, balance.Ledger, want.ID)
+	}
+	_, err = upiService.GetAccountBalance(ctx, "4")
+	assert.Equal(t, "ACCOUNT_NOT_FOUND", models.ErrorCode(err))
+}
+
+func TestTransferIntegration_ResetReturnsToSeed(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	seed := repository.DemoAccounts()
+	repo := repository.NewSqlAccountRepository(seed)
+	upiService := service.NewUPITransferService(repo)
+
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("200.00")))
+	assert.Equal(t, helpers.INR("1000.00"), seed[0].Balance, "the caller's seed accounts are copied")
+
+	repo.Reset()
+
+	assert.Equal(t, helpers.INR("1000.00"), repo.Snapshot().Account("1").Balance)
+	assert.Equal(t, helpers.INR("500.00"), repo.Snapshot().Account("2").Balance)
+}
+
+func TestTransferIntegration_ContextEndsBeforeFundsMove(t *testing.T) {
+	expired, cancelExpired := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancelExpired()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name string
+		ctx  context.Context
+		code string
+	}{
+		// reads take 30ms, so the deadline passes while the accounts load
+		{"deadline", expired, "TIMEOUT"},
+		{"cancelled", cancelled, "CANCELLED"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upiService := service.NewUPITransferService(newDemoRepository())
+			before, err := upiService.GetAccountBalance(context.Background(), "1")
+			require.NoError(t, err)
 
-	finalBalance1, _ := upiService.GetAccountBalance("1")
-	finalBalance2, _ := upiService.GetAccountBalance("2")
+			err = upiService.Transfer(tc.ctx, "1", "2", helpers.INR("10.00"))
+			assert.Equal(t, tc.code, models.ErrorCode(err))
 
-	assert.Equal(t, initialBalance1-transferAmount, finalBalance1)
-	assert.Equal(t, initialBalance2+transferAmount, finalBalance2)
+			after, err := upiService.GetAccountBalance(context.Background(), "1")
+			require.NoError(t, err)
+			assert.Equal(t, before, after)
+		})
+	}
 }
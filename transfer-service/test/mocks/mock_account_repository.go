@@ -2,24 +2,157 @@
 package mocks
 
 import (
+	"context"
 	"transfer-service/models"
+	"transfer-service/repository"
 
 	"github.com/stretchr/testify/mock"
 )
 
+var _ repository.AccountRepository = (*MockAccountRepository)(nil)
+
+// AnyContext matches the context argument of any call. Unlike mock.Anything
+// it rejects a nil context, which the service must never pass on.
+var AnyContext = mock.MatchedBy(func(ctx context.Context) bool { return ctx != nil })
+
+// ContextWithDeadline matches a context carrying a deadline, as every
+// repository call the service makes should
+func ContextWithDeadline() interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		if ctx == nil {
+			return false
+		}
+		_, ok := ctx.Deadline()
+		return ok
+	})
+}
+
+// AccountWithId matches an account argument by its ID
+func AccountWithId(id string) interface{} {
+	return mock.MatchedBy(func(a *models.Account) bool { return a != nil && a.ID == id })
+}
+
+// untilDone blocks until ctx ends and fails with its error, like a store
+// that never answers
+func untilDone(ctx context.Context) error {
+	<-ctx.Done()
+	return models.WrapContextError(ctx.Err())
+}
+
 type MockAccountRepository struct {
 	mock.Mock
 }
 
-func (m *MockAccountRepository) GetAccountById(accountId string) (*models.Account, error) {
-	args := m.Called(accountId)
+// ExpectAccount makes GetAccountById return acc for its ID
+func (m *MockAccountRepository) ExpectAccount(acc *models.Account) *mock.Call {
+	return m.On("GetAccountById", AnyContext, acc.ID).Return(acc, nil)
+}
+
+// ExpectAccounts is ExpectAccount for each account
+func (m *MockAccountRepository) ExpectAccounts(accounts ...*models.Account) {
+	for _, acc := range accounts {
+		m.ExpectAccount(acc)
+	}
+}
+
+// ExpectAccountError makes GetAccountById fail with err for accountId
+func (m *MockAccountRepository) ExpectAccountError(accountId string, err error) *mock.Call {
+	return m.On("GetAccountById", AnyContext, accountId).Return(nil, err)
+}
+
+// ExpectAccountBlocked makes GetAccountById for accountId hang until the
+// caller's context ends and then fail with TIMEOUT or CANCELLED
+func (m *MockAccountRepository) ExpectAccountBlocked(accountId string) *mock.Call {
+	return m.On("GetAccountById", AnyContext, accountId).Return(func(ctx context.Context, _ string) (*models.Account, error) {
+		return nil, untilDone(ctx)
+	}, nil)
+}
+
+// ExpectUpdate accepts every UpdateAccount call
+func (m *MockAccountRepository) ExpectUpdate() *mock.Call {
+	return m.On("UpdateAccount", AnyContext, mock.AnythingOfType("*models.Account")).Return(nil)
+}
+
+// ExpectUpdateOf answers UpdateAccount of accountId with err, nil for success
+func (m *MockAccountRepository) ExpectUpdateOf(accountId string, err error) *mock.Call {
+	return m.On("UpdateAccount", AnyContext, AccountWithId(accountId)).Return(err)
+}
+
+// ExpectUpdateBlocked makes UpdateAccount of accountId hang until the
+// caller's context ends and then fail with TIMEOUT or CANCELLED
+func (m *MockAccountRepository) ExpectUpdateBlocked(accountId string) *mock.Call {
+	return m.On("UpdateAccount", AnyContext, AccountWithId(accountId)).Return(func(ctx context.Context, _ *models.Account) error {
+		return untilDone(ctx)
+	})
+}
+
+// ExpectCreate answers CreateAccount of accountId with err, nil for success
+func (m *MockAccountRepository) ExpectCreate(accountId string, err error) *mock.Call {
+	return m.On("CreateAccount", AnyContext, AccountWithId(accountId)).Return(err)
+}
+
+// snapshot copies an account under its lock. Matching a call makes testify
+// format every argument, which would race with the service rewriting the
+// account concurrently, so the calls record a snapshot while any function
+// given as a return value still gets the account itself.
+func snapshot(acc *models.Account) *models.Account {
+	if acc == nil {
+		return nil
+	}
+	acc.Mutex.RLock()
+	defer acc.Mutex.RUnlock()
+	return &models.Account{
+		ID:       acc.ID,
+		Name:     acc.Name,
+		Balance:  acc.Balance,
+		Held:     acc.Held,
+		Currency: acc.Currency,
+		Type:     acc.Type,
+		Status:   acc.Status,
+		Version:  acc.Version,
+	}
+}
+
+// The methods below accept either fixed return values or, as mockery's
+// generated mocks do, a function of the call's arguments producing them.
+
+func (m *MockAccountRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	args := m.Called(ctx, snapshot(account))
+	if fn, ok := args.Get(0).(func(context.Context, *models.Account) error); ok {
+		return fn(ctx, account)
+	}
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) GetAccountById(ctx context.Context, accountId string) (*models.Account, error) {
+	args := m.Called(ctx, accountId)
+	if fn, ok := args.Get(0).(func(context.Context, string) (*models.Account, error)); ok {
+		return fn(ctx, accountId)
+	}
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Account), args.Error(1)
 }
 
-func (m *MockAccountRepository) UpdateAccount(account *models.Account) error {
-	args := m.Called(account)
+func (m *MockAccountRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+	args := m.Called(ctx, snapshot(account))
+	if fn, ok := args.Get(0).(func(context.Context, *models.Account) error); ok {
+		return fn(ctx, account)
+	}
 	return args.Error(0)
 }
+
+// GetMultipleAccounts resolves each id through GetAccountById so tests only
+// need to set expectations on the single-account lookup
+func (m *MockAccountRepository) GetMultipleAccounts(ctx context.Context, accountIds []string) ([]*models.Account, error) {
+	accounts := make([]*models.Account, 0, len(accountIds))
+	for _, id := range accountIds {
+		acc, err := m.GetAccountById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, nil
+}
@@ -0,0 +1,86 @@
+// File: test/mocks/mock_transfer_service.go
+package mocks
+
+import (
+	"context"
+	"transfer-service/models"
+	"transfer-service/service"
+
+	"github.com/stretchr/testify/mock"
+)
+
+var _ service.TransferService = (*MockTransferService)(nil)
+
+// MockTransferService stands in for the service behind the HTTP endpoints
+type MockTransferService struct {
+	mock.Mock
+}
+
+// ExpectProcessTransfer answers every ProcessTransfer call with result
+func (m *MockTransferService) ExpectProcessTransfer(result models.TransferResult) *mock.Call {
+	return m.On("ProcessTransfer", AnyContext, mock.AnythingOfType("models.TransferRequest")).Return(result)
+}
+
+func (m *MockTransferService) Transfer(ctx context.Context, fromAccountId, toAccountId string, amount models.Money) error {
+	args := m.Called(ctx, fromAccountId, toAccountId, amount)
+	return args.Error(0)
+}
+
+func (m *MockTransferService) ProcessTransfer(ctx context.Context, req models.TransferRequest) models.TransferResult {
+	args := m.Called(ctx, req)
+	if fn, ok := args.Get(0).(func(context.Context, models.TransferRequest) models.TransferResult); ok {
+		return fn(ctx, req)
+	}
+	return args.Get(0).(models.TransferResult)
+}
+
+func (m *MockTransferService) GetAccountBalance(ctx context.Context, accountId string) (models.AccountBalance, error) {
+	args := m.Called(ctx, accountId)
+	return args.Get(0).(models.AccountBalance), args.Error(1)
+}
+
+func (m *MockTransferService) BulkTransfer(ctx context.Context, transfers []models.TransferRequest, opts service.BulkOptions) []models.TransferResult {
+	args := m.Called(ctx, transfers, opts)
+	return args.Get(0).([]models.TransferResult)
+}
+
+func (m *MockTransferService) GetStats() (int64, int64) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Get(1).(int64)
+}
+
+func (m *MockTransferService) Authorize(ctx context.Context, req models.TransferRequest) (*models.Hold, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+func (m *MockTransferService) Capture(ctx context.Context, holdId string) (models.TransferResult, error) {
+	args := m.Called(ctx, holdId)
+	return args.Get(0).(models.TransferResult), args.Error(1)
+}
+
+func (m *MockTransferService) CapturePartial(ctx context.Context, holdId string, amount models.Money) (models.TransferResult, error) {
+	args := m.Called(ctx, holdId, amount)
+	return args.Get(0).(models.TransferResult), args.Error(1)
+}
+
+func (m *MockTransferService) Void(ctx context.Context, holdId string) (*models.Hold, error) {
+	args := m.Called(ctx, holdId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+func (m *MockTransferService) Reverse(ctx context.Context, transferId string) (models.TransferResult, error) {
+	args := m.Called(ctx, transferId)
+	return args.Get(0).(models.TransferResult), args.Error(1)
+}
+
+func (m *MockTransferService) Refund(ctx context.Context, transferId string, amount models.Money) (models.TransferResult, error) {
+	args := m.Called(ctx, transferId, amount)
+	return args.Get(0).(models.TransferResult), args.Error(1)
+}
@@ -0,0 +1,384 @@
+// File: test/stress/harness.go
+
+// Package stress drives a transfer service with seeded random concurrent
+// load and checks the invariants that must hold under any interleaving:
+// money is neither created nor destroyed, no balance goes negative, and the
+// service's own counters agree with what its callers saw.
+//
+// The workload is generated from the seed before anything runs, so the same
+// seed always issues the same calls. With one worker the outcome is
+// reproducible too; with more, only the invariants are.
+package stress
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/service"
+)
+
+// Config describes a run. The zero value of each field picks its default.
+type Config struct {
+	Seed       int64
+	Accounts   int          // accounts S000, S001, ...; default 10
+	Balance    models.Money // opening balance of each account; default 1000.00 INR
+	MaxAmount  models.Money // largest single amount; default a fifth of Balance
+	Operations int          // Transfer and BulkTransfer calls; default 1000
+	Workers    int          // concurrent callers; default 8
+	BulkRatio  float64      // share of calls that are BulkTransfer; default 0.2, negative for none
+	BulkSize   int          // most items in one batch; default 5
+
+	// Repository builds the store under test from the seeded accounts;
+	// default is an in-memory store that loads accounts concurrently
+	Repository func(accounts []*models.Account) repository.AccountRepository
+	// Options configure the service. They must keep every movement inside
+	// the seeded accounts, so no fee schedule unless its revenue account is
+	// one of them.
+	Options []service.Option
+}
+
+func (c Config) withDefaults() Config {
+	if c.Accounts <= 0 {
+		c.Accounts = 10
+	}
+	if c.Balance.Currency() == "" {
+		c.Balance = models.MustParseMoney("1000.00", models.DefaultCurrency)
+	}
+	if c.MaxAmount.Currency() == "" {
+		c.MaxAmount = models.NewMoney(c.Balance.Minor()/5, c.Balance.Currency())
+	}
+	if c.Operations <= 0 {
+		c.Operations = 1000
+	}
+	if c.Workers <= 0 {
+		c.Workers = 8
+	}
+	if c.BulkRatio == 0 {
+		c.BulkRatio = 0.2
+	}
+	if c.BulkSize <= 0 {
+		c.BulkSize = 5
+	}
+	if c.Repository == nil {
+		c.Repository = func(accounts []*models.Account) repository.AccountRepository {
+			return repository.NewSqlAccountRepository(accounts)
+		}
+	}
+	return c
+}
+
+// AccountId names the i-th seeded account
+func AccountId(i int) string {
+	return fmt.Sprintf("S%03d", i)
+}
+
+type opKind string
+
+const (
+	opTransfer opKind = "transfer"
+	opBulk     opKind = "bulk"
+	opAtomic   opKind = "atomic"
+)
+
+// operation is one generated call
+type operation struct {
+	kind     opKind
+	requests []models.TransferRequest
+	bulk     service.BulkOptions
+}
+
+// workload generates the calls a run makes. It depends on nothing but cfg,
+// so equal configs give equal workloads.
+func workload(cfg Config) []operation {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	request := func(n int) models.TransferRequest {
+		from := rng.Intn(cfg.Accounts)
+		to := rng.Intn(cfg.Accounts - 1)
+		if to >= from {
+			to++
+		}
+		return models.TransferRequest{
+			FromAccountId: AccountId(from),
+			ToAccountId:   AccountId(to),
+			Amount:        models.NewMoney(1+rng.Int63n(cfg.MaxAmount.Minor()), cfg.MaxAmount.Currency()),
+			RequestId:     fmt.Sprintf("STRESS-%d-%06d", cfg.Seed, n),
+		}
+	}
+
+	ops := make([]operation, cfg.Operations)
+	n := 0
+	for i := range ops {
+		if rng.Float64() >= cfg.BulkRatio {
+			ops[i] = operation{kind: opTransfer, requests: []models.TransferRequest{request(n)}}
+			n++
+			continue
+		}
+		op := operation{kind: opBulk, bulk: service.BulkOptions{Workers: 1 + rng.Intn(3), Ordered: rng.Intn(2) == 0}}
+		if rng.Intn(3) == 0 {
+			op.kind, op.bulk = opAtomic, service.BulkOptions{Atomic: true}
+		}
+		if cfg.Workers == 1 {
+			op.bulk.Workers = 1 // keep single-worker runs reproducible
+		}
+		for size := 1 + rng.Intn(cfg.BulkSize); size > 0; size-- {
+			op.requests = append(op.requests, request(n))
+			n++
+		}
+		ops[i] = op
+	}
+	return ops
+}
+
+// Percentiles summarises the latencies of one kind of call
+type Percentiles struct {
+	Count              int
+	P50, P90, P99, Max time.Duration
+}
+
+func percentiles(samples []time.Duration) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	at := func(q float64) time.Duration {
+		return samples[int(q*float64(len(samples)-1))]
+	}
+	return Percentiles{Count: len(samples), P50: at(0.50), P90: at(0.90), P99: at(0.99), Max: samples[len(samples)-1]}
+}
+
+// Report is what a run observed
+type Report struct {
+	Seed       int64
+	Operations int
+	Transfers  int            // transfers attempted, batch items included
+	Succeeded  int            // transfers that completed
+	Failures   map[string]int // failed transfers by error code
+	Elapsed    time.Duration
+	Procs      int                    // GOMAXPROCS; with one there is little to contend
+	Latency    map[string]Percentiles // by call: transfer, bulk or atomic
+	// LockWait is how long goroutines were blocked on mutexes during the
+	// run, as the runtime counts it; Contention is that per transfer
+	LockWait   time.Duration
+	Contention time.Duration
+	Balances   map[string]models.Money // final balance of every seeded account
+	Violations []string                // broken invariants; empty when all held
+}
+
+// OK reports whether every invariant held
+func (r *Report) OK() bool { return len(r.Violations) == 0 }
+
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "seed=%d operations=%d transfers=%d succeeded=%d elapsed=%s procs=%d\n",
+		r.Seed, r.Operations, r.Transfers, r.Succeeded, r.Elapsed.Round(time.Millisecond), r.Procs)
+	codes := make([]string, 0, len(r.Failures))
+	for code := range r.Failures {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(&b, "  failed %-28s %d\n", code, r.Failures[code])
+	}
+	for _, kind := range []opKind{opTransfer, opBulk, opAtomic} {
+		if p, ok := r.Latency[string(kind)]; ok {
+			fmt.Fprintf(&b, "  %-8s n=%-5d p50=%-10s p90=%-10s p99=%-10s max=%s\n", kind, p.Count, p.P50, p.P90, p.P99, p.Max)
+		}
+	}
+	fmt.Fprintf(&b, "  lock wait %s (%s per transfer)\n", r.LockWait, r.Contention)
+	for _, v := range r.Violations {
+		fmt.Fprintf(&b, "  VIOLATION %s\n", v)
+	}
+	return b.String()
+}
+
+// maxViolations keeps a badly broken run from flooding the report
+const maxViolations = 20
+
+type checker struct {
+	violations []string
+	mutex      sync.Mutex
+}
+
+func (c *checker) fail(format string, args ...interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.violations) < maxViolations {
+		c.violations = append(c.violations, fmt.Sprintf(format, args...))
+	}
+}
+
+// Run seeds the accounts, runs the workload against a fresh service and
+// checks the invariants. It returns an error only if the run could not be
+// set up; broken invariants are in the report.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	cfg = cfg.withDefaults()
+	if cfg.Accounts < 2 {
+		return nil, fmt.Errorf("stress: need at least two accounts, got %d", cfg.Accounts)
+	}
+	accounts := make([]*models.Account, cfg.Accounts)
+	for i := range accounts {
+		accounts[i] = &models.Account{ID: AccountId(i), Name: AccountId(i), Balance: cfg.Balance}
+	}
+	ids := make([]string, len(accounts))
+	for i, acc := range accounts {
+		ids[i] = acc.ID
+	}
+	opening, err := total(accounts)
+	if err != nil {
+		return nil, err
+	}
+	repo := cfg.Repository(accounts)
+	svc := service.NewUPITransferService(repo, cfg.Options...)
+	ops := workload(cfg)
+
+	report := &Report{
+		Seed:       cfg.Seed,
+		Operations: len(ops),
+		Procs:      runtime.GOMAXPROCS(0),
+		Failures:   make(map[string]int),
+		Latency:    make(map[string]Percentiles),
+	}
+	check := &checker{}
+
+	// watch balances while the load runs: a negative balance may be
+	// repaired by a later credit and never show at the end. Accounts are
+	// read through the store, which need not hand out the seeded values.
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		for {
+			current, err := repo.GetMultipleAccounts(watchCtx, ids)
+			if err != nil && watchCtx.Err() == nil {
+				check.fail("reading balances during the run: %v", err)
+			}
+			for _, acc := range current {
+				if b := acc.Balances(); b.Ledger.IsNegative() || b.Available.IsNegative() {
+					check.fail("account %s went negative: ledger %s, available %s", acc.ID, b.Ledger, b.Available)
+				}
+			}
+			select {
+			case <-watchCtx.Done():
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}()
+
+	var (
+		latencies = make(map[opKind][]time.Duration)
+		mutex     sync.Mutex
+		wg        sync.WaitGroup
+		queue     = make(chan operation)
+	)
+	record := func(kind opKind, took time.Duration, results []models.TransferResult) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		latencies[kind] = append(latencies[kind], took)
+		for _, r := range results {
+			report.Transfers++
+			if r.Success {
+				report.Succeeded++
+			} else {
+				report.Failures[models.ErrorCode(r.Error)]++
+			}
+		}
+	}
+
+	waitBefore := mutexWait()
+	started := time.Now()
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range queue {
+				begin := time.Now()
+				var results []models.TransferResult
+				if op.kind == opTransfer {
+					req := op.requests[0]
+					err := svc.Transfer(ctx, req.FromAccountId, req.ToAccountId, req.Amount)
+					results = []models.TransferResult{{RequestId: req.RequestId, Success: err == nil, Error: err}}
+				} else {
+					results = svc.BulkTransfer(ctx, op.requests, op.bulk)
+					if len(results) != len(op.requests) {
+						check.fail("%s batch of %d returned %d results", op.kind, len(op.requests), len(results))
+					}
+				}
+				record(op.kind, time.Since(begin), results)
+			}
+		}()
+	}
+	for _, op := range ops {
+		queue <- op
+	}
+	close(queue)
+	wg.Wait()
+	report.Elapsed = time.Since(started)
+	report.LockWait = mutexWait() - waitBefore
+	if report.Transfers > 0 {
+		report.Contention = report.LockWait / time.Duration(report.Transfers)
+	}
+	stopWatching()
+	<-watched
+
+	for kind, samples := range latencies {
+		report.Latency[string(kind)] = percentiles(samples)
+	}
+	final, err := repo.GetMultipleAccounts(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("stress: reading closing balances: %w", err)
+	}
+	report.Balances = make(map[string]models.Money, len(final))
+	for _, acc := range final {
+		report.Balances[acc.ID] = acc.GetBalance()
+		if acc.GetBalance().IsNegative() {
+			check.fail("account %s ended negative at %s", acc.ID, acc.GetBalance())
+		}
+		if held := acc.GetHeld(); !held.IsZero() {
+			check.fail("account %s ended with %s held", acc.ID, held)
+		}
+	}
+	closing, err := total(final)
+	if err != nil {
+		check.fail("closing balances do not add up: %v", err)
+	} else if closing != opening {
+		check.fail("money not conserved: opened with %s, closed with %s", opening, closing)
+	}
+	attempted, succeeded := svc.GetStats()
+	if attempted != int64(report.Transfers) || succeeded != int64(report.Succeeded) {
+		check.fail("GetStats reports %d attempted and %d succeeded, callers saw %d and %d",
+			attempted, succeeded, report.Transfers, report.Succeeded)
+	}
+	report.Violations = check.violations
+	return report, nil
+}
+
+func total(accounts []*models.Account) (models.Money, error) {
+	sum := models.Zero(accounts[0].GetCurrency())
+	for _, acc := range accounts {
+		var err error
+		if sum, err = sum.Add(acc.GetBalance()); err != nil {
+			return models.Money{}, err
+		}
+	}
+	return sum, nil
+}
+
+// mutexWait reads the runtime's running total of time spent blocked on
+// sync.Mutex and sync.RWMutex
+func mutexWait() time.Duration {
+	sample := []metrics.Sample{{Name: "/sync/mutex/wait/total:seconds"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindFloat64 {
+		return 0
+	}
+	return time.Duration(sample[0].Value.Float64() * float64(time.Second))
+}
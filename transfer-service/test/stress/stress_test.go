@@ -0,0 +1,95 @@
+// File: test/stress/stress_test.go
+package stress_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/test/stress"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func runStress(t *testing.T, cfg stress.Config) *stress.Report {
+	t.Helper()
+	report, err := stress.Run(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Log("\n" + report.String())
+	assert.Empty(t, report.Violations)
+	return report
+}
+
+func TestStress_InvariantsUnderContention(t *testing.T) {
+	cfg := stress.Config{Seed: 1, Accounts: 8, Operations: 3000, Workers: 16}
+	if testing.Short() {
+		cfg.Operations = 300
+	}
+	report := runStress(t, cfg)
+
+	assert.Greater(t, report.Transfers, cfg.Operations, "batches add transfers")
+	assert.Positive(t, report.Succeeded)
+	assert.Positive(t, report.Failures["INSUFFICIENT_BALANCE"], "the load drives accounts to empty")
+	assert.Contains(t, report.Latency, "transfer")
+	assert.Contains(t, report.Latency, "atomic")
+}
+
+func TestStress_HotAccounts(t *testing.T) {
+	// two accounts and many workers put every transfer on the same locks
+	runStress(t, stress.Config{Seed: 7, Accounts: 2, Operations: 1000, Workers: 32, BulkSize: 8})
+}
+
+func TestStress_SingleWorkerIsReproducible(t *testing.T) {
+	cfg := stress.Config{Seed: 42, Accounts: 5, Operations: 300, Workers: 1}
+	first := runStress(t, cfg)
+	second := runStress(t, cfg)
+
+	assert.Equal(t, first.Balances, second.Balances)
+	assert.Equal(t, first.Failures, second.Failures)
+	assert.Equal(t, first.Succeeded, second.Succeeded)
+
+	cfg.Seed = 43
+	assert.NotEqual(t, first.Balances, runStress(t, cfg).Balances)
+}
+
+func TestStress_DatabaseRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs against SQLite")
+	}
+	runStress(t, stress.Config{
+		Seed: 3, Accounts: 6, Operations: 400, Workers: 8,
+		Repository: func(accounts []*models.Account) repository.AccountRepository {
+			return newDBRepository(t, accounts)
+		},
+	})
+}
+
+func newDBRepository(t *testing.T, accounts []*models.Account) repository.AccountRepository {
+	t.Helper()
+	ctx := context.Background()
+	dsn := "file:" + filepath.Join(t.TempDir(), "stress.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	repo, err := repository.NewDBAccountRepository(ctx, db)
+	require.NoError(t, err)
+	require.NoError(t, repo.SeedAccounts(ctx, accounts...))
+	return repo
+}
+
+func TestStress_CachedDatabaseRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs against SQLite")
+	}
+	runStress(t, stress.Config{
+		Seed: 4, Accounts: 6, Operations: 400, Workers: 8,
+		Repository: func(accounts []*models.Account) repository.AccountRepository {
+			store := newDBRepository(t, accounts).(repository.TransactionalOutboxRepository)
+			return repository.NewCachedOutboxRepository(store)
+		},
+	})
+}
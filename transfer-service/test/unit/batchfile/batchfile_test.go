@@ -0,0 +1,144 @@
+// File: test/unit/batchfile/batchfile_test.go
+package batchfile_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"transfer-service/batchfile"
+	"transfer-service/models"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_CSV(t *testing.T) {
+	input := `currency,request_id,from_account_id,to_account_id,amount
+INR,PAY-1,1,2,100.00
+,PAY-2,2,3,0.50
+INR,PAY-3,2,3,abc
+USD,PAY-4,3,3,5.00
+INR,PAY-1,1,3,1.00
+INR,PAY-5,1,2
+INR,,1,2,1.00
+XYZ,PAY-6,1,2,1.00
+`
+	b, err := batchfile.Parse(strings.NewReader(input), batchfile.FormatCSV)
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.TransferRequest{
+		{RequestId: "PAY-1", FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("100.00")},
+		{RequestId: "PAY-2", FromAccountId: "2", ToAccountId: "3", Amount: helpers.INR("0.50")},
+	}, b.Requests)
+	assert.Equal(t, []int{2, 3}, b.Lines)
+
+	var got []string
+	for _, e := range b.Errors {
+		got = append(got, fmt.Sprintf("%d %s %s %s", e.Line, e.RequestId, e.Field, e.Code))
+	}
+	assert.Equal(t, []string{
+		"4 PAY-3 amount MALFORMED_AMOUNT",
+		"5 PAY-4 to_account_id SAME_ACCOUNT_TRANSFER",
+		"6 PAY-1 request_id DUPLICATE_REQUEST_ID",
+		"7  row MALFORMED_ROW",
+		"8  request_id MISSING_FIELD",
+		"9 PAY-6 currency UNKNOWN_CURRENCY",
+	}, got)
+}
+
+func TestParse_CSVFileErrors(t *testing.T) {
+	_, err := batchfile.Parse(strings.NewReader(""), batchfile.FormatCSV)
+	assert.Error(t, err)
+	_, err = batchfile.Parse(strings.NewReader("request_id,from_account_id,amount\n"), batchfile.FormatCSV)
+	assert.ErrorContains(t, err, "to_account_id")
+	_, err = batchfile.Parse(strings.NewReader("x"), batchfile.Format("xml"))
+	assert.ErrorContains(t, err, "unsupported format")
+}
+
+func header() string { return "1" + pad("BATCH-0001", 20) + "20261018" }
+
+func detail(requestId, from, to, currency string, minor int64) string {
+	return "6" + pad(requestId, 20) + pad(from, 15) + pad(to, 15) + pad(currency, 3) + fmt.Sprintf("%015d", minor)
+}
+
+func trailer(count int, total int64) string { return fmt.Sprintf("9%06d%018d", count, total) }
+
+func pad(s string, n int) string { return s + strings.Repeat(" ", n-len(s)) }
+
+func TestParse_FixedWidth(t *testing.T) {
+	input := strings.Join([]string{
+		header(),
+		detail("F-1", "1", "2", "INR", 15000),
+		"",
+		strings.TrimRight(detail("F-2", "2", "3", "", 250), " "),
+		detail("F-3", "3", "", "INR", 100),
+		"6" + pad("F-4", 20) + pad("1", 15) + pad("2", 15) + "INR" + "00000000000012x",
+		"7 what is this",
+		trailer(5, 15000+250+100),
+	}, "\n")
+
+	b, err := batchfile.Parse(strings.NewReader(input), batchfile.FormatFixedWidth)
+	require.NoError(t, err)
+	assert.Equal(t, "BATCH-0001", b.Id)
+	assert.Equal(t, []models.TransferRequest{
+		{RequestId: "F-1", FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("150.00")},
+		{RequestId: "F-2", FromAccountId: "2", ToAccountId: "3", Amount: helpers.INR("2.50")},
+	}, b.Requests)
+	assert.Equal(t, []int{2, 4}, b.Lines)
+	require.Len(t, b.Errors, 3)
+	assert.Equal(t, "MISSING_FIELD", b.Errors[0].Code)
+	assert.Equal(t, 5, b.Errors[0].Line)
+	assert.Equal(t, "MALFORMED_AMOUNT", b.Errors[1].Code)
+	assert.Equal(t, "MALFORMED_ROW", b.Errors[2].Code)
+}
+
+func TestParse_FixedWidthFileErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		lines []string
+		err   string
+	}{
+		{"no header", []string{detail("F-1", "1", "2", "INR", 100), trailer(1, 100)}, "before the header"},
+		{"bad date", []string{"1" + pad("B", 20) + "2026-10-", trailer(0, 0)}, "YYYYMMDD"},
+		{"no trailer", []string{header(), detail("F-1", "1", "2", "INR", 100)}, "no trailer"},
+		{"count mismatch", []string{header(), detail("F-1", "1", "2", "INR", 100), trailer(2, 100)}, "detail records"},
+		{"total mismatch", []string{header(), detail("F-1", "1", "2", "INR", 100), trailer(1, 101)}, "trailer total"},
+		{"after trailer", []string{header(), trailer(0, 0), detail("F-1", "1", "2", "INR", 100)}, "after the trailer"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := batchfile.Parse(strings.NewReader(strings.Join(tc.lines, "\n")), batchfile.FormatFixedWidth)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, batchfile.FormatCSV, batchfile.DetectFormat("in/Payroll.CSV"))
+	assert.Equal(t, batchfile.FormatFixedWidth, batchfile.DetectFormat("in/payroll.ach"))
+}
+
+func TestWriteResults(t *testing.T) {
+	b, err := batchfile.Parse(strings.NewReader("request_id,from_account_id,to_account_id,amount\nA,1,2,1.00\nB,1,2,x\nC,2,1,5.00\n"), batchfile.FormatCSV)
+	require.NoError(t, err)
+
+	results := b.Rejected()
+	results = append(results,
+		b.Outcome(0, models.TransferResult{RequestId: "A", TransferId: "TXN-00000001", Success: true}),
+		b.Outcome(1, models.TransferResult{RequestId: "C", Error: models.NewInsufficientBalanceError("2", helpers.INR("1.00"), helpers.INR("5.00"), models.Zero("INR"))}),
+		b.Checked(0, nil),
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, batchfile.WriteResults(&buf, results))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		"line,request_id,transfer_id,status,error_code,message",
+		`3,B,,REJECTED,MALFORMED_AMOUNT,"amount: [MALFORMED_AMOUNT] Malformed amount ""x"""`,
+		"2,A,TXN-00000001,COMPLETED,,",
+		"4,C,,FAILED,INSUFFICIENT_BALANCE,[INSUFFICIENT_BALANCE] Account 2 has insufficient balance",
+		"2,A,,VALID,,",
+	}, lines)
+}
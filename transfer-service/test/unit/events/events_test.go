@@ -0,0 +1,155 @@
+// File: test/unit/events/events_test.go
+package events_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	"transfer-service/events"
+	"transfer-service/repository"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var occurred = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func completed(ref string) events.TransferCompleted {
+	return events.TransferCompleted{
+		Meta:          events.Meta{OccurredAt: occurred},
+		Reference:     ref,
+		FromAccountId: "1",
+		ToAccountId:   "2",
+		Amount:        helpers.INR("10.00"),
+		Credit:        helpers.INR("10.00"),
+		Fee:           helpers.INR("0.00"),
+	}
+}
+
+// failureCounter only cares about failures
+type failureCounter struct {
+	events.NopListener
+	failures int
+}
+
+func (c *failureCounter) OnTransferFailed(e events.TransferFailed) { c.failures++ }
+
+type panickyListener struct{ events.NopListener }
+
+func (panickyListener) OnTransferCompleted(events.TransferCompleted) { panic("boom") }
+
+func TestBus_SynchronousDelivery(t *testing.T) {
+	bus := events.NewBus()
+	sink := events.NewMemorySink()
+	counter := &failureCounter{}
+	bus.RegisterListener(&panickyListener{})
+	bus.RegisterListener(sink)
+	bus.RegisterListener(counter)
+
+	bus.Publish(completed("A"))
+	bus.Publish(events.TransferFailed{Reference: "B", ErrorCode: "INSUFFICIENT_BALANCE"})
+	bus.UnregisterListener(sink)
+	bus.Publish(events.TransferFailed{Reference: "C"})
+
+	got := sink.Events()
+	require.Len(t, got, 2, "delivered before Publish returns, and not after unregistering")
+	assert.Equal(t, completed("A"), got[0], "a panicking listener does not stop the others")
+	assert.Equal(t, events.TypeTransferFailed, got[1].EventType())
+	assert.Equal(t, 2, counter.failures)
+}
+
+// gatedListener blocks delivery until released
+type gatedListener struct {
+	events.NopListener
+	gate chan struct{}
+}
+
+func (l *gatedListener) OnTransferCompleted(events.TransferCompleted) { <-l.gate }
+
+func TestBus_AsynchronousDelivery(t *testing.T) {
+	bus := events.NewBus(events.WithAsync(16))
+	gated := &gatedListener{gate: make(chan struct{})}
+	sink := events.NewMemorySink()
+	bus.RegisterListener(gated)
+	bus.RegisterListener(sink)
+
+	for _, ref := range []string{"A", "B", "C"} {
+		bus.Publish(completed(ref))
+	}
+	assert.Empty(t, sink.Events(), "a slow listener holds up delivery, not the publisher")
+
+	close(gated.gate)
+	bus.Close()
+	got := sink.Events()
+	require.Len(t, got, 3, "Close delivers what was queued")
+	for i, ref := range []string{"A", "B", "C"} {
+		assert.Equal(t, ref, got[i].(events.TransferCompleted).Reference)
+	}
+
+	bus.Publish(completed("D"))
+	assert.Len(t, sink.Events(), 3, "events after Close are dropped")
+}
+
+func TestRelay_PublishesInOrderOnce(t *testing.T) {
+	ctx := context.Background()
+	outbox := repository.NewInMemoryOutbox()
+	bus := events.NewBus()
+	sink := events.NewMemorySink()
+	bus.RegisterListener(sink)
+	relay := events.NewRelay(outbox, bus)
+
+	for _, ref := range []string{"A", "B"} {
+		rec, err := events.NewRecord(completed(ref))
+		require.NoError(t, err)
+		require.NoError(t, outbox.Append(ctx, rec))
+	}
+	require.NoError(t, outbox.Append(ctx, &repository.OutboxRecord{Type: "Mystery", Payload: []byte(`{}`)}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := relay.Flush(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	got := sink.Events()
+	require.Len(t, got, 2, "concurrent flushes publish each event once; the unknown record is skipped")
+	assert.Equal(t, int64(1), got[0].EventMeta().Sequence)
+	assert.Equal(t, int64(2), got[1].EventMeta().Sequence)
+	pending, err := outbox.Pending(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestFileSink_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink, err := events.NewFileSink(path)
+	require.NoError(t, err)
+
+	written := []events.Event{
+		events.TransferInitiated{Meta: events.Meta{Sequence: 1, OccurredAt: occurred}, Reference: "A", FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("10.00")},
+		completed("A"),
+		events.AccountBalanceChanged{Meta: events.Meta{Sequence: 3, OccurredAt: occurred}, AccountId: "1", Reference: "A", Delta: helpers.INR("-10.00"), Balance: helpers.INR("990.00")},
+	}
+	bus := events.NewBus()
+	bus.RegisterListener(sink)
+	for _, e := range written {
+		bus.Publish(e)
+	}
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	read, err := events.ReadEvents(f)
+	require.NoError(t, err)
+	assert.Equal(t, written, read)
+}
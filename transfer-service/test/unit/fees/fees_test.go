@@ -0,0 +1,143 @@
+// File: test/unit/fees/fees_test.go
+package fees_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"transfer-service/fees"
+	"transfer-service/models"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const yamlSchedule = `
+revenueAccount: FEES
+fees:
+  - name: convenience
+    type: flat
+    amount: "2.00"
+    waivedFor: [merchant]
+  - name: commission
+    type: percentage
+    bps: 25
+    min: "1.00"
+    max: "50.00"
+  - name: slab
+    type: tiered
+    tiers:
+      - upTo: "1000.00"
+      - upTo: "25000.00"
+        flat: "5.00"
+      - flat: "10.00"
+        bps: 10
+`
+
+func loadSchedule(t *testing.T) *fees.Schedule {
+	t.Helper()
+	cfg, err := fees.ParseYAML([]byte(yamlSchedule))
+	require.NoError(t, err)
+	s, err := cfg.Schedule()
+	require.NoError(t, err)
+	return s
+}
+
+func TestFeeRules(t *testing.T) {
+	pct := &fees.PercentageFee{BasisPoints: 25, Min: helpers.INR("1.00"), Max: helpers.INR("50.00")}
+	tiered := &fees.TieredFee{Tiers: []fees.Tier{
+		{UpTo: helpers.INR("1000.00")},
+		{UpTo: helpers.INR("25000.00"), Flat: helpers.INR("5.00")},
+		{Flat: helpers.INR("10.00"), BasisPoints: 10},
+	}}
+
+	testCases := []struct {
+		name   string
+		rule   fees.Rule
+		amount string
+		fee    string
+	}{
+		{"flat", &fees.FlatFee{Amount: helpers.INR("2.00")}, "10.00", "2.00"},
+		{"percentage", pct, "1000.00", "2.50"},
+		{"percentage rounds up", pct, "1000.01", "2.51"},
+		{"percentage floor", pct, "10.00", "1.00"},
+		{"percentage cap", pct, "100000.00", "50.00"},
+		{"first tier free", tiered, "1000.00", "0.00"},
+		{"middle tier", tiered, "1000.01", "5.00"},
+		{"open tier", tiered, "30000.00", "40.00"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fee, err := tc.rule.Compute(helpers.INR(tc.amount))
+			require.NoError(t, err)
+			assert.Equal(t, helpers.INR(tc.fee), fee)
+		})
+	}
+}
+
+func TestSchedule_Calculate(t *testing.T) {
+	s := loadSchedule(t)
+	assert.Equal(t, "FEES", s.RevenueAccountId)
+
+	b, err := s.Calculate(models.AccountPersonal, helpers.INR("2000.00"))
+	require.NoError(t, err)
+	require.NotNil(t, b)
+	assert.Equal(t, []models.FeeComponent{
+		{Name: "convenience", Amount: helpers.INR("2.00")},
+		{Name: "commission", Amount: helpers.INR("5.00")},
+		{Name: "slab", Amount: helpers.INR("5.00")},
+	}, b.Components)
+	assert.Equal(t, helpers.INR("12.00"), b.Total)
+	assert.Equal(t, "FEES", b.RevenueAccountId)
+
+	b, err = s.Calculate(models.AccountMerchant, helpers.INR("400.00"))
+	require.NoError(t, err)
+	assert.Equal(t, []models.FeeComponent{{Name: "commission", Amount: helpers.INR("1.00")}}, b.Components,
+		"merchants skip the convenience fee and the first slab is free")
+
+	b, err = s.Calculate(models.AccountPersonal, models.MustParseMoney("100.00", "USD"))
+	require.NoError(t, err)
+	assert.Nil(t, b, "INR fees do not apply to USD transfers")
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fees.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"revenueAccount":"REV","fees":[{"name":"f","type":"flat","amount":"1"}]}`), 0o600))
+	cfg, err := fees.LoadConfig(path)
+	require.NoError(t, err)
+	s, err := cfg.Schedule()
+	require.NoError(t, err)
+	assert.Equal(t, "REV", s.RevenueAccountId)
+	require.Len(t, s.Components, 1)
+
+	_, err = fees.LoadConfig(filepath.Join(dir, "fees.ini"))
+	assert.Error(t, err)
+}
+
+func TestConfig_RejectsInvalidFees(t *testing.T) {
+	testCases := []struct {
+		name string
+		fee  fees.FeeConfig
+	}{
+		{"missing name", fees.FeeConfig{Type: "flat", Amount: "1"}},
+		{"unknown type", fees.FeeConfig{Name: "x", Type: "bribe"}},
+		{"zero flat", fees.FeeConfig{Name: "x", Type: "flat", Amount: "0"}},
+		{"bad amount", fees.FeeConfig{Name: "x", Type: "flat", Amount: "1.001"}},
+		{"zero bps", fees.FeeConfig{Name: "x", Type: "percentage"}},
+		{"min above max", fees.FeeConfig{Name: "x", Type: "percentage", BPS: 1, Min: "5", Max: "1"}},
+		{"no tiers", fees.FeeConfig{Name: "x", Type: "tiered"}},
+		{"descending tiers", fees.FeeConfig{Name: "x", Type: "tiered", Tiers: []fees.TierConfig{{UpTo: "10"}, {UpTo: "5"}}}},
+		{"open tier not last", fees.FeeConfig{Name: "x", Type: "tiered", Tiers: []fees.TierConfig{{BPS: 1}, {UpTo: "5"}}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&fees.Config{RevenueAccount: "REV", Fees: []fees.FeeConfig{tc.fee}}).Schedule()
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := (&fees.Config{}).Schedule()
+	assert.ErrorContains(t, err, "revenueAccount")
+}
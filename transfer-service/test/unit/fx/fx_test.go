@@ -0,0 +1,107 @@
+// File: test/unit/fx/fx_test.go
+package fx_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"transfer-service/fx"
+	"transfer-service/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	testCases := []struct {
+		value string
+		out   string
+		err   string
+	}{
+		{value: "83.25", out: "83.25"},
+		{value: " 0.012 ", out: "0.012"},
+		{value: "90.100", out: "90.1"},
+		{value: "2", out: "2"},
+		{value: "0", err: "positive"},
+		{value: "-1.5", err: "positive"},
+		{value: "1/3", err: "malformed"},
+		{value: "1e3", err: "malformed"},
+		{value: "abc", err: "malformed"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			r, err := fx.ParseRate("USD", "INR", tc.value)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.out, r.String())
+		})
+	}
+
+	_, err := fx.ParseRate("USD", "XYZ", "1")
+	assert.Equal(t, "UNKNOWN_CURRENCY", models.ErrorCode(err))
+}
+
+func TestStaticRates(t *testing.T) {
+	ctx := context.Background()
+	usdInr, err := fx.ParseRate("USD", "INR", "83.25")
+	require.NoError(t, err)
+	rates := fx.NewStaticRates(usdInr)
+
+	r, err := rates.Rate(ctx, "USD", "INR")
+	require.NoError(t, err)
+	assert.Equal(t, "83.25", r.String())
+
+	_, err = rates.Rate(ctx, "INR", "USD")
+	assert.Equal(t, "FX_RATE_UNAVAILABLE", models.ErrorCode(err), "inverse pairs are not derived")
+
+	same, err := rates.Rate(ctx, "EUR", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "1", same.String())
+
+	updated, _ := fx.ParseRate("USD", "INR", "83.50")
+	rates.Set(updated)
+	r, _ = rates.Rate(ctx, "USD", "INR")
+	assert.Equal(t, "83.5", r.String())
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = rates.Rate(cctx, "USD", "INR")
+	assert.Equal(t, "CANCELLED", models.ErrorCode(err))
+}
+
+func TestLoadRatesFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	rates, err := fx.LoadRatesFile(write("rates.yaml", `
+asOf: 2024-03-01T09:00:00Z
+rates:
+  - {from: USD, to: INR, rate: "83.25"}
+  - {from: EUR, to: INR, rate: "90.10"}
+`))
+	require.NoError(t, err)
+	r, err := rates.Rate(context.Background(), "EUR", "INR")
+	require.NoError(t, err)
+	assert.Equal(t, "90.1", r.String())
+	assert.Equal(t, 2024, r.AsOf.Year())
+
+	rates, err = fx.LoadRatesFile(write("rates.json", `{"rates":[{"from":"INR","to":"USD","rate":"0.012"}]}`))
+	require.NoError(t, err)
+	_, err = rates.Rate(context.Background(), "INR", "USD")
+	assert.NoError(t, err)
+
+	_, err = fx.LoadRatesFile(write("dup.json", `{"rates":[{"from":"INR","to":"USD","rate":"0.012"},{"from":"INR","to":"USD","rate":"0.013"}]}`))
+	assert.ErrorContains(t, err, "duplicate")
+	_, err = fx.LoadRatesFile(write("bad.json", `{"rates":[{"from":"INR","to":"USD","rate":"cheap"}]}`))
+	assert.ErrorContains(t, err, "malformed")
+	_, err = fx.LoadRatesFile(write("rates.csv", "USD,INR,83.25"))
+	assert.ErrorContains(t, err, "unsupported")
+}
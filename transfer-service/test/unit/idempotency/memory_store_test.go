@@ -0,0 +1,72 @@
+// File: test/unit/idempotency/memory_store_test.go
+package idempotency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"transfer-service/idempotency"
+	"transfer-service/models"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryStore_BeginCompleteAndExpire(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := idempotency.NewInMemoryStore(time.Hour, idempotency.WithClock(func() time.Time { return now }))
+
+	existing, err := store.Begin(ctx, "REQ-1", "fp")
+	require.NoError(t, err)
+	assert.Nil(t, existing, "first Begin reserves the key")
+
+	existing, err = store.Begin(ctx, "REQ-1", "fp")
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.False(t, existing.Completed)
+
+	require.NoError(t, store.Complete(ctx, "REQ-1", models.TransferResult{RequestId: "REQ-1", Success: true}))
+	existing, err = store.Begin(ctx, "REQ-1", "fp")
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.True(t, existing.Completed)
+	assert.True(t, existing.Result.Success)
+
+	now = now.Add(time.Hour)
+	existing, err = store.Begin(ctx, "REQ-1", "other")
+	require.NoError(t, err)
+	assert.Nil(t, existing, "expired records no longer block the key")
+}
+
+func TestInMemoryStore_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := idempotency.NewInMemoryStore(time.Minute, idempotency.WithClock(func() time.Time { return now }))
+
+	_, _ = store.Begin(ctx, "REQ-1", "fp")
+	now = now.Add(30 * time.Second)
+	_, _ = store.Begin(ctx, "REQ-2", "fp")
+	now = now.Add(45 * time.Second)
+
+	assert.Equal(t, 1, store.PurgeExpired())
+	assert.Equal(t, 0, store.PurgeExpired())
+}
+
+func TestInMemoryStore_CompleteWithoutBegin(t *testing.T) {
+	store := idempotency.NewInMemoryStore(time.Minute)
+	err := store.Complete(context.Background(), "missing", models.TransferResult{})
+	assert.Contains(t, err.Error(), "IDEMPOTENCY_KEY_NOT_FOUND")
+}
+
+func TestFingerprint(t *testing.T) {
+	req := models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("10.00"), RequestId: "A"}
+	same := req
+	same.RequestId = "B"
+	other := req
+	other.Amount = helpers.INR("10.01")
+
+	assert.Equal(t, idempotency.Fingerprint(req), idempotency.Fingerprint(same))
+	assert.NotEqual(t, idempotency.Fingerprint(req), idempotency.Fingerprint(other))
+}
@@ -0,0 +1,103 @@
+// File: test/unit/ledger/ledger_test.go
+package ledger_test
+
+import (
+	"context"
+	"testing"
+	"transfer-service/ledger"
+	"transfer-service/models"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryLedger_RecordTransfer(t *testing.T) {
+	ctx := context.Background()
+	l := ledger.NewInMemoryLedger()
+
+	entry, err := l.Record(ctx, ledger.NewTransferEntry("REQ-1", "1", "2", helpers.INR("150.00")))
+	require.NoError(t, err)
+	assert.NotEmpty(t, entry.ID)
+	require.Len(t, entry.Postings, 2)
+	assert.Equal(t, ledger.Debit, entry.Postings[0].Direction)
+	assert.Equal(t, ledger.Credit, entry.Postings[1].Direction)
+	assert.Equal(t, entry.ID, entry.Postings[0].EntryID)
+
+	postings, err := l.Postings(ctx, "1")
+	require.NoError(t, err)
+	require.Len(t, postings, 1)
+	assert.Equal(t, "REQ-1", entry.Reference)
+
+	from, err := l.Balance(ctx, "1", "INR")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("-150.00"), from)
+	to, err := l.Balance(ctx, "2", "INR")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("150.00"), to)
+}
+
+func TestInMemoryLedger_RejectsInvalidEntries(t *testing.T) {
+	ctx := context.Background()
+	l := ledger.NewInMemoryLedger()
+
+	testCases := []struct {
+		name     string
+		postings []ledger.Posting
+		code     string
+	}{
+		{"single posting", []ledger.Posting{
+			{AccountID: "1", Direction: ledger.Debit, Amount: helpers.INR("1.00")},
+		}, "INVALID_POSTING"},
+		{"unbalanced", []ledger.Posting{
+			{AccountID: "1", Direction: ledger.Debit, Amount: helpers.INR("1.00")},
+			{AccountID: "2", Direction: ledger.Credit, Amount: helpers.INR("0.99")},
+		}, "UNBALANCED_ENTRY"},
+		{"mixed currencies", []ledger.Posting{
+			{AccountID: "1", Direction: ledger.Debit, Amount: helpers.INR("1.00")},
+			{AccountID: "2", Direction: ledger.Credit, Amount: models.MustParseMoney("1.00", "USD")},
+		}, "UNBALANCED_ENTRY"},
+		{"non-positive amount", []ledger.Posting{
+			{AccountID: "1", Direction: ledger.Debit, Amount: helpers.INR("0.00")},
+			{AccountID: "2", Direction: ledger.Credit, Amount: helpers.INR("0.00")},
+		}, "INVALID_POSTING"},
+		{"missing account", []ledger.Posting{
+			{AccountID: "", Direction: ledger.Debit, Amount: helpers.INR("1.00")},
+			{AccountID: "2", Direction: ledger.Credit, Amount: helpers.INR("1.00")},
+		}, "INVALID_POSTING"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := l.Record(ctx, ledger.JournalEntry{Reference: tc.name, Postings: tc.postings})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.code)
+		})
+	}
+
+	accounts, err := l.Accounts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, accounts)
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	l := ledger.NewInMemoryLedger()
+	accounts := helpers.CreateTestAccounts()
+
+	require.NoError(t, ledger.RecordOpeningBalances(ctx, l, accounts))
+
+	report, err := ledger.Reconcile(ctx, l, accounts)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.True(t, report.Balanced())
+
+	// a balance change with no matching postings must be caught
+	accounts[1].UpdateBalance(helpers.INR("499.99"))
+
+	report, err = ledger.Reconcile(ctx, l, accounts)
+	require.NoError(t, err)
+	require.Len(t, report.Discrepancies, 1)
+	assert.Equal(t, "2", report.Discrepancies[0].AccountID)
+	assert.Equal(t, helpers.INR("500.00"), report.Discrepancies[0].PostedBalance)
+}
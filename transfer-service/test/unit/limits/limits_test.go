@@ -0,0 +1,211 @@
+// File: test/unit/limits/limits_test.go
+package limits_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"transfer-service/limits"
+	"transfer-service/models"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const yamlRules = `
+rules:
+  - name: per-txn
+    type: per_transaction
+    max: "1000.00"
+    currency: INR
+  - name: daily
+    type: daily_amount
+    max: "1500.00"
+    timezone: Asia/Kolkata
+  - name: burst
+    type: velocity
+    count: 3
+    window: 1m
+    accounts: ["1"]
+`
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newEngine(t *testing.T, clock *fakeClock) *limits.Engine {
+	t.Helper()
+	cfg, err := limits.ParseYAML([]byte(yamlRules))
+	require.NoError(t, err)
+	policies, err := cfg.Policies()
+	require.NoError(t, err)
+	return limits.NewEngine(policies, limits.WithClock(clock.Now))
+}
+
+func transfer(from string, amount string) models.TransferRequest {
+	return models.TransferRequest{FromAccountId: from, ToAccountId: "9", Amount: helpers.INR(amount)}
+}
+
+func assertRule(t *testing.T, err error, rule string) *models.TransferError {
+	t.Helper()
+	require.Error(t, err)
+	te := err.(*models.TransferError)
+	assert.Equal(t, "LIMIT_EXCEEDED", te.Code)
+	assert.Equal(t, rule, te.Details["rule"])
+	return te
+}
+
+func TestEngine_PerTransaction(t *testing.T) {
+	engine := newEngine(t, &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)})
+
+	_, err := engine.Reserve(context.Background(), transfer("2", "1000.00"))
+	require.NoError(t, err)
+
+	_, err = engine.Reserve(context.Background(), transfer("2", "1000.01"))
+	te := assertRule(t, err, "per-txn")
+	assert.Equal(t, "per_transaction", te.Details["ruleType"])
+	assert.Equal(t, helpers.INR("1000.00"), te.Details["limit"])
+
+	_, err = engine.Reserve(context.Background(), models.TransferRequest{
+		FromAccountId: "2", ToAccountId: "9", Amount: models.MustParseMoney("1.00", "USD"),
+	})
+	te = assertRule(t, err, "per-txn")
+	assert.Equal(t, "no_limit_for_currency", te.Details["reason"], "a currency without a cap must not skip the rule")
+	assert.Equal(t, "USD", te.Details["currency"])
+}
+
+func TestEngine_CapsEachCurrency(t *testing.T) {
+	cfg, err := limits.ParseYAML([]byte(`
+rules:
+  - name: per-txn
+    type: per_transaction
+    max: "1000.00"
+    currency: INR
+    other_currencies:
+      - max: "20.00"
+        currency: USD
+  - name: daily
+    type: daily_amount
+    max: "1500.00"
+    other_currencies:
+      - max: "30.00"
+        currency: USD
+`))
+	require.NoError(t, err)
+	policies, err := cfg.Policies()
+	require.NoError(t, err)
+	engine := limits.NewEngine(policies, limits.WithClock((&fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}).Now))
+	ctx := context.Background()
+	usd := func(amount string) models.TransferRequest {
+		return models.TransferRequest{FromAccountId: "2", ToAccountId: "9", Amount: models.MustParseMoney(amount, "USD")}
+	}
+
+	_, err = engine.Reserve(ctx, usd("20.01"))
+	assertRule(t, err, "per-txn")
+	_, err = engine.Reserve(ctx, usd("20.00"))
+	require.NoError(t, err)
+	_, err = engine.Reserve(ctx, transfer("2", "1000.00"))
+	require.NoError(t, err, "INR usage counts against the INR cap only")
+	_, err = engine.Reserve(ctx, usd("10.01"))
+	te := assertRule(t, err, "daily")
+	assert.Equal(t, models.MustParseMoney("20.00", "USD"), te.Details["used"])
+}
+
+func TestEngine_DailyAmountUsesConfiguredTimezone(t *testing.T) {
+	// 18:00 UTC is 23:30 in Kolkata; 18:31 UTC is already the next day there
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)}
+	engine := newEngine(t, clock)
+	ctx := context.Background()
+
+	_, err := engine.Reserve(ctx, transfer("2", "900.00"))
+	require.NoError(t, err)
+	_, err = engine.Reserve(ctx, transfer("2", "600.01"))
+	te := assertRule(t, err, "daily")
+	assert.Equal(t, helpers.INR("900.00"), te.Details["used"])
+
+	clock.now = time.Date(2024, 3, 1, 18, 31, 0, 0, time.UTC)
+	_, err = engine.Reserve(ctx, transfer("2", "600.01"))
+	assert.NoError(t, err)
+}
+
+func TestEngine_VelocityAndRelease(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	engine := newEngine(t, clock)
+	ctx := context.Background()
+
+	var last *limits.Reservation
+	for i := 0; i < 3; i++ {
+		r, err := engine.Reserve(ctx, transfer("1", "1.00"))
+		require.NoError(t, err)
+		last = r
+	}
+	_, err := engine.Reserve(ctx, transfer("1", "1.00"))
+	te := assertRule(t, err, "burst")
+	assert.Equal(t, 3, te.Details["count"])
+
+	_, err = engine.Reserve(ctx, transfer("2", "1.00"))
+	assert.NoError(t, err, "the velocity rule is scoped to account 1")
+
+	last.Release()
+	last.Release()
+	_, err = engine.Reserve(ctx, transfer("1", "1.00"))
+	assert.NoError(t, err, "released usage no longer counts")
+
+	clock.now = clock.now.Add(time.Minute + time.Second)
+	_, err = engine.Reserve(ctx, transfer("1", "1.00"))
+	assert.NoError(t, err, "the window has moved on")
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "limits.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"rules":[{"name":"cap","type":"per_transaction","max":"10"}]}`), 0o600))
+
+	cfg, err := limits.LoadConfig(jsonPath)
+	require.NoError(t, err)
+	policies, err := cfg.Policies()
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, "cap", policies[0].Name())
+
+	yamlPath := filepath.Join(dir, "limits.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(yamlRules), 0o600))
+	cfg, err = limits.LoadConfig(yamlPath)
+	require.NoError(t, err)
+	assert.Len(t, cfg.Rules, 3)
+
+	_, err = limits.LoadConfig(filepath.Join(dir, "limits.toml"))
+	assert.Error(t, err)
+}
+
+func TestConfig_RejectsInvalidRules(t *testing.T) {
+	testCases := []struct {
+		name string
+		rule limits.RuleConfig
+	}{
+		{"missing name", limits.RuleConfig{Type: "velocity", Count: 1, Window: "1m"}},
+		{"unknown type", limits.RuleConfig{Name: "x", Type: "monthly"}},
+		{"bad amount", limits.RuleConfig{Name: "x", Type: "per_transaction", Max: "1.001"}},
+		{"zero amount", limits.RuleConfig{Name: "x", Type: "daily_amount", Max: "0"}},
+		{"bad timezone", limits.RuleConfig{Name: "x", Type: "daily_amount", Max: "1", Timezone: "Mars/Olympus"}},
+		{"bad window", limits.RuleConfig{Name: "x", Type: "velocity", Count: 1, Window: "soon"}},
+		{"zero count", limits.RuleConfig{Name: "x", Type: "velocity", Window: "1m"}},
+		{"bad other amount", limits.RuleConfig{Name: "x", Type: "per_transaction", Max: "1",
+			OtherCurrencies: []limits.AmountConfig{{Max: "0", Currency: "USD"}}}},
+		{"two caps in one currency", limits.RuleConfig{Name: "x", Type: "daily_amount", Max: "1",
+			OtherCurrencies: []limits.AmountConfig{{Max: "2", Currency: "INR"}}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&limits.Config{Rules: []limits.RuleConfig{tc.rule}}).Policies()
+			assert.Error(t, err)
+		})
+	}
+
+	dup := limits.RuleConfig{Name: "x", Type: "velocity", Count: 1, Window: "1m"}
+	_, err := (&limits.Config{Rules: []limits.RuleConfig{dup, dup}}).Policies()
+	assert.ErrorContains(t, err, "duplicate")
+}
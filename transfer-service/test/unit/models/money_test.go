@@ -0,0 +1,177 @@
+// File: test/unit/models/money_test.go
+package models_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+	"transfer-service/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		input    string
+		currency string
+		minor    int64
+		err      string
+	}{
+		{"1000", "INR", 100000, ""},
+		{"1000.5", "INR", 100050, ""},
+		{"0.10", "INR", 10, ""},
+		{"-3.25", "INR", -325, ""},
+		{"+7.00", "INR", 700, ""},
+		{"1.500", "INR", 150, ""},
+		{"12", "JPY", 12, ""},
+		{"1.234", "KWD", 1234, ""},
+		{"1.005", "INR", 0, "PRECISION_LOSS"},
+		{"0.5", "JPY", 0, "PRECISION_LOSS"},
+		{"1e3", "INR", 0, "MALFORMED_AMOUNT"},
+		{".5", "INR", 0, "MALFORMED_AMOUNT"},
+		{"5.", "INR", 0, "MALFORMED_AMOUNT"},
+		{"", "INR", 0, "MALFORMED_AMOUNT"},
+		{"1.00", "XXX", 0, "UNKNOWN_CURRENCY"},
+		{"99999999999999999999", "INR", 0, "AMOUNT_OVERFLOW"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input+" "+tc.currency, func(t *testing.T) {
+			m, err := models.ParseMoney(tc.input, tc.currency)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.minor, m.Minor())
+			assert.Equal(t, tc.currency, m.Currency())
+		})
+	}
+}
+
+func TestParseMoneyRounded(t *testing.T) {
+	testCases := []struct {
+		input string
+		mode  models.RoundingMode
+		want  string
+	}{
+		{"1.005", models.RoundHalfEven, "1.00"},
+		{"1.015", models.RoundHalfEven, "1.02"},
+		{"1.005", models.RoundHalfUp, "1.01"},
+		{"-1.005", models.RoundHalfUp, "-1.01"},
+		{"1.009", models.RoundDown, "1.00"},
+		{"1.001", models.RoundUp, "1.01"},
+		{"-1.001", models.RoundUp, "-1.01"},
+	}
+
+	for _, tc := range testCases {
+		m, err := models.ParseMoneyRounded(tc.input, "INR", tc.mode)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, m.Decimal(), "input %s mode %d", tc.input, tc.mode)
+	}
+}
+
+func TestMoney_Format(t *testing.T) {
+	assert.Equal(t, "1000.50 INR", models.NewMoney(100050, "INR").String())
+	assert.Equal(t, "0.05", models.NewMoney(5, "INR").Decimal())
+	assert.Equal(t, "-0.05", models.NewMoney(-5, "INR").Decimal())
+	assert.Equal(t, "12", models.NewMoney(12, "JPY").Decimal())
+	assert.Equal(t, "0.001", models.NewMoney(1, "KWD").Decimal())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a := models.MustParseMoney("0.10", "INR")
+	sum := models.Zero("INR")
+	for i := 0; i < 10; i++ {
+		var err error
+		sum, err = sum.Add(a)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, models.MustParseMoney("1.00", "INR"), sum)
+
+	diff, err := sum.Sub(models.MustParseMoney("0.30", "INR"))
+	require.NoError(t, err)
+	assert.Equal(t, "0.70", diff.Decimal())
+
+	cmp, err := diff.Cmp(sum)
+	require.NoError(t, err)
+	assert.Equal(t, -1, cmp)
+}
+
+func TestMoney_ArithmeticErrors(t *testing.T) {
+	max := models.NewMoney(math.MaxInt64, "INR")
+	min := models.NewMoney(math.MinInt64, "INR")
+	one := models.NewMoney(1, "INR")
+
+	_, err := max.Add(one)
+	assert.Contains(t, err.Error(), "AMOUNT_OVERFLOW")
+	_, err = min.Sub(one)
+	assert.Contains(t, err.Error(), "AMOUNT_OVERFLOW")
+	_, err = min.Neg()
+	assert.Contains(t, err.Error(), "AMOUNT_OVERFLOW")
+	_, err = max.MulRat(2, 1, models.RoundExact)
+	assert.Contains(t, err.Error(), "AMOUNT_OVERFLOW")
+
+	_, err = one.Add(models.NewMoney(1, "USD"))
+	assert.Contains(t, err.Error(), "CURRENCY_MISMATCH")
+	_, err = one.Cmp(models.NewMoney(1, "USD"))
+	assert.Contains(t, err.Error(), "CURRENCY_MISMATCH")
+}
+
+func TestMoney_MulRat(t *testing.T) {
+	amount := models.MustParseMoney("10.05", "INR")
+
+	_, err := amount.MulRat(1, 2, models.RoundExact)
+	assert.Contains(t, err.Error(), "PRECISION_LOSS")
+
+	half, err := amount.MulRat(1, 2, models.RoundHalfEven)
+	require.NoError(t, err)
+	assert.Equal(t, "5.02", half.Decimal())
+
+	fee, err := amount.MulRat(150, 10000, models.RoundUp)
+	require.NoError(t, err)
+	assert.Equal(t, "0.16", fee.Decimal())
+}
+
+func TestMoney_Convert(t *testing.T) {
+	usd := models.MustParseMoney("12.34", "USD")
+
+	inr, err := usd.Convert("INR", big.NewRat(8325, 100), models.RoundDown)
+	require.NoError(t, err)
+	assert.Equal(t, "1027.30", inr.Decimal(), "12.34 * 83.25 = 1027.305, rounded down")
+
+	jpy, err := usd.Convert("JPY", big.NewRat(1505, 10), models.RoundHalfEven)
+	require.NoError(t, err)
+	assert.Equal(t, "1857", jpy.Decimal(), "exponents differ: 12.34 * 150.5 = 1857.17")
+
+	kwd, err := models.MustParseMoney("1000", "JPY").Convert("KWD", big.NewRat(2, 1000), models.RoundExact)
+	require.NoError(t, err)
+	assert.Equal(t, "2.000", kwd.Decimal())
+
+	_, err = usd.Convert("INR", big.NewRat(8325, 100), models.RoundExact)
+	assert.Contains(t, err.Error(), "PRECISION_LOSS")
+	_, err = usd.Convert("XYZ", big.NewRat(1, 1), models.RoundDown)
+	assert.Contains(t, err.Error(), "UNKNOWN_CURRENCY")
+	_, err = usd.Convert("INR", big.NewRat(0, 1), models.RoundDown)
+	assert.Error(t, err)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	m := models.MustParseMoney("1000.50", "INR")
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"1000.50","currency":"INR"}`, string(data))
+
+	var decoded models.Money
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, m, decoded)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":0.1,"currency":"INR"}`), &decoded))
+	assert.Equal(t, int64(10), decoded.Minor())
+
+	err = json.Unmarshal([]byte(`{"amount":"0.001","currency":"INR"}`), &decoded)
+	assert.Contains(t, err.Error(), "PRECISION_LOSS")
+}
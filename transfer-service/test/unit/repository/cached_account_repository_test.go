@@ -0,0 +1,194 @@
+// File: test/unit/repository/cached_account_repository_test.go
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCachedRepository puts the cache over a store that counts its reads
+func newCachedRepository(opts ...repository.CacheOption) (*repository.CachedRepository, *repository.FaultyRepository) {
+	store := repository.NewFaultyRepository(repository.NewSqlAccountRepository(repository.DemoAccounts()), 1)
+	store.SetFault(repository.MethodGetAccountById, repository.Fault{})
+	return repository.NewCachedRepository(store, opts...), store
+}
+
+func mustGet(t *testing.T, repo repository.AccountRepository, accountId string) *models.Account {
+	t.Helper()
+	acc, err := repo.GetAccountById(context.Background(), accountId)
+	require.NoError(t, err)
+	return acc
+}
+
+func TestCachedRepository_ReadsThroughOnce(t *testing.T) {
+	repo, store := newCachedRepository()
+	upiService := service.NewUPITransferService(repo)
+
+	for i := 0; i < 5; i++ {
+		balance, err := upiService.GetAccountBalance(context.Background(), "1")
+		require.NoError(t, err)
+		assert.Equal(t, helpers.INR("1000.00"), balance.Ledger)
+	}
+
+	assert.Equal(t, 1, store.Calls(repository.MethodGetAccountById))
+	stats := repo.Stats()
+	assert.Equal(t, int64(4), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, 1, stats.Size)
+	assert.InDelta(t, 0.8, stats.HitRate(), 0.001)
+}
+
+func TestCachedRepository_EntriesExpire(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo, store := newCachedRepository(
+		repository.WithCacheTTL(time.Minute),
+		repository.WithCacheClock(func() time.Time { return now }),
+	)
+
+	mustGet(t, repo, "1")
+	now = now.Add(59 * time.Second)
+	mustGet(t, repo, "1")
+	assert.Equal(t, 1, store.Calls(repository.MethodGetAccountById))
+
+	now = now.Add(time.Second)
+	mustGet(t, repo, "1")
+	assert.Equal(t, 2, store.Calls(repository.MethodGetAccountById))
+	assert.Equal(t, int64(1), repo.Stats().Expirations)
+}
+
+func TestCachedRepository_EvictsLeastRecentlyUsed(t *testing.T) {
+	repo, store := newCachedRepository(repository.WithCacheSize(2))
+
+	mustGet(t, repo, "1")
+	mustGet(t, repo, "2")
+	mustGet(t, repo, "1") // 2 is now the least recently used
+	mustGet(t, repo, "3")
+
+	stats := repo.Stats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Size)
+
+	mustGet(t, repo, "1")
+	assert.Equal(t, 3, store.Calls(repository.MethodGetAccountById), "1 stayed cached")
+	mustGet(t, repo, "2")
+	assert.Equal(t, 4, store.Calls(repository.MethodGetAccountById), "2 was evicted")
+}
+
+func TestCachedRepository_UpdateRefreshesEntry(t *testing.T) {
+	repo, store := newCachedRepository()
+	mustGet(t, repo, "1")
+
+	updated := &models.Account{ID: "1", Name: "Alice", Balance: helpers.INR("1.00")}
+	require.NoError(t, repo.UpdateAccount(context.Background(), updated))
+
+	assert.Same(t, updated, mustGet(t, repo, "1"))
+	assert.Equal(t, 1, store.Calls(repository.MethodGetAccountById))
+}
+
+func TestCachedRepository_FailedUpdateInvalidates(t *testing.T) {
+	repo, store := newCachedRepository()
+	mustGet(t, repo, "1")
+	store.SetFault(repository.MethodUpdateAccount, repository.Fault{ErrorRate: 1, Err: errors.New("disk full")})
+
+	stale := &models.Account{ID: "1", Name: "Alice", Balance: helpers.INR("1.00")}
+	assert.Error(t, repo.UpdateAccount(context.Background(), stale))
+
+	acc := mustGet(t, repo, "1")
+	assert.Equal(t, helpers.INR("1000.00"), acc.GetBalance())
+	assert.Equal(t, 2, store.Calls(repository.MethodGetAccountById))
+	assert.Equal(t, int64(1), repo.Stats().Invalidations)
+}
+
+func TestCachedRepository_ConcurrentMissesShareOneRead(t *testing.T) {
+	repo, store := newCachedRepository()
+	store.SetFault(repository.MethodGetAccountById, repository.Fault{Latency: repository.FixedLatency(20 * time.Millisecond)})
+
+	const callers = 20
+	var wg sync.WaitGroup
+	accounts := make([]*models.Account, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			acc, err := repo.GetAccountById(context.Background(), "1")
+			assert.NoError(t, err)
+			accounts[i] = acc
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, store.Calls(repository.MethodGetAccountById))
+	for _, acc := range accounts {
+		assert.Same(t, accounts[0], acc)
+	}
+	stats := repo.Stats()
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(callers-1), stats.Hits+stats.Coalesced)
+}
+
+func TestCachedRepository_WaiterReadsAgainWhenReaderGivesUp(t *testing.T) {
+	repo, store := newCachedRepository()
+	store.SetFault(repository.MethodGetAccountById, repository.Fault{Latency: repository.FixedLatency(50 * time.Millisecond)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := repo.GetAccountById(ctx, "1")
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return store.Calls(repository.MethodGetAccountById) == 1 }, time.Second, time.Millisecond)
+
+	second := make(chan *models.Account, 1)
+	go func() {
+		acc, err := repo.GetAccountById(context.Background(), "1")
+		assert.NoError(t, err)
+		second <- acc
+	}()
+	require.Eventually(t, func() bool { return repo.Stats().Coalesced == 1 }, time.Second, time.Millisecond)
+	cancel()
+
+	assert.Equal(t, "CANCELLED", models.ErrorCode(<-first))
+	assert.Equal(t, "Alice", (<-second).Name)
+	assert.Equal(t, 2, store.Calls(repository.MethodGetAccountById))
+}
+
+func TestCachedRepository_TransfersReadTheStore(t *testing.T) {
+	repo, store := newCachedRepository()
+	store.SetFault(repository.MethodGetMultipleAccounts, repository.Fault{})
+	upiService := service.NewUPITransferService(repo)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, upiService.Transfer(context.Background(), "1", "2", helpers.INR("10.00")))
+	}
+
+	assert.Equal(t, 3, store.Calls(repository.MethodGetMultipleAccounts))
+	balance, err := upiService.GetAccountBalance(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("970.00"), balance.Ledger)
+	assert.Zero(t, store.Calls(repository.MethodGetAccountById), "loading for a transfer fills the cache")
+}
+
+func TestCachedRepository_ListsTheStoresAccounts(t *testing.T) {
+	repo, store := newCachedRepository()
+	store.SetFault(repository.MethodListAccountIds, repository.Fault{})
+
+	ids, err := repository.ListAccountIds(context.Background(), repo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+	assert.Equal(t, 1, store.Calls(repository.MethodListAccountIds))
+
+	unlistable := repository.NewCachedRepository(new(mocks.MockAccountRepository))
+	_, err = unlistable.ListAccountIds(context.Background())
+	assert.Equal(t, "ACCOUNT_LISTING_NOT_SUPPORTED", models.ErrorCode(err))
+}
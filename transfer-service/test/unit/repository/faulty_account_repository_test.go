@@ -0,0 +1,175 @@
+// File: test/unit/repository/faulty_account_repository_test.go
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFaultyRepository() (*repository.FaultyRepository, *repository.SqlAccountRepository) {
+	store := repository.NewSqlAccountRepository(repository.DemoAccounts())
+	return repository.NewFaultyRepository(store, 1), store
+}
+
+func ledgerBalance(t *testing.T, store *repository.SqlAccountRepository, accountId string) models.Money {
+	t.Helper()
+	acc := store.Snapshot().Account(accountId)
+	require.NotNil(t, acc, accountId)
+	return acc.Balance
+}
+
+func TestFaultyRepository_OnlySecondUpdateFails(t *testing.T) {
+	repo, store := newFaultyRepository()
+	upiService := service.NewUPITransferService(repo)
+	repo.SetFault(repository.MethodUpdateAccount, repository.Fault{FailCalls: []int{2}})
+
+	// the debit lands, the credit fails and the debit is rolled back
+	err := upiService.Transfer(context.Background(), "1", "2", helpers.INR("100.00"))
+
+	assert.Equal(t, "PARTIAL_FAILURE_COMPENSATED", models.ErrorCode(err))
+	assert.Equal(t, 3, repo.Calls(repository.MethodUpdateAccount))
+	assert.Equal(t, helpers.INR("1000.00"), ledgerBalance(t, store, "1"))
+	assert.Equal(t, helpers.INR("500.00"), ledgerBalance(t, store, "2"))
+
+	// later calls pass again
+	require.NoError(t, upiService.Transfer(context.Background(), "1", "2", helpers.INR("100.00")))
+	assert.Equal(t, helpers.INR("900.00"), ledgerBalance(t, store, "1"))
+}
+
+func TestFaultyRepository_HangEndsWithCallerDeadline(t *testing.T) {
+	repo, store := newFaultyRepository()
+	upiService := service.NewUPITransferService(repo)
+	repo.SetFault(repository.MethodGetAccountById, repository.Fault{HangRate: 1, Accounts: []string{"2"}})
+	repo.SetFault(repository.MethodGetMultipleAccounts, repository.Fault{HangRate: 1, Accounts: []string{"2"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("100.00"))
+
+	assert.Equal(t, "TIMEOUT", models.ErrorCode(err))
+	assert.Equal(t, helpers.INR("1000.00"), ledgerBalance(t, store, "1"))
+
+	// reads that do not name account 2 are neither faulted nor counted
+	balance, err := upiService.GetAccountBalance(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("1000.00"), balance.Ledger)
+	assert.Zero(t, repo.Calls(repository.MethodGetAccountById))
+}
+
+func TestFaultyRepository_CancelledDuringLatency(t *testing.T) {
+	repo, _ := newFaultyRepository()
+	upiService := service.NewUPITransferService(repo)
+	repo.SetFault(repository.MethodGetMultipleAccounts, repository.Fault{Latency: repository.FixedLatency(time.Second)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	started := time.Now()
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("100.00"))
+
+	assert.Equal(t, "CANCELLED", models.ErrorCode(err))
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+}
+
+func TestFaultyRepository_TimeoutBoundsEachCall(t *testing.T) {
+	repo, store := newFaultyRepository()
+	repo.SetFaults(repository.FaultConfig{
+		Timeout: 10 * time.Millisecond,
+		Methods: map[string]repository.Fault{
+			repository.MethodUpdateAccount: {Latency: repository.FixedLatency(time.Second)},
+		},
+	})
+
+	acc := store.Snapshot().Account("1")
+	acc.Balance = helpers.INR("1.00")
+	err := repo.UpdateAccount(context.Background(), acc)
+
+	assert.Equal(t, "TIMEOUT", models.ErrorCode(err))
+	assert.Equal(t, helpers.INR("1000.00"), ledgerBalance(t, store, "1"))
+}
+
+func TestFaultyRepository_FailureAfterCallStillWrites(t *testing.T) {
+	repo, store := newFaultyRepository()
+	lost := errors.New("connection reset")
+	repo.SetFault(repository.MethodUpdateAccount, repository.Fault{ErrorRate: 1, Err: lost, AfterCall: true})
+
+	acc := store.Snapshot().Account("1")
+	acc.Balance = helpers.INR("1.00")
+	err := repo.UpdateAccount(context.Background(), acc)
+
+	assert.ErrorIs(t, err, lost)
+	assert.Equal(t, helpers.INR("1.00"), ledgerBalance(t, store, "1"))
+}
+
+func TestFaultyRepository_DefaultErrorIsStorageError(t *testing.T) {
+	repo, _ := newFaultyRepository()
+	repo.SetFault(repository.MethodGetMultipleAccounts, repository.Fault{ErrorRate: 1})
+
+	_, err := repo.GetMultipleAccounts(context.Background(), []string{"1", "2"})
+
+	assert.Equal(t, "STORAGE_ERROR", models.ErrorCode(err))
+}
+
+func TestFaultyRepository_ErrorRateIsSeeded(t *testing.T) {
+	failures := func(seed int64) []int {
+		store := repository.NewSqlAccountRepository(repository.DemoAccounts())
+		repo := repository.NewFaultyRepository(store, seed)
+		repo.SetFault(repository.MethodGetAccountById, repository.Fault{ErrorRate: 0.3})
+		var failed []int
+		for i := 0; i < 200; i++ {
+			if _, err := repo.GetAccountById(context.Background(), "1"); err != nil {
+				failed = append(failed, i)
+			}
+		}
+		return failed
+	}
+
+	first := failures(42)
+	assert.Equal(t, first, failures(42))
+	assert.InDelta(t, 60, len(first), 25)
+}
+
+func TestFaultyRepository_AdjustableWhileRunning(t *testing.T) {
+	repo, _ := newFaultyRepository()
+	repo.SetFault(repository.MethodGetAccountById, repository.Fault{ErrorRate: 1})
+
+	_, err := repo.GetAccountById(context.Background(), "1")
+	assert.Error(t, err)
+
+	repo.ClearFaults()
+	acc, err := repo.GetAccountById(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", acc.Name)
+	assert.Zero(t, repo.Calls(repository.MethodGetAccountById))
+}
+
+func TestLatency_Distributions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		latency  repository.Latency
+		min, max time.Duration
+	}{
+		{"fixed", repository.FixedLatency(5 * time.Millisecond), 5 * time.Millisecond, 5 * time.Millisecond},
+		{"uniform", repository.UniformLatency(2*time.Millisecond, 8*time.Millisecond), 2 * time.Millisecond, 8 * time.Millisecond},
+		{"normal never negative", repository.NormalLatency(time.Millisecond, 10*time.Millisecond), 0, time.Hour},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(7))
+			for i := 0; i < 1000; i++ {
+				d := tc.latency(r)
+				assert.GreaterOrEqual(t, d, tc.min)
+				assert.LessOrEqual(t, d, tc.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// File: test/unit/risk/risk_test.go
+package risk_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"transfer-service/models"
+	"transfer-service/risk"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func transfer(from, to, amount string) models.TransferRequest {
+	return models.TransferRequest{FromAccountId: from, ToAccountId: to, Amount: helpers.INR(amount)}
+}
+
+func evaluate(t *testing.T, e *risk.Engine, req models.TransferRequest) risk.Assessment {
+	t.Helper()
+	a, err := e.Evaluate(context.Background(), req)
+	require.NoError(t, err)
+	return a
+}
+
+func TestEngine_NoRulesAllows(t *testing.T) {
+	a := evaluate(t, risk.NewEngine(nil), transfer("1", "2", "10.00"))
+	assert.Equal(t, risk.DecisionAllow, a.Decision)
+	assert.Empty(t, a.Findings)
+}
+
+func TestNewPayeeRule(t *testing.T) {
+	clock := &fakeClock{now: start}
+	e := risk.NewEngine([]risk.Rule{
+		&risk.NewPayeeRule{RuleName: "first-payment", MinAmount: helpers.INR("100.00")},
+	}, risk.WithClock(clock.Now))
+
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("1", "2", "99.99")).Decision, "below the minimum")
+	a := evaluate(t, e, transfer("1", "2", "100.00"))
+	assert.Equal(t, risk.DecisionReview, a.Decision)
+	assert.Equal(t, []string{"first-payment"}, a.Rules())
+	assert.Equal(t, "new_payee", a.Findings[0].RuleType)
+
+	e.Observe(context.Background(), transfer("1", "2", "5.00"))
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("1", "2", "500.00")).Decision)
+	assert.Equal(t, risk.DecisionReview, evaluate(t, e, transfer("2", "1", "500.00")).Decision, "paying is not symmetric")
+
+	clock.now = clock.now.Add(365 * 24 * time.Hour)
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("1", "2", "500.00")).Decision, "a known payee stays known")
+}
+
+func TestAmountSpikeRule(t *testing.T) {
+	clock := &fakeClock{now: start}
+	e := risk.NewEngine([]risk.Rule{
+		&risk.AmountSpikeRule{RuleName: "spike", Multiplier: 5, Window: 24 * time.Hour, MinHistory: 3},
+	}, risk.WithClock(clock.Now))
+	ctx := context.Background()
+
+	e.Observe(ctx, transfer("1", "2", "100.00"))
+	e.Observe(ctx, transfer("1", "3", "200.00"))
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("1", "2", "5000.00")).Decision, "too little history for a baseline")
+
+	e.Observe(ctx, transfer("1", "2", "300.00"))
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("1", "2", "1000.00")).Decision, "exactly five times the average")
+	a := evaluate(t, e, transfer("1", "2", "1000.01"))
+	assert.Equal(t, risk.DecisionReview, a.Decision)
+	assert.Equal(t, helpers.INR("200.00"), a.Findings[0].Details["average"])
+
+	clock.now = clock.now.Add(25 * time.Hour)
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("1", "2", "1000.01")).Decision, "history outside the window is ignored")
+}
+
+func TestRapidFireRule(t *testing.T) {
+	clock := &fakeClock{now: start}
+	e := risk.NewEngine([]risk.Rule{
+		&risk.RapidFireRule{RuleName: "burst", Count: 3, Window: time.Minute, Decision: risk.DecisionDeny},
+	}, risk.WithClock(clock.Now))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("1", "2", "1.00")).Decision)
+		e.Observe(ctx, transfer("1", "2", "1.00"))
+		clock.now = clock.now.Add(10 * time.Second)
+	}
+	assert.Equal(t, risk.DecisionDeny, evaluate(t, e, transfer("1", "2", "1.00")).Decision)
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("2", "1", "1.00")).Decision, "counted per sender")
+
+	clock.now = start.Add(time.Minute + time.Second)
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("1", "2", "1.00")).Decision, "the oldest left the window")
+}
+
+func TestRoundTripRule(t *testing.T) {
+	clock := &fakeClock{now: start}
+	e := risk.NewEngine([]risk.Rule{
+		&risk.RoundTripRule{RuleName: "boomerang", Window: time.Hour},
+	}, risk.WithClock(clock.Now))
+	ctx := context.Background()
+
+	e.Observe(ctx, transfer("A", "B", "500.00"))
+	clock.now = clock.now.Add(30 * time.Minute)
+
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("A", "B", "500.00")).Decision, "same direction again")
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("B", "C", "500.00")).Decision)
+	a := evaluate(t, e, transfer("B", "A", "490.00"))
+	assert.Equal(t, risk.DecisionReview, a.Decision)
+	assert.Equal(t, "round_trip", a.Findings[0].RuleType)
+
+	clock.now = start.Add(time.Hour + time.Second)
+	assert.Equal(t, risk.DecisionAllow, evaluate(t, e, transfer("B", "A", "490.00")).Decision)
+}
+
+func TestEngine_StrictestFindingWins(t *testing.T) {
+	e := risk.NewEngine([]risk.Rule{
+		&risk.NewPayeeRule{RuleName: "first-payment"},
+		&risk.RapidFireRule{RuleName: "any", Count: 0, Window: time.Minute, Decision: risk.DecisionDeny},
+	})
+	a := evaluate(t, e, transfer("1", "2", "1.00"))
+	assert.Equal(t, risk.DecisionDeny, a.Decision)
+	assert.Equal(t, []string{"first-payment", "any"}, a.Rules())
+}
+
+func TestEngine_CancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := risk.NewEngine(nil).Evaluate(ctx, transfer("1", "2", "1.00"))
+	assert.Equal(t, "CANCELLED", models.ErrorCode(err))
+}
+
+func TestReviewQueue_DecidesOnce(t *testing.T) {
+	q := risk.NewReviewQueue()
+	held := q.Hold(transfer("1", "2", "10.00"), risk.Assessment{Decision: risk.DecisionReview}, start)
+	assert.Equal(t, "RV-000001", held.ID)
+	assert.Len(t, q.Pending(), 1)
+
+	approved, err := q.Decide(held.ID, risk.ReviewApproved, "", start.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, risk.ReviewApproved, approved.Status)
+	assert.Empty(t, q.Pending())
+
+	_, err = q.Decide(held.ID, risk.ReviewRejected, "too late", start)
+	assert.Equal(t, "INVALID_REVIEW_STATE", models.ErrorCode(err))
+	_, err = q.Decide("RV-999999", risk.ReviewApproved, "", start)
+	assert.Equal(t, "REVIEW_NOT_FOUND", models.ErrorCode(err))
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := risk.LoadConfig(filepath.Join("..", "..", "..", "config", "risk.yaml"))
+	require.NoError(t, err)
+	rules, err := cfg.BuildRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+	assert.Equal(t, helpers.INR("10000.00"), rules[0].(*risk.NewPayeeRule).MinAmount)
+	assert.Equal(t, 720*time.Hour, rules[1].Lookback())
+
+	path := filepath.Join(t.TempDir(), "risk.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules":[{"name":"burst","type":"rapid_fire","count":2,"window":"1m","decision":"DENY"}]}`), 0o644))
+	cfg, err = risk.LoadConfig(path)
+	require.NoError(t, err)
+	rules, err = cfg.BuildRules()
+	require.NoError(t, err)
+	assert.Equal(t, risk.DecisionDeny, rules[0].(*risk.RapidFireRule).Decision)
+}
+
+func TestConfig_RejectsInvalidRules(t *testing.T) {
+	testCases := []struct {
+		name string
+		yaml string
+	}{
+		{"missing name", "rules: [{type: round_trip, window: 1h}]"},
+		{"duplicate name", "rules: [{name: a, type: round_trip, window: 1h}, {name: a, type: round_trip, window: 1h}]"},
+		{"unknown type", "rules: [{name: a, type: vibes}]"},
+		{"allow decision", "rules: [{name: a, type: round_trip, window: 1h, decision: ALLOW}]"},
+		{"bad window", "rules: [{name: a, type: rapid_fire, count: 2, window: soon}]"},
+		{"zero count", "rules: [{name: a, type: rapid_fire, window: 1m}]"},
+		{"zero multiplier", "rules: [{name: a, type: amount_spike, window: 1h}]"},
+		{"bad amount", `rules: [{name: a, type: new_payee, minAmount: "ten"}]`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := risk.ParseYAML([]byte(tc.yaml))
+			require.NoError(t, err)
+			_, err = cfg.BuildRules()
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,332 @@
+// File: test/unit/scheduler/scheduler_test.go
+package scheduler_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"transfer-service/idempotency"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/scheduler"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+
+type fakeClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = t
+}
+
+// scriptedExecutor fails with the queued error codes, then succeeds
+type scriptedExecutor struct {
+	failures []string
+	requests []models.TransferRequest
+}
+
+func (e *scriptedExecutor) ProcessTransfer(ctx context.Context, req models.TransferRequest) models.TransferResult {
+	e.requests = append(e.requests, req)
+	if len(e.failures) > 0 {
+		code := e.failures[0]
+		e.failures = e.failures[1:]
+		return models.TransferResult{RequestId: req.RequestId, Error: &models.TransferError{Code: code}}
+	}
+	return models.TransferResult{RequestId: req.RequestId, Success: true, TransferId: "TXN-" + req.RequestId}
+}
+
+func instruction(freq scheduler.Frequency) scheduler.Instruction {
+	return scheduler.Instruction{
+		FromAccountId: "1",
+		ToAccountId:   "2",
+		Amount:        helpers.INR("100.00"),
+		Frequency:     freq,
+		StartAt:       start,
+	}
+}
+
+func TestInstruction_OccurrenceAt(t *testing.T) {
+	monthly := instruction(scheduler.Monthly)
+	monthly.EndAt = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	expected := []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"}
+	for n, day := range expected {
+		at, ok := monthly.OccurrenceAt(n)
+		require.True(t, ok, "occurrence %d", n)
+		assert.Equal(t, day, at.Format("2006-01-02"))
+		assert.Equal(t, 9, at.Hour())
+	}
+	_, ok := monthly.OccurrenceAt(len(expected))
+	assert.False(t, ok, "May 31 is after the end date")
+
+	weekly := instruction(scheduler.Weekly)
+	at, _ := weekly.OccurrenceAt(2)
+	assert.Equal(t, "2024-02-14", at.Format("2006-01-02"))
+
+	once := instruction(scheduler.Once)
+	_, ok = once.OccurrenceAt(1)
+	assert.False(t, ok)
+}
+
+func TestScheduler_OneOffRunsWhenDue(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: start.Add(-time.Hour)}
+	exec := &scriptedExecutor{}
+	s := scheduler.NewScheduler(exec, scheduler.WithClock(clock.Now))
+
+	in, err := s.Schedule(ctx, instruction(scheduler.Once))
+	require.NoError(t, err)
+	assert.Equal(t, "SI-000001", in.ID)
+
+	assert.Empty(t, s.RunDue(ctx), "not due yet")
+
+	clock.Set(start)
+	ran := s.RunDue(ctx)
+	require.Len(t, ran, 1)
+	assert.True(t, ran[0].Success)
+	assert.Equal(t, "SI-000001-0001", ran[0].RequestId)
+	assert.Equal(t, "TXN-SI-000001-0001", ran[0].TransferId)
+	assert.Equal(t, start, ran[0].ScheduledFor)
+
+	got, _ := s.Get(in.ID)
+	assert.Equal(t, scheduler.InstructionCompleted, got.Status)
+	clock.Set(start.Add(48 * time.Hour))
+	assert.Empty(t, s.RunDue(ctx))
+}
+
+func TestScheduler_DailyCatchesUpUntilEndDate(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: start}
+	exec := &scriptedExecutor{}
+	s := scheduler.NewScheduler(exec, scheduler.WithClock(clock.Now))
+
+	daily := instruction(scheduler.Daily)
+	daily.EndAt = start.AddDate(0, 0, 2)
+	in, err := s.Schedule(ctx, daily)
+	require.NoError(t, err)
+
+	clock.Set(start.AddDate(0, 0, 10))
+	ran := s.RunDue(ctx)
+	require.Len(t, ran, 3)
+	for i, e := range ran {
+		assert.Equal(t, i, e.Occurrence)
+		assert.Equal(t, start.AddDate(0, 0, i), e.ScheduledFor)
+	}
+	assert.Equal(t, []string{"SI-000001-0001", "SI-000001-0002", "SI-000001-0003"},
+		[]string{exec.requests[0].RequestId, exec.requests[1].RequestId, exec.requests[2].RequestId})
+
+	got, _ := s.Get(in.ID)
+	assert.Equal(t, scheduler.InstructionCompleted, got.Status)
+	assert.Len(t, s.Executions(in.ID), 3)
+}
+
+func TestScheduler_RetriesTransientFailures(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: start}
+	exec := &scriptedExecutor{failures: []string{"CONCURRENT_MODIFICATION", "CONCURRENT_MODIFICATION"}}
+	s := scheduler.NewScheduler(exec, scheduler.WithClock(clock.Now), scheduler.WithRetry(3, time.Minute))
+	in, err := s.Schedule(ctx, instruction(scheduler.Weekly))
+	require.NoError(t, err)
+
+	ran := s.RunDue(ctx)
+	require.Len(t, ran, 1)
+	assert.True(t, ran[0].WillRetry)
+	assert.Equal(t, "CONCURRENT_MODIFICATION", ran[0].ErrorCode)
+
+	clock.Set(start.Add(59 * time.Second))
+	assert.Empty(t, s.RunDue(ctx), "backing off")
+
+	clock.Set(start.Add(time.Minute))
+	ran = s.RunDue(ctx)
+	require.Len(t, ran, 1)
+	assert.Equal(t, 2, ran[0].Attempt)
+	assert.Equal(t, "SI-000001-0001-r2", ran[0].RequestId)
+	assert.True(t, ran[0].WillRetry)
+
+	clock.Set(start.Add(3 * time.Minute)) // the second backoff doubles
+	ran = s.RunDue(ctx)
+	require.Len(t, ran, 1)
+	assert.True(t, ran[0].Success)
+	assert.Equal(t, 3, ran[0].Attempt)
+
+	got, _ := s.Get(in.ID)
+	assert.Equal(t, 1, got.Occurrence)
+	assert.Equal(t, start.AddDate(0, 0, 7), got.NextRunAt)
+	assert.Len(t, s.Executions(in.ID), 3)
+}
+
+func TestScheduler_DoesNotRetryPermanentFailures(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: start}
+	exec := &scriptedExecutor{failures: []string{"INSUFFICIENT_BALANCE", "CONCURRENT_MODIFICATION", "CONCURRENT_MODIFICATION"}}
+	s := scheduler.NewScheduler(exec, scheduler.WithClock(clock.Now), scheduler.WithRetry(2, time.Minute))
+	in, _ := s.Schedule(ctx, instruction(scheduler.Daily))
+
+	ran := s.RunDue(ctx)
+	require.Len(t, ran, 1)
+	assert.False(t, ran[0].WillRetry)
+
+	// the next occurrence gives up after its two attempts
+	clock.Set(start.AddDate(0, 0, 1))
+	s.RunDue(ctx)
+	clock.Set(start.AddDate(0, 0, 1).Add(time.Minute))
+	ran = s.RunDue(ctx)
+	require.Len(t, ran, 1)
+	assert.False(t, ran[0].WillRetry)
+
+	got, _ := s.Get(in.ID)
+	assert.Equal(t, 2, got.Occurrence)
+	assert.Equal(t, scheduler.InstructionActive, got.Status)
+}
+
+func TestScheduler_RetriesAFailedRead(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewFaultyRepository(repository.NewSqlAccountRepository(repository.DemoAccounts()), 1)
+	store.SetFault(repository.MethodGetMultipleAccounts, repository.Fault{FailCalls: []int{1}})
+	upiService := service.NewUPITransferService(store)
+	clock := &fakeClock{now: start}
+	s := scheduler.NewScheduler(upiService, scheduler.WithClock(clock.Now), scheduler.WithRetry(3, time.Minute))
+	_, err := s.Schedule(ctx, instruction(scheduler.Once))
+	require.NoError(t, err)
+
+	ran := s.RunDue(ctx)
+	require.Len(t, ran, 1)
+	assert.Equal(t, "STORAGE_READ_FAILED", ran[0].ErrorCode)
+	assert.True(t, ran[0].WillRetry, "nothing was written before the read failed")
+	assert.False(t, ran[0].Unresolved)
+
+	clock.Set(start.Add(time.Minute))
+	ran = s.RunDue(ctx)
+	require.Len(t, ran, 1)
+	assert.True(t, ran[0].Success)
+	balance, err := upiService.GetAccountBalance(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("900.00"), balance.Ledger, "paid once")
+}
+
+func TestScheduler_CompensatedFailureIsResolved(t *testing.T) {
+	ctx := context.Background()
+	exec := &scriptedExecutor{failures: []string{"PARTIAL_FAILURE_COMPENSATED"}}
+	s := scheduler.NewScheduler(exec, scheduler.WithClock((&fakeClock{now: start}).Now))
+	_, err := s.Schedule(ctx, instruction(scheduler.Daily))
+	require.NoError(t, err)
+
+	ran := s.RunDue(ctx)
+	require.Len(t, ran, 1)
+	assert.False(t, ran[0].WillRetry)
+	assert.False(t, ran[0].Unresolved, "every write was rolled back")
+}
+
+func TestScheduler_DoesNotRetryAmbiguousFailures(t *testing.T) {
+	for _, code := range []string{"TIMEOUT", "STORAGE_ERROR", "COMPENSATION_FAILED"} {
+		t.Run(code, func(t *testing.T) {
+			ctx := context.Background()
+			clock := &fakeClock{now: start}
+			exec := &scriptedExecutor{failures: []string{code}}
+			s := scheduler.NewScheduler(exec, scheduler.WithClock(clock.Now), scheduler.WithRetry(3, time.Minute))
+			in, _ := s.Schedule(ctx, instruction(scheduler.Daily))
+
+			ran := s.RunDue(ctx)
+			require.Len(t, ran, 1)
+			assert.False(t, ran[0].WillRetry)
+			assert.True(t, ran[0].Unresolved, "the commit may have landed")
+
+			// no retry under a new RequestId, which could pay a second time
+			clock.Set(start.Add(time.Hour))
+			assert.Empty(t, s.RunDue(ctx))
+			require.Len(t, exec.requests, 1)
+			got, _ := s.Get(in.ID)
+			assert.Equal(t, 1, got.Occurrence)
+		})
+	}
+}
+
+func TestScheduler_PauseResumeCancel(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: start}
+	exec := &scriptedExecutor{}
+	s := scheduler.NewScheduler(exec, scheduler.WithClock(clock.Now))
+	in, _ := s.Schedule(ctx, instruction(scheduler.Daily))
+
+	require.NoError(t, s.Pause(in.ID))
+	assert.Equal(t, "INVALID_INSTRUCTION_STATE", models.ErrorCode(s.Pause(in.ID)))
+
+	clock.Set(start.AddDate(0, 0, 2).Add(time.Hour))
+	assert.Empty(t, s.RunDue(ctx))
+
+	require.NoError(t, s.Resume(in.ID))
+	got, _ := s.Get(in.ID)
+	assert.Equal(t, 3, got.Occurrence, "occurrences missed while paused are skipped")
+	assert.Equal(t, start.AddDate(0, 0, 3), got.NextRunAt)
+
+	require.NoError(t, s.Cancel(in.ID))
+	clock.Set(start.AddDate(0, 0, 30))
+	assert.Empty(t, s.RunDue(ctx))
+	assert.Equal(t, "INVALID_INSTRUCTION_STATE", models.ErrorCode(s.Resume(in.ID)))
+	assert.Equal(t, "INSTRUCTION_NOT_FOUND", models.ErrorCode(s.Cancel("SI-999999")))
+	assert.Empty(t, exec.requests)
+}
+
+func TestScheduler_RejectsInvalidInstructions(t *testing.T) {
+	s := scheduler.NewScheduler(&scriptedExecutor{})
+	mutate := []func(*scheduler.Instruction){
+		func(in *scheduler.Instruction) { in.FromAccountId = "" },
+		func(in *scheduler.Instruction) { in.ToAccountId = in.FromAccountId },
+		func(in *scheduler.Instruction) { in.Amount = helpers.INR("0.00") },
+		func(in *scheduler.Instruction) { in.StartAt = time.Time{} },
+		func(in *scheduler.Instruction) { in.EndAt = in.StartAt.Add(-time.Second) },
+		func(in *scheduler.Instruction) { in.Frequency = "HOURLY" },
+	}
+	for _, m := range mutate {
+		in := instruction(scheduler.Daily)
+		m(&in)
+		_, err := s.Schedule(context.Background(), in)
+		assert.Equal(t, "INVALID_INSTRUCTION", models.ErrorCode(err))
+	}
+	assert.Empty(t, s.List())
+}
+
+func TestScheduler_RestartDoesNotPayTwice(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mocks.MockAccountRepository)
+	for _, acc := range helpers.CreateTestAccounts() {
+		mockRepo.ExpectAccount(acc)
+	}
+	mockRepo.ExpectUpdate()
+	store := idempotency.NewInMemoryStore(24 * time.Hour)
+	upiService := service.NewUPITransferService(mockRepo, service.WithIdempotencyStore(store))
+	clock := &fakeClock{now: start}
+
+	// two scheduler processes loaded with the same instruction, as after a
+	// restart that lost the record of the first run
+	for i := 0; i < 2; i++ {
+		s := scheduler.NewScheduler(upiService, scheduler.WithClock(clock.Now))
+		_, err := s.Schedule(ctx, instruction(scheduler.Once))
+		require.NoError(t, err)
+		ran := s.RunDue(ctx)
+		require.Len(t, ran, 1)
+		assert.True(t, ran[0].Success)
+	}
+
+	balance, err := upiService.GetAccountBalance(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("900.00"), balance.Ledger)
+}
@@ -0,0 +1,99 @@
+// File: test/unit/service/account_lifecycle_test.go
+package service_test
+
+import (
+	"context"
+	"testing"
+	"transfer-service/ledger"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLifecycleService(accounts ...*models.Account) (*service.UPITransferService, *mocks.MockAccountRepository) {
+	mockRepo := new(mocks.MockAccountRepository)
+	for _, acc := range accounts {
+		mockRepo.ExpectAccount(acc)
+	}
+	mockRepo.ExpectUpdate()
+	return service.NewUPITransferService(mockRepo), mockRepo
+}
+
+func TestUPITransferService_OpenAccount(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mocks.MockAccountRepository)
+	journal := ledger.NewInMemoryLedger()
+	upiService := service.NewUPITransferService(mockRepo, service.WithLedger(journal))
+
+	mockRepo.ExpectCreate("9", nil).Once()
+	mockRepo.ExpectCreate("9", models.NewAccountAlreadyExistsError("9")).Once()
+
+	acc, err := upiService.OpenAccount(ctx, "9", "Ivy", helpers.INR("25.00"))
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountActive, acc.Status)
+
+	posted, err := journal.Balance(ctx, "9", "INR")
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("25.00"), posted, "the opening balance is journaled")
+
+	_, err = upiService.OpenAccount(ctx, "9", "Ivy", helpers.INR("0.00"))
+	assert.Contains(t, err.Error(), "ACCOUNT_ALREADY_EXISTS")
+
+	_, err = upiService.OpenAccount(ctx, "10", "Neg", helpers.INR("-1.00"))
+	assert.Contains(t, err.Error(), "INVALID_AMOUNT")
+	_, err = upiService.OpenAccount(ctx, "", "Nobody", helpers.INR("0.00"))
+	assert.Contains(t, err.Error(), "EMPTY_ACCOUNT_ID")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUPITransferService_FrozenAccount(t *testing.T) {
+	ctx := context.Background()
+	alice := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	bob := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	upiService, _ := newLifecycleService(alice, bob)
+
+	require.NoError(t, upiService.FreezeAccount(ctx, "1"))
+	assert.Equal(t, models.AccountFrozen, alice.GetStatus())
+
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("10.00"))
+	require.Error(t, err)
+	assert.Equal(t, "ACCOUNT_FROZEN", err.(*models.TransferError).Code)
+
+	require.NoError(t, upiService.Transfer(ctx, "2", "1", helpers.INR("10.00")), "a frozen account still receives credits")
+	assert.Equal(t, helpers.INR("1010.00"), alice.Balance)
+
+	err = upiService.FreezeAccount(ctx, "1")
+	assert.Contains(t, err.Error(), "INVALID_STATUS_TRANSITION")
+
+	require.NoError(t, upiService.UnfreezeAccount(ctx, "1"))
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("10.00")))
+}
+
+func TestUPITransferService_ClosedAccount(t *testing.T) {
+	ctx := context.Background()
+	alice := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	bob := helpers.CreateTestAccount("2", "Bob", helpers.INR("0.00"))
+	upiService, _ := newLifecycleService(alice, bob)
+
+	err := upiService.CloseAccount(ctx, "1")
+	require.Error(t, err)
+	assert.Equal(t, "ACCOUNT_NOT_EMPTY", err.(*models.TransferError).Code)
+
+	require.NoError(t, upiService.CloseAccount(ctx, "2"))
+
+	err = upiService.Transfer(ctx, "1", "2", helpers.INR("10.00"))
+	require.Error(t, err)
+	assert.Equal(t, "ACCOUNT_CLOSED", err.(*models.TransferError).Code)
+	assert.Equal(t, "2", err.(*models.TransferError).Details["accountId"])
+	assert.Equal(t, helpers.INR("1000.00"), alice.Balance)
+
+	err = upiService.Transfer(ctx, "2", "1", helpers.INR("10.00"))
+	assert.Equal(t, "ACCOUNT_CLOSED", err.(*models.TransferError).Code)
+
+	err = upiService.UnfreezeAccount(ctx, "2")
+	assert.Contains(t, err.Error(), "INVALID_STATUS_TRANSITION")
+}
@@ -0,0 +1,136 @@
+// File: test/unit/service/atomic_batch_test.go
+package service_test
+
+import (
+	"context"
+	"testing"
+	"transfer-service/ledger"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var atomicOpts = service.BulkOptions{Atomic: true}
+
+func balances(repo *bulkRepository, ids ...string) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = repo.accounts[id].GetBalance().Decimal()
+	}
+	return out
+}
+
+func TestAtomicBatch_ChainsWithinTheBatch(t *testing.T) {
+	testCases := []struct {
+		name  string
+		order []int
+	}{
+		{"in order", []int{0, 1}},
+		{"spend before receiving", []int{1, 0}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newBulkRepository(3, "10.00")
+			journal := ledger.NewInMemoryLedger()
+			require.NoError(t, ledger.RecordOpeningBalances(ctx, journal, []*models.Account{repo.accounts["A00"], repo.accounts["A01"], repo.accounts["A02"]}))
+			upiService := service.NewUPITransferService(repo, service.WithLedger(journal))
+
+			// A01 only has 10.00 of its own, so it needs A00's payment to pay A02
+			chain := []models.TransferRequest{
+				{FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR("10.00"), RequestId: "R00"},
+				{FromAccountId: "A01", ToAccountId: "A02", Amount: helpers.INR("20.00"), RequestId: "R01"},
+			}
+			reqs := []models.TransferRequest{chain[tc.order[0]], chain[tc.order[1]]}
+
+			results := upiService.BulkTransfer(ctx, reqs, atomicOpts)
+			require.Len(t, results, 2)
+			for i, r := range results {
+				assert.Equal(t, reqs[i].RequestId, r.RequestId)
+				assert.True(t, r.Success, "item %d: %v", i, r.Error)
+			}
+			assert.Equal(t, []string{"0.00", "0.00", "30.00"}, balances(repo, "A00", "A01", "A02"))
+
+			report, err := upiService.Reconcile(ctx)
+			require.NoError(t, err)
+			assert.True(t, report.Balanced())
+			total, success := upiService.GetStats()
+			assert.Equal(t, int64(2), total)
+			assert.Equal(t, int64(2), success)
+		})
+	}
+}
+
+func TestAtomicBatch_AbortNamesTheFailingItem(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mutate  func(reqs []models.TransferRequest)
+		culprit int
+		code    string
+	}{
+		{"overdraft", func(reqs []models.TransferRequest) { reqs[2].Amount = helpers.INR("12.00") }, 2, "INSUFFICIENT_BALANCE"},
+		{"invalid item", func(reqs []models.TransferRequest) { reqs[1].ToAccountId = "A01" }, 1, "SAME_ACCOUNT_TRANSFER"},
+		{"unknown account", func(reqs []models.TransferRequest) { reqs[3].ToAccountId = "ZZ" }, 3, "ACCOUNT_NOT_FOUND"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newBulkRepository(5, "10.00")
+			reqs := bulkRequests(4)
+			tc.mutate(reqs)
+			upiService := service.NewUPITransferService(repo)
+
+			results := upiService.BulkTransfer(context.Background(), reqs, atomicOpts)
+			require.Len(t, results, 4)
+			for i, r := range results {
+				assert.False(t, r.Success)
+				if i == tc.culprit {
+					assert.Equal(t, tc.code, models.ErrorCode(r.Error))
+					continue
+				}
+				require.Equal(t, "BATCH_ABORTED", models.ErrorCode(r.Error), "item %d", i)
+				details := r.Error.(*models.TransferError).Details
+				assert.Equal(t, tc.culprit, details["abortedByIndex"])
+				assert.Equal(t, reqs[tc.culprit].RequestId, details["abortedByRequestId"])
+				assert.Equal(t, tc.code, details["cause"])
+			}
+			assert.Equal(t, []string{"10.00", "10.00", "10.00", "10.00", "10.00"}, balances(repo, "A00", "A01", "A02", "A03", "A04"))
+			total, success := upiService.GetStats()
+			assert.Equal(t, int64(4), total)
+			assert.Equal(t, int64(0), success)
+		})
+	}
+}
+
+func TestAtomicBatch_FailedWriteRollsBackEverything(t *testing.T) {
+	repo := newBulkRepository(4, "10.00")
+	repo.failures["A02"] = models.NewStorageError("update", assert.AnError)
+	upiService := service.NewUPITransferService(repo)
+
+	results := upiService.BulkTransfer(context.Background(), bulkRequests(3), atomicOpts)
+	require.Len(t, results, 3)
+	for _, r := range results {
+		assert.Equal(t, "PARTIAL_FAILURE_COMPENSATED", models.ErrorCode(r.Error), "no single item is to blame")
+	}
+	assert.Equal(t, []string{"10.00", "10.00", "10.00", "10.00"}, balances(repo, "A00", "A01", "A02", "A03"))
+}
+
+func TestAtomicBatch_JournalFailureAfterCommitKeepsBatch(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(3, "10.00")
+	upiService := service.NewUPITransferService(repo, service.WithLedger(failingLedger{ledger.NewInMemoryLedger()}))
+
+	reqs := []models.TransferRequest{
+		{FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR("4.00"), RequestId: "R00"},
+		{FromAccountId: "A01", ToAccountId: "A02", Amount: helpers.INR("6.00"), RequestId: "R01"},
+	}
+	results := upiService.BulkTransfer(ctx, reqs, atomicOpts)
+
+	// the batch committed as a whole, so it is reported as a whole
+	for i, r := range results {
+		assert.True(t, r.Success, "item %d: %v", i, r.Error)
+	}
+	assert.Equal(t, []string{"6.00", "8.00", "16.00"}, balances(repo, "A00", "A01", "A02"))
+}
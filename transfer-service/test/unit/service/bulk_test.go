@@ -0,0 +1,220 @@
+// File: test/unit/service/bulk_test.go
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkRepository is a hand-written fake that can slow reads of some accounts,
+// fail writes of others and records how many reads run at once; testify's
+// mock would race with the workers updating the shared accounts
+type bulkRepository struct {
+	accounts map[string]*models.Account
+	delays   map[string]time.Duration
+	failures map[string]error // UpdateAccount error by account id
+	mutex    sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func newBulkRepository(n int, balance string) *bulkRepository {
+	r := &bulkRepository{
+		accounts: make(map[string]*models.Account),
+		delays:   make(map[string]time.Duration),
+		failures: make(map[string]error),
+	}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("A%02d", i)
+		r.accounts[id] = helpers.CreateTestAccount(id, id, helpers.INR(balance))
+	}
+	return r
+}
+
+func (r *bulkRepository) CreateAccount(ctx context.Context, acc *models.Account) error {
+	return models.NewAccountAlreadyExistsError(acc.ID)
+}
+
+func (r *bulkRepository) GetAccountById(ctx context.Context, id string) (*models.Account, error) {
+	acc, ok := r.accounts[id]
+	if !ok {
+		return nil, models.NewAccountNotFoundError(id)
+	}
+	return acc, nil
+}
+
+func (r *bulkRepository) GetMultipleAccounts(ctx context.Context, ids []string) ([]*models.Account, error) {
+	r.mutex.Lock()
+	r.inFlight++
+	if r.inFlight > r.peak {
+		r.peak = r.inFlight
+	}
+	r.mutex.Unlock()
+	defer func() {
+		r.mutex.Lock()
+		r.inFlight--
+		r.mutex.Unlock()
+	}()
+
+	if d := r.delays[ids[0]]; d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return nil, models.WrapContextError(ctx.Err())
+		}
+	}
+	var out []*models.Account
+	for _, id := range ids {
+		acc, err := r.GetAccountById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, acc)
+	}
+	return out, nil
+}
+
+func (r *bulkRepository) ListAccountIds(ctx context.Context) ([]string, error) {
+	ids := make([]string, 0, len(r.accounts))
+	for id := range r.accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (r *bulkRepository) UpdateAccount(ctx context.Context, acc *models.Account) error {
+	return r.failures[acc.ID]
+}
+
+// bulkRequests sends 1.00 from A<i> to A<i+1>, one request per account
+func bulkRequests(n int) []models.TransferRequest {
+	reqs := make([]models.TransferRequest, n)
+	for i := range reqs {
+		reqs[i] = models.TransferRequest{
+			FromAccountId: fmt.Sprintf("A%02d", i),
+			ToAccountId:   fmt.Sprintf("A%02d", i+1),
+			Amount:        helpers.INR("1.00"),
+			RequestId:     fmt.Sprintf("R%02d", i),
+		}
+	}
+	return reqs
+}
+
+func TestBulkTransfer_OrderedResultsAndWorkerCount(t *testing.T) {
+	repo := newBulkRepository(21, "10.00")
+	for i := 0; i < 20; i++ {
+		// earlier items finish last
+		repo.delays[fmt.Sprintf("A%02d", i)] = time.Duration(20-i) * time.Millisecond
+	}
+	upiService := service.NewUPITransferService(repo)
+
+	results := upiService.BulkTransfer(context.Background(), bulkRequests(20), service.BulkOptions{Workers: 5, Ordered: true})
+	require.Len(t, results, 20)
+	for i, r := range results {
+		assert.Equal(t, fmt.Sprintf("R%02d", i), r.RequestId)
+		assert.True(t, r.Success)
+	}
+	assert.Equal(t, 5, repo.peak)
+}
+
+func TestBulkTransfer_UnorderedReportsCompletionOrder(t *testing.T) {
+	repo := newBulkRepository(3, "10.00")
+	repo.delays["A00"] = 50 * time.Millisecond
+	upiService := service.NewUPITransferService(repo)
+
+	results := upiService.BulkTransfer(context.Background(), bulkRequests(2), service.BulkOptions{Workers: 2})
+	require.Len(t, results, 2)
+	assert.Equal(t, "R01", results[0].RequestId, "the slow first item completes last")
+	assert.Equal(t, "R00", results[1].RequestId)
+}
+
+func TestBulkTransfer_FailurePolicy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      service.BulkOptions
+		attempted int
+	}{
+		{"continue", service.BulkOptions{Workers: 1, Ordered: true}, 6},
+		{"stop on first error", service.BulkOptions{Workers: 1, Ordered: true, StopOnFirstError: true}, 2},
+		{"max failures", service.BulkOptions{Workers: 1, Ordered: true, MaxFailures: 2}, 4},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newBulkRepository(7, "10.00")
+			reqs := bulkRequests(6)
+			// items 1 and 3 overdraw their accounts
+			reqs[1].Amount = helpers.INR("50.00")
+			reqs[3].Amount = helpers.INR("50.00")
+			upiService := service.NewUPITransferService(repo)
+
+			results := upiService.BulkTransfer(context.Background(), reqs, tc.opts)
+			require.Len(t, results, 6)
+			for i, r := range results {
+				assert.Equal(t, reqs[i].RequestId, r.RequestId)
+				if i >= tc.attempted {
+					assert.Equal(t, "BULK_ABORTED", models.ErrorCode(r.Error), "item %d", i)
+				}
+			}
+			assert.Equal(t, "INSUFFICIENT_BALANCE", models.ErrorCode(results[1].Error))
+			total, _ := upiService.GetStats()
+			assert.Equal(t, int64(tc.attempted), total)
+		})
+	}
+}
+
+func TestBulkTransfer_ItemTimeout(t *testing.T) {
+	repo := newBulkRepository(4, "10.00")
+	repo.delays["A01"] = time.Second
+	upiService := service.NewUPITransferService(repo)
+
+	results := upiService.BulkTransfer(context.Background(), bulkRequests(3),
+		service.BulkOptions{Workers: 3, Ordered: true, ItemTimeout: 20 * time.Millisecond})
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, "TIMEOUT", models.ErrorCode(results[1].Error))
+	assert.True(t, results[2].Success)
+}
+
+func TestBulkTransfer_CancelledContext(t *testing.T) {
+	upiService := service.NewUPITransferService(newBulkRepository(4, "10.00"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := upiService.BulkTransfer(ctx, bulkRequests(3), service.BulkOptions{Ordered: true})
+	require.Len(t, results, 3)
+	for _, r := range results {
+		assert.Equal(t, "CANCELLED", models.ErrorCode(r.Error))
+	}
+}
+
+func TestBulkTransferStream(t *testing.T) {
+	repo := newBulkRepository(51, "10.00")
+	upiService := service.NewUPITransferService(repo)
+
+	next := 0
+	for r := range upiService.BulkTransferStream(context.Background(), bulkRequests(50), service.BulkOptions{Workers: 4, Ordered: true}) {
+		assert.Equal(t, next, r.Index)
+		assert.Equal(t, fmt.Sprintf("R%02d", r.Index), r.RequestId)
+		assert.True(t, r.Success)
+		next++
+	}
+	assert.Equal(t, 50, next)
+
+	seen := make(map[int]bool)
+	upiService.BulkTransferEach(context.Background(), bulkRequests(50), service.BulkOptions{Workers: 8}, func(r service.BulkResult) {
+		assert.False(t, seen[r.Index], "each item is reported once")
+		seen[r.Index] = true
+	})
+	assert.Len(t, seen, 50)
+}
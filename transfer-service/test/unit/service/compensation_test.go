@@ -0,0 +1,114 @@
+// File: test/unit/service/compensation_test.go
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"transfer-service/ledger"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newFailingRepo(from, to *models.Account) *mocks.MockAccountRepository {
+	mockRepo := new(mocks.MockAccountRepository)
+	mockRepo.ExpectAccount(from)
+	mockRepo.ExpectAccount(to)
+	return mockRepo
+}
+
+func TestUPITransferService_Transfer_CompensatesPartialFailure(t *testing.T) {
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	mockRepo := newFailingRepo(fromAccount, toAccount)
+	journal := ledger.NewInMemoryLedger()
+	upiService := service.NewUPITransferService(mockRepo, service.WithLedger(journal))
+
+	// the debit write lands (and later its rollback), the credit write fails
+	mockRepo.ExpectUpdateOf("1", nil).Twice()
+	mockRepo.ExpectUpdateOf("2", errors.New("disk full")).Once()
+
+	err := upiService.Transfer(context.Background(), "1", "2", helpers.INR("300.00"))
+
+	require.Error(t, err)
+	te := err.(*models.TransferError)
+	assert.Equal(t, "PARTIAL_FAILURE_COMPENSATED", te.Code)
+	assert.Equal(t, []string{"1"}, te.Details["compensated"])
+	assert.Equal(t, "disk full", te.Details["cause"])
+	assert.Equal(t, helpers.INR("1000.00"), fromAccount.Balance)
+	assert.Equal(t, helpers.INR("500.00"), toAccount.Balance)
+	mockRepo.AssertExpectations(t)
+
+	postings, _ := journal.Postings(context.Background(), "1")
+	assert.Empty(t, postings, "a rolled back transfer must not be journaled")
+	_, success := upiService.GetStats()
+	assert.Equal(t, int64(0), success)
+}
+
+func TestUPITransferService_Transfer_BothWritesFail(t *testing.T) {
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	mockRepo := newFailingRepo(fromAccount, toAccount)
+	upiService := service.NewUPITransferService(mockRepo)
+
+	mockRepo.On("UpdateAccount", mocks.AnyContext, mock.Anything).Return(errors.New("db down")).Twice()
+
+	err := upiService.Transfer(context.Background(), "1", "2", helpers.INR("300.00"))
+
+	assert.EqualError(t, err, "db down", "nothing was stored, so there is nothing to compensate")
+	assert.Equal(t, helpers.INR("1000.00"), fromAccount.Balance)
+	assert.Equal(t, helpers.INR("500.00"), toAccount.Balance)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUPITransferService_Transfer_CompensationFails(t *testing.T) {
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	mockRepo := newFailingRepo(fromAccount, toAccount)
+	upiService := service.NewUPITransferService(mockRepo)
+
+	mockRepo.ExpectUpdateOf("1", nil).Once()
+	mockRepo.ExpectUpdateOf("2", errors.New("disk full")).Once()
+	mockRepo.ExpectUpdateOf("1", errors.New("still down")).Once()
+
+	err := upiService.Transfer(context.Background(), "1", "2", helpers.INR("300.00"))
+
+	require.Error(t, err)
+	te := err.(*models.TransferError)
+	assert.Equal(t, "COMPENSATION_FAILED", te.Code)
+	assert.Equal(t, []string{"1"}, te.Details["unreconciled"])
+	assert.Equal(t, "still down", te.Details["compensationError"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUPITransferService_Transfer_CompensatesOnTimeout(t *testing.T) {
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	mockRepo := newFailingRepo(fromAccount, toAccount)
+	upiService := service.NewUPITransferService(mockRepo)
+
+	// the credit write never answers, so both sides are rewritten with
+	// their original balances
+	mockRepo.ExpectUpdateBlocked("2").Once()
+	mockRepo.ExpectUpdateOf("2", nil).Once()
+	mockRepo.ExpectUpdateOf("1", nil).Twice()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("300.00"))
+
+	require.Error(t, err)
+	te := err.(*models.TransferError)
+	assert.Equal(t, "PARTIAL_FAILURE_COMPENSATED", te.Code)
+	assert.Contains(t, te.Details["cause"], "TIMEOUT")
+	assert.Equal(t, helpers.INR("1000.00"), fromAccount.GetBalance())
+	assert.Equal(t, helpers.INR("500.00"), toAccount.GetBalance())
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,32 @@
+// File: test/unit/service/dry_run_test.go
+package service_test
+
+import (
+	"context"
+	"testing"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTransfer_MovesNoMoney(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "10.00")
+	upiService := service.NewUPITransferService(repo)
+	req := models.TransferRequest{FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR("10.00")}
+
+	assert.NoError(t, upiService.CheckTransfer(ctx, req))
+	assert.Equal(t, []string{"10.00", "10.00"}, balances(repo, "A00", "A01"))
+
+	req.Amount = helpers.INR("10.01")
+	assert.Equal(t, "INSUFFICIENT_BALANCE", models.ErrorCode(upiService.CheckTransfer(ctx, req)))
+	req.ToAccountId = "A99"
+	assert.Equal(t, "ACCOUNT_NOT_FOUND", models.ErrorCode(upiService.CheckTransfer(ctx, req)))
+	req.ToAccountId = "A00"
+	assert.Equal(t, "SAME_ACCOUNT_TRANSFER", models.ErrorCode(upiService.CheckTransfer(ctx, req)))
+
+	total, _ := upiService.GetStats()
+	assert.Equal(t, int64(0), total, "checks are not transfers")
+}
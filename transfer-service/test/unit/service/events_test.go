@@ -0,0 +1,143 @@
+// File: test/unit/service/events_test.go
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"transfer-service/events"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEventService(repo *bulkRepository) (*service.UPITransferService, *events.MemorySink) {
+	bus := events.NewBus()
+	sink := events.NewMemorySink()
+	bus.RegisterListener(sink)
+	return service.NewUPITransferService(repo, service.WithEventBus(bus)), sink
+}
+
+// published flushes the relay, as its Run would, and returns what reached sink
+func published(t *testing.T, s *service.UPITransferService, sink *events.MemorySink) []events.Event {
+	t.Helper()
+	_, err := s.EventRelay().Flush(context.Background())
+	require.NoError(t, err)
+	return sink.Events()
+}
+
+func eventTypes(evs []events.Event) []events.Type {
+	types := make([]events.Type, len(evs))
+	for i, e := range evs {
+		types[i] = e.EventType()
+	}
+	return types
+}
+
+func TestEvents_CompletedTransfer(t *testing.T) {
+	repo := newBulkRepository(2, "10.00")
+	upiService, sink := newEventService(repo)
+
+	result := upiService.ProcessTransfer(context.Background(), models.TransferRequest{
+		FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR("4.00"), RequestId: "R1",
+	})
+	require.True(t, result.Success)
+
+	got := published(t, upiService, sink)
+	assert.Equal(t, []events.Type{
+		events.TypeTransferInitiated,
+		events.TypeTransferCompleted,
+		events.TypeAccountBalanceChanged,
+		events.TypeAccountBalanceChanged,
+	}, eventTypes(got))
+	for i, e := range got {
+		assert.Equal(t, int64(i+1), e.EventMeta().Sequence)
+	}
+	assert.Equal(t, "R1", got[1].(events.TransferCompleted).Reference)
+	debit := got[2].(events.AccountBalanceChanged)
+	assert.Equal(t, "A00", debit.AccountId)
+	assert.Equal(t, helpers.INR("-4.00"), debit.Delta)
+	assert.Equal(t, helpers.INR("6.00"), debit.Balance)
+	credit := got[3].(events.AccountBalanceChanged)
+	assert.Equal(t, helpers.INR("14.00"), credit.Balance)
+}
+
+func TestEvents_NothingPublishedForRolledBackTransfers(t *testing.T) {
+	testCases := []struct {
+		name  string
+		setup func(repo *bulkRepository)
+		code  string
+	}{
+		{"rejected", func(repo *bulkRepository) {}, "INSUFFICIENT_BALANCE"},
+		{"write failed", func(repo *bulkRepository) {
+			repo.failures["A01"] = models.NewStorageError("update", assert.AnError)
+		}, "PARTIAL_FAILURE_COMPENSATED"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newBulkRepository(2, "10.00")
+			tc.setup(repo)
+			upiService, sink := newEventService(repo)
+			amount := helpers.INR("5.00")
+			if tc.code == "INSUFFICIENT_BALANCE" {
+				amount = helpers.INR("50.00")
+			}
+
+			err := upiService.Transfer(context.Background(), "A00", "A01", amount)
+			require.Equal(t, tc.code, models.ErrorCode(err))
+
+			got := published(t, upiService, sink)
+			assert.Equal(t, []events.Type{events.TypeTransferInitiated, events.TypeTransferFailed}, eventTypes(got))
+			assert.Equal(t, tc.code, got[1].(events.TransferFailed).ErrorCode)
+		})
+	}
+}
+
+func TestEvents_AtomicBatch(t *testing.T) {
+	repo := newBulkRepository(3, "10.00")
+	upiService, sink := newEventService(repo)
+	reqs := bulkRequests(2)
+	reqs[1].Amount = helpers.INR("50.00")
+
+	upiService.BulkTransfer(context.Background(), reqs, service.BulkOptions{Atomic: true})
+	assert.Equal(t, []events.Type{
+		events.TypeTransferInitiated,
+		events.TypeTransferInitiated,
+		events.TypeTransferFailed,
+		events.TypeTransferFailed,
+	}, eventTypes(published(t, upiService, sink)), "an aborted batch completes nothing")
+}
+
+// blockingListener holds up delivery of completions until released
+type blockingListener struct {
+	events.NopListener
+	release chan struct{}
+}
+
+func (l *blockingListener) OnTransferCompleted(events.TransferCompleted) { <-l.release }
+
+func TestEvents_RelayPublishesOffTheTransferPath(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo := newBulkRepository(3, "10.00")
+	bus := events.NewBus()
+	blocking := &blockingListener{release: make(chan struct{})}
+	sink := events.NewMemorySink()
+	bus.RegisterListener(blocking)
+	bus.RegisterListener(sink)
+	upiService := service.NewUPITransferService(repo, service.WithEventBus(bus))
+
+	require.NoError(t, upiService.Transfer(ctx, "A00", "A01", helpers.INR("1.00")))
+	assert.Empty(t, sink.Events(), "transfers only append")
+
+	go upiService.EventRelay().Run(ctx, time.Hour)
+	// the relay is stuck in the listener, yet transfers carry on
+	require.NoError(t, upiService.Transfer(ctx, "A01", "A02", helpers.INR("1.00")))
+	close(blocking.release)
+
+	require.Eventually(t, func() bool { return len(sink.Events()) == 8 }, time.Second, time.Millisecond,
+		"a nudge publishes without waiting for the tick")
+}
@@ -0,0 +1,110 @@
+// File: test/unit/service/fees_test.go
+package service_test
+
+import (
+	"context"
+	"testing"
+	"transfer-service/fees"
+	"transfer-service/ledger"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFeeSchedule() *fees.Schedule {
+	return &fees.Schedule{
+		RevenueAccountId: "FEES",
+		Components: []fees.Component{
+			{Name: "convenience", Currency: "INR", Rule: &fees.FlatFee{Amount: helpers.INR("2.00")}, WaivedFor: []models.AccountType{models.AccountMerchant}},
+			{Name: "commission", Currency: "INR", Rule: &fees.PercentageFee{BasisPoints: 100}},
+		},
+	}
+}
+
+func newFeeService(t *testing.T, accounts ...*models.Account) (*service.UPITransferService, *ledger.InMemoryLedger) {
+	t.Helper()
+	journal := ledger.NewInMemoryLedger()
+	require.NoError(t, ledger.RecordOpeningBalances(context.Background(), journal, accounts))
+	mockRepo := new(mocks.MockAccountRepository)
+	for _, acc := range accounts {
+		mockRepo.ExpectAccount(acc)
+	}
+	mockRepo.ExpectUpdate()
+	return service.NewUPITransferService(mockRepo, service.WithLedger(journal), service.WithFees(newFeeSchedule())), journal
+}
+
+func TestUPITransferService_Transfer_ChargesFees(t *testing.T) {
+	ctx := context.Background()
+	alice := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	bob := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	revenue := helpers.CreateTestAccount("FEES", "Fee revenue", helpers.INR("0.00"))
+	upiService, journal := newFeeService(t, alice, bob, revenue)
+
+	result := upiService.ProcessTransfer(ctx, models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("100.00")})
+	require.NoError(t, result.Error)
+	require.NotNil(t, result.Fees)
+	assert.Equal(t, helpers.INR("3.00"), result.Fees.Total)
+	assert.Equal(t, []models.FeeComponent{
+		{Name: "convenience", Amount: helpers.INR("2.00")},
+		{Name: "commission", Amount: helpers.INR("1.00")},
+	}, result.Fees.Components)
+
+	assert.Equal(t, helpers.INR("897.00"), alice.Balance)
+	assert.Equal(t, helpers.INR("600.00"), bob.Balance, "the recipient receives the full amount")
+	assert.Equal(t, helpers.INR("3.00"), revenue.Balance)
+
+	report, err := upiService.ReconcileAccounts(ctx, []string{"1", "2", "FEES"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.True(t, report.Balanced())
+	postings, _ := journal.Postings(ctx, "FEES")
+	require.Len(t, postings, 1)
+	assert.Equal(t, ledger.Credit, postings[0].Direction)
+}
+
+func TestUPITransferService_Transfer_FeeWaivedForMerchant(t *testing.T) {
+	merchant := helpers.CreateTestAccount("1", "Shop", helpers.INR("1000.00"))
+	merchant.Type = models.AccountMerchant
+	bob := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	revenue := helpers.CreateTestAccount("FEES", "Fee revenue", helpers.INR("0.00"))
+	upiService, _ := newFeeService(t, merchant, bob, revenue)
+
+	result := upiService.ProcessTransfer(context.Background(), models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("100.00")})
+	require.NoError(t, result.Error)
+	assert.Equal(t, helpers.INR("1.00"), result.Fees.Total, "only the commission applies")
+	assert.Equal(t, helpers.INR("899.00"), merchant.Balance)
+}
+
+func TestUPITransferService_Transfer_InsufficientBalanceIncludesFee(t *testing.T) {
+	alice := helpers.CreateTestAccount("1", "Alice", helpers.INR("100.00"))
+	bob := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	revenue := helpers.CreateTestAccount("FEES", "Fee revenue", helpers.INR("0.00"))
+	upiService, _ := newFeeService(t, alice, bob, revenue)
+
+	// the amount alone fits the balance, but not with the 3.00 fee on top
+	err := upiService.Transfer(context.Background(), "1", "2", helpers.INR("99.00"))
+	require.Error(t, err)
+	te := err.(*models.TransferError)
+	assert.Equal(t, "INSUFFICIENT_BALANCE", te.Code)
+	assert.Equal(t, helpers.INR("99.00"), te.Details["amount"])
+	assert.Equal(t, helpers.INR("2.99"), te.Details["fee"])
+	assert.Equal(t, helpers.INR("101.99"), te.Details["required"])
+
+	assert.Equal(t, helpers.INR("100.00"), alice.Balance)
+	assert.Equal(t, helpers.INR("0.00"), revenue.Balance)
+}
+
+func TestUPITransferService_Transfer_RevenueAccountIsNotCharged(t *testing.T) {
+	alice := helpers.CreateTestAccount("1", "Alice", helpers.INR("100.00"))
+	revenue := helpers.CreateTestAccount("FEES", "Fee revenue", helpers.INR("50.00"))
+	upiService, _ := newFeeService(t, alice, revenue)
+
+	result := upiService.ProcessTransfer(context.Background(), models.TransferRequest{FromAccountId: "FEES", ToAccountId: "1", Amount: helpers.INR("50.00")})
+	require.NoError(t, result.Error)
+	assert.Nil(t, result.Fees)
+	assert.Equal(t, helpers.INR("150.00"), alice.Balance)
+}
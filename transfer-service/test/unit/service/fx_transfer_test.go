@@ -0,0 +1,99 @@
+// File: test/unit/service/fx_transfer_test.go
+package service_test
+
+import (
+	"context"
+	"testing"
+	"transfer-service/fx"
+	"transfer-service/ledger"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+func newFXService(t *testing.T, journal ledger.Ledger, opts ...service.Option) (*service.UPITransferService, *models.Account, *models.Account) {
+	t.Helper()
+	usdAccount := &models.Account{ID: "1", Name: "Alice", Balance: usd("100.00"), Currency: "USD"}
+	inrAccount := &models.Account{ID: "2", Name: "Bob", Balance: helpers.INR("500.00"), Currency: "INR"}
+	if journal != nil {
+		require.NoError(t, ledger.RecordOpeningBalances(context.Background(), journal, []*models.Account{usdAccount, inrAccount}))
+	}
+
+	mockRepo := new(mocks.MockAccountRepository)
+	mockRepo.ExpectAccount(usdAccount)
+	mockRepo.ExpectAccount(inrAccount)
+	mockRepo.ExpectUpdate()
+	return service.NewUPITransferService(mockRepo, opts...), usdAccount, inrAccount
+}
+
+func TestUPITransferService_CrossCurrencyTransfer(t *testing.T) {
+	ctx := context.Background()
+	rate, err := fx.ParseRate("USD", "INR", "83.25")
+	require.NoError(t, err)
+	journal := ledger.NewInMemoryLedger()
+	upiService, alice, bob := newFXService(t, journal,
+		service.WithLedger(journal),
+		service.WithFXRateProvider(fx.NewStaticRates(rate)),
+		service.WithFXMarkup(50),
+	)
+
+	result := upiService.ProcessTransfer(ctx, models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: usd("10.00"), RequestId: "FX-1"})
+	require.NoError(t, result.Error)
+	require.NotNil(t, result.Conversion)
+	assert.Equal(t, "83.25", result.Conversion.Rate)
+	assert.Equal(t, usd("10.00"), result.Conversion.Source)
+	assert.Equal(t, usd("0.05"), result.Conversion.Fee, "50bps of 10.00")
+	assert.Equal(t, helpers.INR("828.33"), result.Conversion.Converted, "9.95 * 83.25 = 828.3375, rounded down")
+
+	assert.Equal(t, usd("90.00"), alice.Balance)
+	assert.Equal(t, helpers.INR("1328.33"), bob.Balance)
+
+	fees, err := journal.Balance(ctx, ledger.FXFeeAccount, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, usd("0.05"), fees)
+	report, err := upiService.ReconcileAccounts(ctx, []string{"1", "2"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Checked)
+	assert.True(t, report.Balanced())
+}
+
+func TestUPITransferService_CrossCurrencyTransfer_NoRate(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		opts []service.Option
+	}{
+		{name: "no provider"},
+		{name: "pair not quoted", opts: []service.Option{service.WithFXRateProvider(fx.NewStaticRates())}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upiService, alice, bob := newFXService(t, nil, tc.opts...)
+
+			err := upiService.Transfer(ctx, "1", "2", usd("10.00"))
+			require.Error(t, err)
+			te := err.(*models.TransferError)
+			assert.Equal(t, "FX_RATE_UNAVAILABLE", te.Code)
+			assert.Equal(t, "USD", te.Details["from"])
+			assert.Equal(t, "INR", te.Details["to"])
+			assert.Equal(t, usd("100.00"), alice.Balance)
+			assert.Equal(t, helpers.INR("500.00"), bob.Balance)
+		})
+	}
+}
+
+func TestUPITransferService_CrossCurrencyTransfer_AmountMustBeInSenderCurrency(t *testing.T) {
+	rate, _ := fx.ParseRate("USD", "INR", "83.25")
+	upiService, _, _ := newFXService(t, nil, service.WithFXRateProvider(fx.NewStaticRates(rate)))
+
+	err := upiService.Transfer(context.Background(), "1", "2", helpers.INR("10.00"))
+	assert.Equal(t, "CURRENCY_MISMATCH", models.ErrorCode(err))
+}
@@ -0,0 +1,226 @@
+// File: test/unit/service/holds_test.go
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// holdClock is a clock the test moves forward by hand
+type holdClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func (c *holdClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *holdClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newHoldService(repo *bulkRepository) (*service.UPITransferService, *holdClock) {
+	clock := &holdClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	return service.NewUPITransferService(repo, service.WithClock(clock.Now), service.WithHoldExpiry(time.Hour)), clock
+}
+
+func authorize(t *testing.T, s *service.UPITransferService, amount string) *models.Hold {
+	t.Helper()
+	hold, err := s.Authorize(context.Background(), models.TransferRequest{
+		FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR(amount),
+	})
+	require.NoError(t, err)
+	return hold
+}
+
+func accountBalance(t *testing.T, s *service.UPITransferService, id string) []string {
+	t.Helper()
+	b, err := s.GetAccountBalance(context.Background(), id)
+	require.NoError(t, err)
+	return []string{b.Ledger.Decimal(), b.Held.Decimal(), b.Available.Decimal()}
+}
+
+func TestHolds_AuthorizeReservesAvailableBalance(t *testing.T) {
+	repo := newBulkRepository(2, "100.00")
+	upiService, clock := newHoldService(repo)
+
+	hold := authorize(t, upiService, "60.00")
+	assert.Equal(t, models.HoldAuthorized, hold.Status)
+	assert.Equal(t, clock.Now().Add(time.Hour), hold.ExpiresAt)
+	assert.Equal(t, []string{"100.00", "60.00", "40.00"}, accountBalance(t, upiService, "A00"))
+	assert.Equal(t, []string{"100.00", "0.00", "100.00"}, accountBalance(t, upiService, "A01"), "nothing is credited yet")
+
+	err := upiService.Transfer(context.Background(), "A00", "A01", helpers.INR("50.00"))
+	assert.Equal(t, "INSUFFICIENT_BALANCE", models.ErrorCode(err), "held funds cannot be spent")
+	_, err = upiService.Authorize(context.Background(), models.TransferRequest{
+		FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR("40.01"),
+	})
+	assert.Equal(t, "INSUFFICIENT_BALANCE", models.ErrorCode(err))
+	require.NoError(t, upiService.Transfer(context.Background(), "A00", "A01", helpers.INR("40.00")))
+	assert.Equal(t, []string{"60.00", "60.00", "0.00"}, accountBalance(t, upiService, "A00"))
+}
+
+func TestHolds_Capture(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService, _ := newHoldService(repo)
+	hold := authorize(t, upiService, "60.00")
+
+	result, err := upiService.Capture(ctx, hold.ID)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, []string{"40.00", "0.00", "40.00"}, accountBalance(t, upiService, "A00"))
+	assert.Equal(t, []string{"160.00", "0.00", "160.00"}, accountBalance(t, upiService, "A01"))
+
+	got, err := upiService.GetHold(ctx, hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.HoldCaptured, got.Status)
+	assert.Equal(t, helpers.INR("60.00"), got.Captured)
+
+	_, err = upiService.Capture(ctx, hold.ID)
+	assert.Equal(t, "HOLD_NOT_OPEN", models.ErrorCode(err))
+	_, err = upiService.Void(ctx, hold.ID)
+	assert.Equal(t, "HOLD_NOT_OPEN", models.ErrorCode(err))
+}
+
+func TestHolds_PartialCaptureThenVoid(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService, _ := newHoldService(repo)
+	hold := authorize(t, upiService, "60.00")
+
+	result, err := upiService.CapturePartial(ctx, hold.ID, helpers.INR("25.00"))
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, []string{"75.00", "35.00", "40.00"}, accountBalance(t, upiService, "A00"))
+
+	_, err = upiService.CapturePartial(ctx, hold.ID, helpers.INR("35.01"))
+	assert.Equal(t, "CAPTURE_EXCEEDS_HOLD", models.ErrorCode(err))
+	_, err = upiService.CapturePartial(ctx, hold.ID, helpers.INR("0.00"))
+	assert.Equal(t, "INVALID_AMOUNT", models.ErrorCode(err))
+
+	voided, err := upiService.Void(ctx, hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.HoldVoided, voided.Status)
+	assert.Equal(t, helpers.INR("25.00"), voided.Captured)
+	assert.Equal(t, []string{"75.00", "0.00", "75.00"}, accountBalance(t, upiService, "A00"))
+	assert.Equal(t, []string{"125.00", "0.00", "125.00"}, accountBalance(t, upiService, "A01"))
+}
+
+func TestHolds_Expiry(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService, clock := newHoldService(repo)
+	swept := authorize(t, upiService, "30.00")
+	lapsed := authorize(t, upiService, "20.00")
+
+	clock.Advance(59 * time.Minute)
+	n, err := upiService.ExpireHolds(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	late := authorize(t, upiService, "10.00")
+
+	clock.Advance(time.Minute)
+	_, err = upiService.Capture(ctx, lapsed.ID)
+	assert.Equal(t, "HOLD_EXPIRED", models.ErrorCode(err), "a lapsed hold cannot be captured before the sweep")
+	n, err = upiService.ExpireHolds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	for _, id := range []string{swept.ID, lapsed.ID} {
+		got, err := upiService.GetHold(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, models.HoldExpired, got.Status)
+	}
+	assert.Equal(t, []string{"100.00", "10.00", "90.00"}, accountBalance(t, upiService, "A00"))
+
+	result, err := upiService.Capture(ctx, late.ID)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestHolds_UnknownHold(t *testing.T) {
+	upiService, _ := newHoldService(newBulkRepository(2, "100.00"))
+	_, err := upiService.Capture(context.Background(), "HLD-404")
+	assert.Equal(t, "HOLD_NOT_FOUND", models.ErrorCode(err))
+}
+
+func TestHolds_AtomicBatchRespectsHolds(t *testing.T) {
+	repo := newBulkRepository(3, "10.00")
+	upiService, _ := newHoldService(repo)
+	authorize(t, upiService, "8.00")
+
+	reqs := bulkRequests(2) // A00→A01 and A01→A02
+	reqs[0].Amount = helpers.INR("3.00")
+	results := upiService.BulkTransfer(context.Background(), reqs, service.BulkOptions{Atomic: true})
+	assert.Equal(t, "INSUFFICIENT_BALANCE", models.ErrorCode(results[0].Error))
+	assert.Equal(t, []string{"10.00", "10.00", "10.00"}, balances(repo, "A00", "A01", "A02"))
+}
+
+// TestHolds_AvailableNeverNegative races authorizations, captures, voids and
+// plain transfers against one sender. Whatever interleaving happens, the
+// sender never holds more than it has.
+func TestHolds_AvailableNeverNegative(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService, _ := newHoldService(repo)
+	sender := repo.accounts["A00"]
+
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		negative []string
+	)
+	check := func() {
+		if available := sender.AvailableBalance(); available.IsNegative() {
+			mutex.Lock()
+			negative = append(negative, available.Decimal())
+			mutex.Unlock()
+		}
+	}
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			amount := helpers.INR(fmt.Sprintf("%d.00", 5+i%7))
+			switch i % 4 {
+			case 0:
+				upiService.Transfer(ctx, "A00", "A01", amount)
+			default:
+				hold, err := upiService.Authorize(ctx, models.TransferRequest{FromAccountId: "A00", ToAccountId: "A01", Amount: amount})
+				check()
+				if err != nil {
+					return
+				}
+				if i%2 == 0 {
+					upiService.CapturePartial(ctx, hold.ID, helpers.INR("3.00"))
+				}
+				check()
+				upiService.Void(ctx, hold.ID)
+			}
+			check()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Empty(t, negative)
+	assert.Equal(t, []string{sender.GetBalance().Decimal(), "0.00", sender.GetBalance().Decimal()}, accountBalance(t, upiService, "A00"),
+		"every hold was voided or captured")
+	total, err := sender.GetBalance().Add(repo.accounts["A01"].GetBalance())
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("200.00"), total)
+}
@@ -0,0 +1,109 @@
+// File: test/unit/service/idempotency_test.go
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"transfer-service/idempotency"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdempotentService(t *testing.T, store idempotency.Store) (*service.UPITransferService, *models.Account, *models.Account) {
+	t.Helper()
+	mockRepo := new(mocks.MockAccountRepository)
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	mockRepo.ExpectAccount(fromAccount)
+	mockRepo.ExpectAccount(toAccount)
+	mockRepo.ExpectUpdate()
+	return service.NewUPITransferService(mockRepo, service.WithIdempotencyStore(store)), fromAccount, toAccount
+}
+
+func TestUPITransferService_ProcessTransfer_ReplayReturnsOriginalResult(t *testing.T) {
+	ctx := context.Background()
+	upiService, fromAccount, toAccount := newIdempotentService(t, idempotency.NewInMemoryStore(time.Hour))
+	req := models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("100.00"), RequestId: "REQ-1"}
+
+	first := upiService.ProcessTransfer(ctx, req)
+	require.True(t, first.Success)
+	assert.False(t, first.Replayed)
+
+	second := upiService.ProcessTransfer(ctx, req)
+	assert.True(t, second.Success)
+	assert.True(t, second.Replayed)
+
+	assert.Equal(t, helpers.INR("900.00"), fromAccount.Balance, "a retry must not debit twice")
+	assert.Equal(t, helpers.INR("600.00"), toAccount.Balance)
+	total, success := upiService.GetStats()
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, int64(1), success)
+}
+
+func TestUPITransferService_ProcessTransfer_ReplaysFailures(t *testing.T) {
+	ctx := context.Background()
+	upiService, fromAccount, _ := newIdempotentService(t, idempotency.NewInMemoryStore(time.Hour))
+	req := models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("5000.00"), RequestId: "REQ-1"}
+
+	first := upiService.ProcessTransfer(ctx, req)
+	require.False(t, first.Success)
+
+	fromAccount.UpdateBalance(helpers.INR("10000.00"))
+	second := upiService.ProcessTransfer(ctx, req)
+	assert.False(t, second.Success)
+	assert.True(t, second.Replayed)
+	assert.Equal(t, first.Error, second.Error)
+}
+
+func TestUPITransferService_ProcessTransfer_PayloadMismatch(t *testing.T) {
+	ctx := context.Background()
+	upiService, fromAccount, _ := newIdempotentService(t, idempotency.NewInMemoryStore(time.Hour))
+	req := models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("100.00"), RequestId: "REQ-1"}
+
+	require.True(t, upiService.ProcessTransfer(ctx, req).Success)
+
+	req.Amount = helpers.INR("200.00")
+	result := upiService.ProcessTransfer(ctx, req)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error.Error(), "IDEMPOTENCY_CONFLICT")
+	assert.Equal(t, helpers.INR("900.00"), fromAccount.Balance)
+}
+
+func TestUPITransferService_ProcessTransfer_KeyExpires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := idempotency.NewInMemoryStore(time.Minute, idempotency.WithClock(func() time.Time { return now }))
+	upiService, fromAccount, _ := newIdempotentService(t, store)
+	req := models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("100.00"), RequestId: "REQ-1"}
+
+	require.True(t, upiService.ProcessTransfer(ctx, req).Success)
+	now = now.Add(2 * time.Minute)
+	result := upiService.ProcessTransfer(ctx, req)
+
+	assert.True(t, result.Success)
+	assert.False(t, result.Replayed)
+	assert.Equal(t, helpers.INR("800.00"), fromAccount.Balance)
+}
+
+func TestUPITransferService_BulkTransfer_DeduplicatesRequestIds(t *testing.T) {
+	ctx := context.Background()
+	upiService, fromAccount, _ := newIdempotentService(t, idempotency.NewInMemoryStore(time.Hour))
+	req := models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("100.00"), RequestId: "REQ-1"}
+
+	results := upiService.BulkTransfer(ctx, []models.TransferRequest{req, req, req}, service.BulkOptions{})
+
+	require.Len(t, results, 3)
+	assert.Equal(t, helpers.INR("900.00"), fromAccount.Balance)
+	for _, r := range results {
+		assert.Equal(t, "REQ-1", r.RequestId)
+		if !r.Success {
+			assert.Contains(t, r.Error.Error(), "REQUEST_IN_PROGRESS")
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// File: test/unit/service/ledger_transfer_test.go
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"transfer-service/ledger"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUPITransferService_Transfer_RecordsJournalEntry(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mocks.MockAccountRepository)
+	journal := ledger.NewInMemoryLedger()
+	upiService := service.NewUPITransferService(mockRepo, service.WithLedger(journal))
+
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	require.NoError(t, ledger.RecordOpeningBalances(ctx, journal, []*models.Account{fromAccount, toAccount}))
+
+	mockRepo.ExpectAccount(fromAccount)
+	mockRepo.ExpectAccount(toAccount)
+	mockRepo.ExpectUpdate()
+
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("300.00")))
+	err := upiService.Transfer(ctx, "2", "1", helpers.INR("5000.00"))
+	assert.Contains(t, err.Error(), "INSUFFICIENT_BALANCE")
+
+	postings, err := journal.Postings(ctx, "1")
+	require.NoError(t, err)
+	require.Len(t, postings, 2, "opening balance + one transfer debit; the failed transfer must not post")
+	assert.Equal(t, ledger.Debit, postings[1].Direction)
+	assert.Equal(t, helpers.INR("300.00"), postings[1].Amount)
+
+	report, err := upiService.ReconcileAccounts(ctx, []string{"1", "2"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Checked)
+	assert.True(t, report.Balanced())
+}
+
+// failingLedger stores nothing, as a ledger whose database is down would
+type failingLedger struct {
+	*ledger.InMemoryLedger
+}
+
+func (failingLedger) Record(ctx context.Context, entry ledger.JournalEntry) (*ledger.JournalEntry, error) {
+	return nil, models.NewStorageError("record journal entry", errors.New("ledger down"))
+}
+
+func TestUPITransferService_Transfer_JournalFailureAfterCommitKeepsTransfer(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mocks.MockAccountRepository)
+	journal := failingLedger{ledger.NewInMemoryLedger()}
+	upiService := service.NewUPITransferService(mockRepo, service.WithLedger(journal))
+
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	mockRepo.ExpectAccount(fromAccount)
+	mockRepo.ExpectAccount(toAccount)
+	mockRepo.ExpectUpdate().Twice()
+
+	// the balances are stored before the journal is written, so the
+	// transfer has happened and must be reported as such
+	result := upiService.ProcessTransfer(ctx, models.TransferRequest{
+		FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("300.00"), RequestId: "REQ-JOURNAL",
+	})
+
+	require.NoError(t, result.Error)
+	assert.True(t, result.Success)
+	assert.Equal(t, helpers.INR("700.00"), fromAccount.GetBalance())
+	assert.Equal(t, helpers.INR("800.00"), toAccount.GetBalance())
+	_, success := upiService.GetStats()
+	assert.Equal(t, int64(1), success)
+}
+
+func TestUPITransferService_Reconcile_ChecksAccountsWithoutPostings(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(3, "10.00")
+	repo.accounts["A03"] = helpers.CreateTestAccount("A03", "A03", helpers.INR("0.00"))
+	journal := ledger.NewInMemoryLedger()
+	require.NoError(t, ledger.RecordOpeningBalances(ctx, journal, []*models.Account{repo.accounts["A00"], repo.accounts["A01"]}))
+	upiService := service.NewUPITransferService(repo, service.WithLedger(journal))
+	require.NoError(t, upiService.Transfer(ctx, "A00", "A01", helpers.INR("5.00")))
+
+	report, err := upiService.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Checked, "every stored account is checked, system accounts are not")
+	require.Len(t, report.Discrepancies, 1, "an empty account without postings balances")
+	assert.Equal(t, ledger.Discrepancy{
+		AccountID:     "A02",
+		Balance:       helpers.INR("10.00"),
+		PostedBalance: helpers.INR("0.00"),
+	}, report.Discrepancies[0])
+}
+
+func TestUPITransferService_Reconcile_NeedsAListableStore(t *testing.T) {
+	ctx := context.Background()
+	upiService := service.NewUPITransferService(new(mocks.MockAccountRepository), service.WithLedger(ledger.NewInMemoryLedger()))
+
+	_, err := upiService.Reconcile(ctx)
+	assert.Equal(t, "ACCOUNT_LISTING_NOT_SUPPORTED", models.ErrorCode(err))
+}
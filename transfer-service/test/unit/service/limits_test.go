@@ -0,0 +1,76 @@
+// File: test/unit/service/limits_test.go
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"transfer-service/limits"
+	"transfer-service/models"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUPITransferService_Transfer_LimitExceeded(t *testing.T) {
+	ctx := context.Background()
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	mockRepo := new(mocks.MockAccountRepository)
+	mockRepo.ExpectAccount(fromAccount)
+	mockRepo.ExpectAccount(toAccount)
+	mockRepo.ExpectUpdate()
+
+	engine := limits.NewEngine([]limits.Policy{
+		&limits.DailyAmountPolicy{RuleName: "daily-300", Max: helpers.INR("300.00")},
+	})
+	upiService := service.NewUPITransferService(mockRepo, service.WithLimits(engine))
+
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("200.00")))
+
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("100.01"))
+	require.Error(t, err)
+	te := err.(*models.TransferError)
+	assert.Equal(t, "LIMIT_EXCEEDED", te.Code)
+	assert.Equal(t, "daily-300", te.Details["rule"])
+	assert.Equal(t, helpers.INR("800.00"), fromAccount.Balance, "no funds move when a limit fires")
+
+	// a transfer that fails for another reason must not use up the allowance
+	fromAccount.UpdateBalance(helpers.INR("50.00"))
+	err = upiService.Transfer(ctx, "1", "2", helpers.INR("100.00"))
+	assert.Contains(t, err.Error(), "INSUFFICIENT_BALANCE")
+	fromAccount.UpdateBalance(helpers.INR("800.00"))
+	assert.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("100.00")))
+}
+
+func TestUPITransferService_ProcessTransfer_VelocityLimit(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mocks.MockAccountRepository)
+	mockRepo.ExpectAccount(helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00")))
+	mockRepo.ExpectAccount(helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00")))
+	mockRepo.ExpectUpdate()
+
+	engine := limits.NewEngine([]limits.Policy{
+		&limits.VelocityPolicy{RuleName: "two-per-minute", MaxCount: 2, Window: time.Minute},
+	})
+	upiService := service.NewUPITransferService(mockRepo, service.WithLimits(engine))
+
+	batch := make([]models.TransferRequest, 5)
+	for i := range batch {
+		batch[i] = models.TransferRequest{FromAccountId: "1", ToAccountId: "2", Amount: helpers.INR("1.00")}
+	}
+	succeeded, limited := 0, 0
+	for _, req := range batch {
+		r := upiService.ProcessTransfer(ctx, req)
+		if r.Success {
+			succeeded++
+		} else if models.ErrorCode(r.Error) == "LIMIT_EXCEEDED" {
+			limited++
+		}
+	}
+	assert.Equal(t, 2, succeeded)
+	assert.Equal(t, 3, limited)
+}
@@ -0,0 +1,354 @@
+// File: test/unit/service/reversal_test.go
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"transfer-service/fx"
+	"transfer-service/ledger"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReversalService(repo *bulkRepository, opts ...service.Option) (*service.UPITransferService, *repository.InMemoryTransferRepository) {
+	history := repository.NewInMemoryTransferRepository()
+	opts = append(opts, service.WithTransferRepository(history))
+	return service.NewUPITransferService(repo, opts...), history
+}
+
+func completedTransfer(t *testing.T, s *service.UPITransferService, amount string) string {
+	t.Helper()
+	result := s.ProcessTransfer(context.Background(), models.TransferRequest{
+		FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR(amount), RequestId: "R1",
+	})
+	require.True(t, result.Success)
+	require.NotEmpty(t, result.TransferId)
+	return result.TransferId
+}
+
+func TestReversal_ReverseIsLinkedAndIdempotent(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService, history := newReversalService(repo)
+	transferId := completedTransfer(t, upiService, "40.00")
+
+	result, err := upiService.Reverse(ctx, transferId)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, []string{"100.00", "100.00"}, balances(repo, "A00", "A01"))
+
+	reversal, err := history.GetTransfer(ctx, result.TransferId)
+	require.NoError(t, err)
+	assert.Equal(t, transferId, reversal.ReversalOf)
+	assert.Equal(t, "A01", reversal.FromAccountId)
+	assert.Equal(t, "A00", reversal.ToAccountId)
+	assert.Equal(t, helpers.INR("40.00"), reversal.Amount)
+
+	original, err := history.GetTransfer(ctx, transferId)
+	require.NoError(t, err)
+	assert.Equal(t, result.TransferId, original.ReversalId)
+	assert.Equal(t, []string{result.TransferId}, original.Refunds)
+	assert.Equal(t, helpers.INR("40.00"), original.Refunded)
+
+	again, err := upiService.Reverse(ctx, transferId)
+	require.NoError(t, err)
+	assert.True(t, again.Replayed)
+	assert.Equal(t, result.TransferId, again.TransferId)
+	assert.Equal(t, []string{"100.00", "100.00"}, balances(repo, "A00", "A01"), "a repeated reversal moves nothing")
+
+	_, err = upiService.Refund(ctx, transferId, helpers.INR("1.00"))
+	assert.Equal(t, "TRANSFER_NOT_REVERSIBLE", models.ErrorCode(err))
+	_, err = upiService.Reverse(ctx, result.TransferId)
+	assert.Equal(t, "TRANSFER_NOT_REVERSIBLE", models.ErrorCode(err), "a reversal cannot itself be reversed")
+}
+
+func TestReversal_PartialRefundsNeverExceedOriginal(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService, history := newReversalService(repo)
+	transferId := completedTransfer(t, upiService, "40.00")
+
+	for _, amount := range []string{"10.00", "15.00"} {
+		result, err := upiService.Refund(ctx, transferId, helpers.INR(amount))
+		require.NoError(t, err)
+		require.True(t, result.Success)
+	}
+	assert.Equal(t, []string{"85.00", "115.00"}, balances(repo, "A00", "A01"))
+
+	_, err := upiService.Refund(ctx, transferId, helpers.INR("15.01"))
+	te, ok := err.(*models.TransferError)
+	require.True(t, ok)
+	assert.Equal(t, "REFUND_EXCEEDS_ORIGINAL", te.Code)
+	assert.Equal(t, helpers.INR("15.00"), te.Details["refundable"])
+	_, err = upiService.Refund(ctx, transferId, helpers.INR("-1.00"))
+	assert.Equal(t, "INVALID_AMOUNT", models.ErrorCode(err))
+
+	result, err := upiService.Reverse(ctx, transferId)
+	require.NoError(t, err)
+	require.True(t, result.Success, "a reversal pays back what the refunds left")
+	assert.Equal(t, []string{"100.00", "100.00"}, balances(repo, "A00", "A01"))
+
+	original, err := history.GetTransfer(ctx, transferId)
+	require.NoError(t, err)
+	assert.Len(t, original.Refunds, 3)
+	assert.Equal(t, helpers.INR("40.00"), original.Refunded)
+}
+
+func TestReversal_RespectsRecipientBalance(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(3, "100.00")
+	upiService, history := newReversalService(repo)
+	transferId := completedTransfer(t, upiService, "40.00")
+	require.NoError(t, upiService.Transfer(ctx, "A01", "A02", helpers.INR("120.00")))
+
+	result, err := upiService.Reverse(ctx, transferId)
+	require.NoError(t, err)
+	require.False(t, result.Success)
+	te := result.Error.(*models.TransferError)
+	assert.Equal(t, "REVERSAL_INSUFFICIENT_FUNDS", te.Code)
+	assert.Equal(t, transferId, te.Details["transferId"])
+	assert.Equal(t, "A01", te.Details["accountId"])
+	assert.Equal(t, []string{"60.00", "20.00"}, balances(repo, "A00", "A01"))
+
+	original, err := history.GetTransfer(ctx, transferId)
+	require.NoError(t, err)
+	assert.Empty(t, original.Refunds, "a failed reversal refunds nothing")
+
+	result, err = upiService.Refund(ctx, transferId, helpers.INR("20.00"))
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"80.00", "0.00"}, balances(repo, "A00", "A01"))
+}
+
+func TestReversal_NotReversible(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService, _ := newReversalService(repo)
+
+	failed := upiService.ProcessTransfer(ctx, models.TransferRequest{
+		FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR("500.00"),
+	})
+	require.False(t, failed.Success)
+	_, err := upiService.Reverse(ctx, failed.TransferId)
+	assert.Equal(t, "TRANSFER_NOT_REVERSIBLE", models.ErrorCode(err))
+
+	_, err = upiService.Reverse(ctx, "TXN-404")
+	assert.Equal(t, "TRANSFER_NOT_FOUND", models.ErrorCode(err))
+
+	_, err = service.NewUPITransferService(repo).Reverse(ctx, "TXN-00000001")
+	assert.Equal(t, "HISTORY_NOT_CONFIGURED", models.ErrorCode(err))
+}
+
+func TestReversal_ReturnsTheFee(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	repo.accounts["FEES"] = helpers.CreateTestAccount("FEES", "Fee revenue", helpers.INR("0.00"))
+	upiService, history := newReversalService(repo, service.WithFees(newFeeSchedule()))
+	transferId := completedTransfer(t, upiService, "50.00")
+	assert.Equal(t, []string{"47.50", "150.00", "2.50"}, balances(repo, "A00", "A01", "FEES"))
+
+	result, err := upiService.Reverse(ctx, transferId)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Nil(t, result.Fees, "the reversal itself is free")
+	require.NotNil(t, result.FeesReturned)
+	assert.Equal(t, helpers.INR("2.50"), result.FeesReturned.Total)
+	assert.Equal(t, []string{"100.00", "100.00", "0.00"}, balances(repo, "A00", "A01", "FEES"),
+		"the sender is made whole, fee included")
+
+	reversal, err := history.GetTransfer(ctx, result.TransferId)
+	require.NoError(t, err)
+	assert.Equal(t, result.FeesReturned, reversal.FeesReturned)
+}
+
+func TestReversal_RefundKeepsTheFee(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	repo.accounts["FEES"] = helpers.CreateTestAccount("FEES", "Fee revenue", helpers.INR("0.00"))
+	upiService, _ := newReversalService(repo, service.WithFees(newFeeSchedule()))
+	transferId := completedTransfer(t, upiService, "50.00")
+
+	result, err := upiService.Refund(ctx, transferId, helpers.INR("50.00"))
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Nil(t, result.FeesReturned)
+	assert.Equal(t, []string{"97.50", "100.00", "2.50"}, balances(repo, "A00", "A01", "FEES"))
+}
+
+func newFXReversalService(t *testing.T) (*service.UPITransferService, *ledger.InMemoryLedger, *models.Account, *models.Account, string) {
+	t.Helper()
+	// only USD->INR is quoted, so paying back must not look up INR->USD
+	rate, err := fx.ParseRate("USD", "INR", "83.25")
+	require.NoError(t, err)
+	journal := ledger.NewInMemoryLedger()
+	upiService, alice, bob := newFXService(t, journal,
+		service.WithLedger(journal),
+		service.WithFXRateProvider(fx.NewStaticRates(rate)),
+		service.WithFXMarkup(50),
+		service.WithTransferRepository(repository.NewInMemoryTransferRepository()),
+	)
+	result := upiService.ProcessTransfer(context.Background(), models.TransferRequest{
+		FromAccountId: "1", ToAccountId: "2", Amount: usd("10.00"), RequestId: "FX-1",
+	})
+	require.True(t, result.Success)
+	require.Equal(t, helpers.INR("828.33"), result.Conversion.Converted)
+	return upiService, journal, alice, bob, result.TransferId
+}
+
+func TestReversal_PaysBackTheConvertedSource(t *testing.T) {
+	ctx := context.Background()
+	upiService, journal, alice, bob, transferId := newFXReversalService(t)
+
+	result, err := upiService.Reverse(ctx, transferId)
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+	require.NotNil(t, result.Conversion)
+	assert.Equal(t, helpers.INR("828.33"), result.Conversion.Source)
+	assert.True(t, result.Conversion.Fee.IsZero(), "no markup on the way back")
+	assert.Equal(t, usd("10.00"), result.Conversion.Converted, "the markup is returned with the rest")
+	assert.Equal(t, usd("100.00"), alice.Balance)
+	assert.Equal(t, helpers.INR("500.00"), bob.Balance)
+
+	report, err := upiService.ReconcileAccounts(ctx, []string{"1", "2"})
+	require.NoError(t, err)
+	assert.True(t, report.Balanced())
+	position, err := journal.Balance(ctx, ledger.FXPositionAccount, "INR")
+	require.NoError(t, err)
+	assert.True(t, position.IsZero())
+}
+
+func TestReversal_PartialRefundsAddUpToTheSource(t *testing.T) {
+	ctx := context.Background()
+	upiService, _, alice, bob, transferId := newFXReversalService(t)
+
+	first, err := upiService.Refund(ctx, transferId, helpers.INR("300.00"))
+	require.NoError(t, err)
+	require.True(t, first.Success, "%v", first.Error)
+	assert.Equal(t, usd("3.62"), first.Conversion.Converted, "10.00 * 300.00/828.33, rounded down")
+	assert.Equal(t, usd("93.62"), alice.Balance)
+
+	rest, err := upiService.Refund(ctx, transferId, helpers.INR("528.33"))
+	require.NoError(t, err)
+	require.True(t, rest.Success, "%v", rest.Error)
+	assert.Equal(t, usd("6.38"), rest.Conversion.Converted, "the last refund takes up the rounding")
+	assert.Equal(t, usd("100.00"), alice.Balance)
+	assert.Equal(t, helpers.INR("500.00"), bob.Balance)
+}
+
+func TestReversal_ConcurrentRefunds(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService, history := newReversalService(repo)
+	transferId := completedTransfer(t, upiService, "40.00")
+
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := upiService.Refund(ctx, transferId, helpers.INR("3.00"))
+			if err == nil && result.Success {
+				mutex.Lock()
+				succeeded++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 13, succeeded, "only 13 refunds of 3.00 fit into 40.00")
+	original, err := history.GetTransfer(ctx, transferId)
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("39.00"), original.Refunded)
+	assert.Equal(t, []string{"99.00", "101.00"}, balances(repo, "A00", "A01"))
+}
+
+// flakyHistory fails the listed UpdateTransfer calls, counted from 1
+type flakyHistory struct {
+	*repository.InMemoryTransferRepository
+	failCalls map[int]bool
+	calls     int
+}
+
+func (h *flakyHistory) UpdateTransfer(ctx context.Context, transfer *models.Transfer) error {
+	h.calls++
+	if h.failCalls[h.calls] {
+		return models.NewStorageError("UpdateTransfer", errors.New("disk full"))
+	}
+	return h.InMemoryTransferRepository.UpdateTransfer(ctx, transfer)
+}
+
+func TestReversal_RefundIsReservedBeforeMoneyMoves(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	history := &flakyHistory{InMemoryTransferRepository: repository.NewInMemoryTransferRepository(), failCalls: map[int]bool{1: true, 3: true}}
+	upiService := service.NewUPITransferService(repo, service.WithTransferRepository(history))
+	transferId := completedTransfer(t, upiService, "40.00")
+
+	// reserving fails, so nothing is paid back
+	_, err := upiService.Refund(ctx, transferId, helpers.INR("30.00"))
+	assert.Equal(t, "STORAGE_ERROR", models.ErrorCode(err))
+	assert.Equal(t, []string{"60.00", "140.00"}, balances(repo, "A00", "A01"))
+
+	// the refund is paid but linking it fails; it still counts as refunded
+	result, err := upiService.Refund(ctx, transferId, helpers.INR("30.00"))
+	assert.Equal(t, "STORAGE_ERROR", models.ErrorCode(err))
+	require.True(t, result.Success)
+	assert.Equal(t, []string{"90.00", "110.00"}, balances(repo, "A00", "A01"))
+
+	original, err := history.GetTransfer(ctx, transferId)
+	require.NoError(t, err)
+	assert.Equal(t, helpers.INR("30.00"), original.Refunded)
+	assert.Empty(t, original.Refunds)
+
+	_, err = upiService.Refund(ctx, transferId, helpers.INR("30.00"))
+	assert.Equal(t, "REFUND_EXCEEDS_ORIGINAL", models.ErrorCode(err))
+	result, err = upiService.Reverse(ctx, transferId)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, []string{"100.00", "100.00"}, balances(repo, "A00", "A01"), "the reversal pays back only the rest")
+}
+
+func TestReversal_FailedRefundReleasesReservation(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(3, "100.00")
+	upiService, history := newReversalService(repo)
+	transferId := completedTransfer(t, upiService, "40.00")
+	require.NoError(t, upiService.Transfer(ctx, "A01", "A02", helpers.INR("130.00")))
+
+	result, err := upiService.Refund(ctx, transferId, helpers.INR("20.00"))
+	require.NoError(t, err)
+	require.False(t, result.Success)
+
+	original, err := history.GetTransfer(ctx, transferId)
+	require.NoError(t, err)
+	assert.True(t, original.Refunded.IsZero(), "a refund that moved nothing is released")
+}
+
+func TestTransferHistory_RefusesStaleUpdate(t *testing.T) {
+	ctx := context.Background()
+	history := repository.NewInMemoryTransferRepository()
+	require.NoError(t, history.SaveTransfer(ctx, &models.Transfer{FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR("40.00")}))
+
+	first, err := history.GetTransfer(ctx, "TXN-00000001")
+	require.NoError(t, err)
+	second, err := history.GetTransfer(ctx, "TXN-00000001")
+	require.NoError(t, err)
+
+	first.Refunded = helpers.INR("40.00")
+	require.NoError(t, history.UpdateTransfer(ctx, first))
+	second.Refunded = helpers.INR("40.00")
+	assert.Equal(t, "CONCURRENT_MODIFICATION", models.ErrorCode(history.UpdateTransfer(ctx, second)),
+		"a second process working from the same read cannot refund again")
+}
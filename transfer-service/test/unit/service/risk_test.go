@@ -0,0 +1,168 @@
+// File: test/unit/service/risk_test.go
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"transfer-service/idempotency"
+	"transfer-service/models"
+	"transfer-service/risk"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRiskService(repo *bulkRepository, rules ...risk.Rule) *service.UPITransferService {
+	return service.NewUPITransferService(repo,
+		service.WithRiskEvaluator(risk.NewEngine(rules)),
+		service.WithIdempotencyStore(idempotency.NewInMemoryStore(time.Hour)),
+	)
+}
+
+func riskRequest(from, to, amount, requestId string) models.TransferRequest {
+	return models.TransferRequest{FromAccountId: from, ToAccountId: to, Amount: helpers.INR(amount), RequestId: requestId}
+}
+
+func TestRisk_DeniedTransferMovesNothing(t *testing.T) {
+	repo := newBulkRepository(2, "100.00")
+	upiService := newRiskService(repo, &risk.NewPayeeRule{RuleName: "no-strangers", Decision: risk.DecisionDeny})
+
+	result := upiService.ProcessTransfer(context.Background(), riskRequest("A00", "A01", "10.00", "R1"))
+	require.False(t, result.Success)
+	te := result.Error.(*models.TransferError)
+	assert.Equal(t, "RISK_DENIED", te.Code)
+	assert.Equal(t, []string{"no-strangers"}, te.Details["rules"])
+	assert.Equal(t, []string{"100.00", "100.00"}, balances(repo, "A00", "A01"))
+	assert.Empty(t, upiService.PendingReviews())
+}
+
+func TestRisk_ValidationRunsBeforeScreening(t *testing.T) {
+	repo := newBulkRepository(2, "100.00")
+	upiService := newRiskService(repo, &risk.NewPayeeRule{RuleName: "no-strangers", Decision: risk.DecisionDeny})
+
+	err := upiService.Transfer(context.Background(), "A00", "A00", helpers.INR("10.00"))
+	assert.Equal(t, "SAME_ACCOUNT_TRANSFER", models.ErrorCode(err))
+}
+
+func TestRisk_HeldTransferRunsOnApproval(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService := newRiskService(repo, &risk.NewPayeeRule{RuleName: "first-payment"})
+
+	held := upiService.ProcessTransfer(ctx, riskRequest("A00", "A01", "10.00", "R1"))
+	assert.Equal(t, "RISK_REVIEW_REQUIRED", models.ErrorCode(held.Error))
+	reviewId := held.Error.(*models.TransferError).Details["reviewId"].(string)
+	assert.Equal(t, []string{"100.00", "100.00"}, balances(repo, "A00", "A01"), "nothing moves while held")
+
+	pending := upiService.PendingReviews()
+	require.Len(t, pending, 1)
+	assert.Equal(t, reviewId, pending[0].ID)
+	assert.Equal(t, "R1", pending[0].Request.RequestId)
+
+	retry := upiService.ProcessTransfer(ctx, riskRequest("A00", "A01", "10.00", "R1"))
+	assert.True(t, retry.Replayed, "a retry does not queue a second review")
+	assert.Len(t, upiService.PendingReviews(), 1)
+
+	result, err := upiService.ApproveReview(ctx, reviewId)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"90.00", "110.00"}, balances(repo, "A00", "A01"))
+
+	review, err := upiService.GetReview(reviewId)
+	require.NoError(t, err)
+	assert.Equal(t, risk.ReviewApproved, review.Status)
+	assert.Empty(t, review.ErrorCode)
+	assert.Empty(t, upiService.PendingReviews())
+
+	_, err = upiService.ApproveReview(ctx, reviewId)
+	assert.Equal(t, "INVALID_REVIEW_STATE", models.ErrorCode(err), "approving twice must not pay twice")
+	assert.Equal(t, []string{"90.00", "110.00"}, balances(repo, "A00", "A01"))
+
+	replay := upiService.ProcessTransfer(ctx, riskRequest("A00", "A01", "10.00", "R1"))
+	assert.True(t, replay.Replayed)
+	assert.True(t, replay.Success, "the RequestId now reports the approved outcome")
+
+	// the approved transfer is history now, so the payee is no longer new
+	next := upiService.ProcessTransfer(ctx, riskRequest("A00", "A01", "10.00", "R2"))
+	assert.True(t, next.Success)
+}
+
+func TestRisk_ApprovedTransferStillChecksBalance(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(3, "100.00")
+	upiService := newRiskService(repo, &risk.NewPayeeRule{RuleName: "first-payment", MinAmount: helpers.INR("50.00")})
+
+	held := upiService.ProcessTransfer(ctx, riskRequest("A00", "A01", "80.00", "R1"))
+	require.Equal(t, "RISK_REVIEW_REQUIRED", models.ErrorCode(held.Error))
+	reviewId := upiService.PendingReviews()[0].ID
+	require.NoError(t, upiService.Transfer(ctx, "A00", "A02", helpers.INR("40.00")))
+
+	result, err := upiService.ApproveReview(ctx, reviewId)
+	require.NoError(t, err)
+	assert.Equal(t, "INSUFFICIENT_BALANCE", models.ErrorCode(result.Error))
+	review, err := upiService.GetReview(reviewId)
+	require.NoError(t, err)
+	assert.Equal(t, risk.ReviewApproved, review.Status)
+	assert.Equal(t, "INSUFFICIENT_BALANCE", review.ErrorCode)
+}
+
+func TestRisk_RejectedTransferNeverRuns(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	upiService := newRiskService(repo, &risk.NewPayeeRule{RuleName: "first-payment"})
+
+	held := upiService.ProcessTransfer(ctx, riskRequest("A00", "A01", "10.00", "R1"))
+	reviewId := held.Error.(*models.TransferError).Details["reviewId"].(string)
+
+	review, err := upiService.RejectReview(ctx, reviewId, "customer did not confirm")
+	require.NoError(t, err)
+	assert.Equal(t, risk.ReviewRejected, review.Status)
+	assert.Equal(t, "customer did not confirm", review.Note)
+
+	_, err = upiService.ApproveReview(ctx, reviewId)
+	assert.Equal(t, "INVALID_REVIEW_STATE", models.ErrorCode(err))
+	assert.Equal(t, []string{"100.00", "100.00"}, balances(repo, "A00", "A01"))
+
+	replay := upiService.ProcessTransfer(ctx, riskRequest("A00", "A01", "10.00", "R1"))
+	assert.Equal(t, "RISK_REJECTED", models.ErrorCode(replay.Error))
+
+	_, err = upiService.RejectReview(ctx, "RV-999999", "")
+	assert.Equal(t, "REVIEW_NOT_FOUND", models.ErrorCode(err))
+}
+
+func TestRisk_AtomicBatchFailsInsteadOfHolding(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(3, "100.00")
+	upiService := newRiskService(repo, &risk.RoundTripRule{RuleName: "boomerang", Window: time.Hour})
+	require.NoError(t, upiService.Transfer(ctx, "A01", "A00", helpers.INR("5.00")))
+
+	results := upiService.BulkTransfer(ctx, []models.TransferRequest{
+		riskRequest("A01", "A02", "10.00", "B1"),
+		riskRequest("A00", "A01", "10.00", "B2"),
+	}, atomicOpts)
+
+	assert.Equal(t, "BATCH_ABORTED", models.ErrorCode(results[0].Error))
+	assert.Equal(t, "RISK_REVIEW_REQUIRED", models.ErrorCode(results[1].Error))
+	assert.NotContains(t, results[1].Error.(*models.TransferError).Details, "reviewId")
+	assert.Empty(t, upiService.PendingReviews())
+	assert.Equal(t, []string{"105.00", "95.00", "100.00"}, balances(repo, "A00", "A01", "A02"))
+}
+
+func TestRisk_CheckTransferReportsWithoutHolding(t *testing.T) {
+	repo := newBulkRepository(2, "100.00")
+	upiService := newRiskService(repo, &risk.NewPayeeRule{RuleName: "first-payment"})
+
+	err := upiService.CheckTransfer(context.Background(), riskRequest("A00", "A01", "10.00", ""))
+	assert.Equal(t, "RISK_REVIEW_REQUIRED", models.ErrorCode(err))
+	assert.Empty(t, upiService.PendingReviews())
+}
+
+func TestRisk_ReviewsWithoutEvaluator(t *testing.T) {
+	upiService := service.NewUPITransferService(newBulkRepository(2, "100.00"))
+	assert.Empty(t, upiService.PendingReviews())
+	_, err := upiService.ApproveReview(context.Background(), "RV-000001")
+	assert.Equal(t, "REVIEW_NOT_FOUND", models.ErrorCode(err))
+}
@@ -0,0 +1,208 @@
+// File: test/unit/service/statement_test.go
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+	"transfer-service/models"
+	"transfer-service/repository"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// steppingClock advances by one minute every time it is read
+type steppingClock struct{ now time.Time }
+
+func (c *steppingClock) Now() time.Time {
+	c.now = c.now.Add(time.Minute)
+	return c.now
+}
+
+func newHistoryService(t *testing.T, clock *steppingClock) *service.UPITransferService {
+	t.Helper()
+	mockRepo := new(mocks.MockAccountRepository)
+	mockRepo.ExpectAccount(helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00")))
+	mockRepo.ExpectAccount(helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00")))
+	mockRepo.ExpectAccount(helpers.CreateTestAccount("3", "Charlie", helpers.INR("750.00")))
+	mockRepo.ExpectUpdate()
+	return service.NewUPITransferService(mockRepo,
+		service.WithTransferRepository(repository.NewInMemoryTransferRepository()),
+		service.WithClock(clock.Now),
+	)
+}
+
+func TestUPITransferService_RecordsHistory(t *testing.T) {
+	ctx := context.Background()
+	clock := &steppingClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	upiService := newHistoryService(t, clock)
+
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("10.00")))
+	require.Error(t, upiService.Transfer(ctx, "2", "1", helpers.INR("9999.00")))
+	results := upiService.BulkTransfer(ctx, []models.TransferRequest{
+		{FromAccountId: "3", ToAccountId: "1", Amount: helpers.INR("5.00"), RequestId: "B-1"},
+	}, service.BulkOptions{})
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].TransferId)
+
+	statement, err := upiService.GetStatement(ctx, service.StatementQuery{AccountId: "1"})
+	require.NoError(t, err)
+	require.Len(t, statement.Lines, 3)
+	assert.Empty(t, statement.NextCursor)
+
+	first := statement.Lines[0]
+	assert.Equal(t, "DEBIT", first.Direction)
+	assert.Equal(t, "2", first.Counterparty)
+	assert.Equal(t, models.TransferCompleted, first.Status)
+	assert.True(t, first.CompletedAt.After(first.CreatedAt))
+
+	failed := statement.Lines[1]
+	assert.Equal(t, "CREDIT", failed.Direction)
+	assert.Equal(t, models.TransferFailed, failed.Status)
+	assert.Equal(t, "INSUFFICIENT_BALANCE", failed.ErrorCode)
+
+	assert.Equal(t, "B-1", statement.Lines[2].RequestId)
+	assert.Equal(t, results[0].TransferId, statement.Lines[2].TransferId)
+}
+
+func TestUPITransferService_StatementPaginationAndRange(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := &steppingClock{now: start}
+	upiService := newHistoryService(t, clock)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("1.00")))
+	}
+
+	var seen []string
+	q := service.StatementQuery{AccountId: "2", Limit: 2}
+	for {
+		page, err := upiService.GetStatement(ctx, q)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Lines), 2)
+		for _, l := range page.Lines {
+			seen = append(seen, l.TransferId)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	assert.Len(t, seen, 5)
+
+	// each transfer reads the clock twice, so the third starts at start+5m
+	ranged, err := upiService.GetStatement(ctx, service.StatementQuery{
+		AccountId: "2",
+		From:      start.Add(5 * time.Minute),
+		To:        start.Add(9 * time.Minute),
+	})
+	require.NoError(t, err)
+	require.Len(t, ranged.Lines, 2)
+	assert.Equal(t, seen[2], ranged.Lines[0].TransferId)
+	assert.Equal(t, seen[3], ranged.Lines[1].TransferId)
+
+	_, err = upiService.GetStatement(ctx, service.StatementQuery{AccountId: "2", Cursor: "bogus!"})
+	assert.Contains(t, err.Error(), "INVALID_CURSOR")
+}
+
+func TestUPITransferService_ExportStatement(t *testing.T) {
+	ctx := context.Background()
+	clock := &steppingClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	upiService := newHistoryService(t, clock)
+
+	require.NoError(t, upiService.Transfer(ctx, "1", "2", helpers.INR("10.50")))
+	require.NoError(t, upiService.Transfer(ctx, "2", "1", helpers.INR("0.25")))
+	q := service.StatementQuery{AccountId: "1", Limit: 1}
+
+	var csvOut bytes.Buffer
+	require.NoError(t, upiService.ExportStatement(ctx, q, service.ExportCSV, &csvOut))
+	rows, err := csv.NewReader(&csvOut).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3, "header plus every page")
+	assert.Equal(t, "transfer_id", rows[0][0])
+	assert.Equal(t, []string{"DEBIT", "2", "10.50", "", "INR", "COMPLETED", ""}, rows[1][4:], "no fee was charged")
+	assert.Equal(t, "CREDIT", rows[2][4])
+
+	var jsonOut bytes.Buffer
+	require.NoError(t, upiService.ExportStatement(ctx, q, service.ExportJSON, &jsonOut))
+	var decoded service.Statement
+	require.NoError(t, json.Unmarshal(jsonOut.Bytes(), &decoded))
+	require.Len(t, decoded.Lines, 2)
+	assert.Equal(t, helpers.INR("0.25"), decoded.Lines[1].Amount)
+
+	err = upiService.ExportStatement(ctx, q, "xml", &jsonOut)
+	assert.Contains(t, err.Error(), "UNSUPPORTED_FORMAT")
+}
+
+// statementNet adds up how the completed lines moved the account's balance
+func statementNet(t *testing.T, lines []service.StatementLine) models.Money {
+	t.Helper()
+	net := models.Zero("INR")
+	for _, l := range lines {
+		if l.Status != models.TransferCompleted {
+			continue
+		}
+		moved := l.Amount
+		if l.Fee != nil {
+			var err error
+			moved, err = moved.Add(*l.Fee)
+			require.NoError(t, err)
+		}
+		if l.Direction == "DEBIT" {
+			moved, _ = moved.Neg()
+		}
+		var err error
+		net, err = net.Add(moved)
+		require.NoError(t, err)
+	}
+	return net
+}
+
+func TestUPITransferService_StatementReconcilesWithFees(t *testing.T) {
+	ctx := context.Background()
+	repo := newBulkRepository(2, "100.00")
+	repo.accounts["FEES"] = helpers.CreateTestAccount("FEES", "Fee revenue", helpers.INR("0.00"))
+	upiService, _ := newReversalService(repo, service.WithFees(newFeeSchedule()))
+
+	first := completedTransfer(t, upiService, "50.00")
+	back := upiService.ProcessTransfer(ctx, models.TransferRequest{FromAccountId: "A01", ToAccountId: "A00", Amount: helpers.INR("20.00")})
+	require.True(t, back.Success)
+	failed := upiService.ProcessTransfer(ctx, models.TransferRequest{FromAccountId: "A00", ToAccountId: "A01", Amount: helpers.INR("500.00")})
+	require.False(t, failed.Success)
+	reversal, err := upiService.Reverse(ctx, first)
+	require.NoError(t, err)
+	require.True(t, reversal.Success)
+	require.Equal(t, []string{"120.00", "77.80", "2.20"}, balances(repo, "A00", "A01", "FEES"))
+
+	opening := map[string]string{"A00": "100.00", "A01": "100.00", "FEES": "0.00"}
+	for id, open := range opening {
+		statement, err := upiService.GetStatement(ctx, service.StatementQuery{AccountId: id})
+		require.NoError(t, err)
+		change, err := repo.accounts[id].GetBalance().Sub(helpers.INR(open))
+		require.NoError(t, err)
+		assert.Equal(t, change, statementNet(t, statement.Lines), "statement of %s", id)
+	}
+
+	statement, err := upiService.GetStatement(ctx, service.StatementQuery{AccountId: "FEES"})
+	require.NoError(t, err)
+	require.Len(t, statement.Lines, 3, "both fees and the one returned")
+	assert.Equal(t, "CREDIT", statement.Lines[0].Direction)
+	assert.Equal(t, "A00", statement.Lines[0].Counterparty)
+	assert.Equal(t, helpers.INR("2.50"), statement.Lines[0].Amount)
+	assert.Equal(t, "DEBIT", statement.Lines[2].Direction)
+	assert.Equal(t, "A00", statement.Lines[2].Counterparty)
+
+	var csvOut bytes.Buffer
+	require.NoError(t, upiService.ExportStatement(ctx, service.StatementQuery{AccountId: "A00"}, service.ExportCSV, &csvOut))
+	rows, err := csv.NewReader(&csvOut).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DEBIT", "A01", "50.00", "2.50", "INR", "COMPLETED", ""}, rows[1][4:])
+}
@@ -0,0 +1,285 @@
+// File: test/unit/service/transport_test.go
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transfer-service/idempotency"
+	"transfer-service/models"
+	"transfer-service/risk"
+	"transfer-service/service"
+	"transfer-service/test/helpers"
+	"transfer-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mockRepo := new(mocks.MockAccountRepository)
+	mockRepo.ExpectAccount(helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00")))
+	mockRepo.ExpectAccount(helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00")))
+	mockRepo.ExpectAccountError("404", models.NewAccountNotFoundError("404"))
+	mockRepo.ExpectUpdate()
+
+	svc := service.NewUPITransferService(mockRepo, service.WithIdempotencyStore(idempotency.NewInMemoryStore(time.Hour)))
+	handler := service.NewHTTPHandler(
+		service.MakeTransferEndpoint(svc),
+		service.MakeBalanceEndpoint(svc),
+		service.MakeBulkTransferEndpoint(svc),
+		service.MakeStatsEndpoint(svc),
+	)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func doJSON(t *testing.T, method, url, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+func TestHTTP_TransferAndBalance(t *testing.T) {
+	srv := newTestServer(t)
+
+	var transfer service.TransferResponse
+	status := doJSON(t, "POST", srv.URL+"/transfers",
+		`{"fromAccountId":"1","toAccountId":"2","amount":{"amount":"300.00","currency":"INR"},"requestId":"REQ-1"}`, &transfer)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, transfer.Success)
+	assert.Equal(t, "REQ-1", transfer.RequestId)
+
+	var balance service.BalanceResponse
+	status = doJSON(t, "GET", srv.URL+"/accounts/1/balance", "", &balance)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, helpers.INR("700.00"), balance.Balance)
+
+	var stats service.StatsResponse
+	status = doJSON(t, "GET", srv.URL+"/stats", "", &stats)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, service.StatsResponse{Total: 1, Success: 1}, stats)
+}
+
+func TestHTTP_ErrorMapping(t *testing.T) {
+	srv := newTestServer(t)
+
+	testCases := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"malformed json", `{"fromAccountId":`, http.StatusBadRequest, "MALFORMED_REQUEST"},
+		{"unknown field", `{"from":"1"}`, http.StatusBadRequest, "MALFORMED_REQUEST"},
+		{"missing fields", `{"fromAccountId":"1"}`, http.StatusBadRequest, "INVALID_REQUEST"},
+		{"too precise", `{"fromAccountId":"1","toAccountId":"2","amount":{"amount":"1.001","currency":"INR"}}`, http.StatusBadRequest, "PRECISION_LOSS"},
+		{"negative", `{"fromAccountId":"1","toAccountId":"2","amount":{"amount":"-1","currency":"INR"}}`, http.StatusBadRequest, "INVALID_AMOUNT"},
+		{"insufficient", `{"fromAccountId":"2","toAccountId":"1","amount":{"amount":"5000","currency":"INR"}}`, http.StatusUnprocessableEntity, "INSUFFICIENT_BALANCE"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var errResp service.ErrorResponse
+			status := doJSON(t, "POST", srv.URL+"/transfers", tc.body, &errResp)
+			assert.Equal(t, tc.status, status)
+			assert.Equal(t, tc.code, errResp.Code)
+		})
+	}
+
+	var errResp service.ErrorResponse
+	status := doJSON(t, "POST", srv.URL+"/transfers",
+		`{"fromAccountId":"2","toAccountId":"1","amount":{"amount":"5000","currency":"INR"}}`, &errResp)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "2", errResp.Details["accountId"])
+	assert.Equal(t, map[string]interface{}{"amount": "500.00", "currency": "INR"}, errResp.Details["balance"])
+}
+
+func TestHTTP_IdempotencyConflict(t *testing.T) {
+	srv := newTestServer(t)
+	body := `{"fromAccountId":"1","toAccountId":"2","amount":{"amount":"%s","currency":"INR"},"requestId":"REQ-9"}`
+
+	status := doJSON(t, "POST", srv.URL+"/transfers", strings.Replace(body, "%s", "10", 1), nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	var errResp service.ErrorResponse
+	status = doJSON(t, "POST", srv.URL+"/transfers", strings.Replace(body, "%s", "11", 1), &errResp)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "IDEMPOTENCY_CONFLICT", errResp.Code)
+	assert.Equal(t, "REQ-9", errResp.Details["requestId"])
+}
+
+func TestHTTP_BulkTransfer(t *testing.T) {
+	srv := newTestServer(t)
+
+	var resp service.BulkTransferResponse
+	status := doJSON(t, "POST", srv.URL+"/transfers/bulk", `{"transfers":[
+		{"fromAccountId":"1","toAccountId":"2","amount":{"amount":"10","currency":"INR"},"requestId":"A"},
+		{"fromAccountId":"2","toAccountId":"1","amount":{"amount":"99999","currency":"INR"},"requestId":"B"}
+	]}`, &resp)
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 1, resp.Succeeded)
+	assert.Equal(t, 1, resp.Failed)
+	for _, item := range resp.Results {
+		if item.RequestId == "B" {
+			require.NotNil(t, item.Error)
+			assert.Equal(t, "INSUFFICIENT_BALANCE", item.Error.Code)
+		}
+	}
+
+	var errResp service.ErrorResponse
+	status = doJSON(t, "POST", srv.URL+"/transfers/bulk", `{"transfers":[{"fromAccountId":"1"}]}`, &errResp)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, float64(0), errResp.Details["index"])
+}
+
+func TestHTTP_BulkTransferOptions(t *testing.T) {
+	srv := newTestServer(t)
+
+	var resp service.BulkTransferResponse
+	status := doJSON(t, "POST", srv.URL+"/transfers/bulk", `{"transfers":[
+		{"fromAccountId":"2","toAccountId":"1","amount":{"amount":"99999","currency":"INR"},"requestId":"A"},
+		{"fromAccountId":"1","toAccountId":"2","amount":{"amount":"10","currency":"INR"},"requestId":"B"}
+	],"options":{"workers":1,"stopOnFirstError":true,"itemTimeout":"1s"}}`, &resp)
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "A", resp.Results[0].RequestId, "results come back in input order")
+	assert.Equal(t, "BULK_ABORTED", resp.Results[1].Error.Code)
+
+	resp = service.BulkTransferResponse{}
+	status = doJSON(t, "POST", srv.URL+"/transfers/bulk", `{"transfers":[
+		{"fromAccountId":"1","toAccountId":"2","amount":{"amount":"10","currency":"INR"},"requestId":"C"},
+		{"fromAccountId":"2","toAccountId":"1","amount":{"amount":"99999","currency":"INR"},"requestId":"D"}
+	],"options":{"atomic":true}}`, &resp)
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "BATCH_ABORTED", resp.Results[0].Error.Code)
+	assert.Equal(t, "D", resp.Results[0].Error.Details["abortedByRequestId"])
+
+	var errResp service.ErrorResponse
+	status = doJSON(t, "POST", srv.URL+"/transfers/bulk", `{"transfers":[
+		{"fromAccountId":"1","toAccountId":"2","amount":{"amount":"10","currency":"INR"}}
+	],"options":{"itemTimeout":"soon"}}`, &errResp)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "INVALID_REQUEST", errResp.Code)
+}
+
+func TestHTTP_UnknownAccount(t *testing.T) {
+	srv := newTestServer(t)
+
+	var errResp service.ErrorResponse
+	status := doJSON(t, "GET", srv.URL+"/accounts/404/balance", "", &errResp)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "ACCOUNT_NOT_FOUND", errResp.Code)
+	assert.Equal(t, "404", errResp.Details["accountId"])
+}
+
+func newMockServer(t *testing.T) (*httptest.Server, *mocks.MockTransferService) {
+	t.Helper()
+	svc := new(mocks.MockTransferService)
+	handler := service.NewHTTPHandler(
+		service.MakeTransferEndpoint(svc),
+		service.MakeBalanceEndpoint(svc),
+		service.MakeBulkTransferEndpoint(svc),
+		service.MakeStatsEndpoint(svc),
+	)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv, svc
+}
+
+func TestHTTP_ContextErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"deadline", models.WrapContextError(context.DeadlineExceeded), http.StatusGatewayTimeout, "TIMEOUT"},
+		{"cancelled", models.WrapContextError(context.Canceled), http.StatusServiceUnavailable, "CANCELLED"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, svc := newMockServer(t)
+			svc.ExpectProcessTransfer(models.TransferResult{RequestId: "REQ-1", Error: tc.err})
+
+			var errResp service.ErrorResponse
+			status := doJSON(t, "POST", srv.URL+"/transfers",
+				`{"fromAccountId":"1","toAccountId":"2","amount":{"amount":"1.00","currency":"INR"},"requestId":"REQ-1"}`, &errResp)
+			assert.Equal(t, tc.status, status)
+			assert.Equal(t, tc.code, errResp.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHTTP_BalanceReportsHeldAndAvailable(t *testing.T) {
+	srv, svc := newMockServer(t)
+	svc.On("GetAccountBalance", mocks.AnyContext, "1").Return(models.AccountBalance{
+		Ledger:    helpers.INR("100.00"),
+		Held:      helpers.INR("30.00"),
+		Available: helpers.INR("70.00"),
+	}, nil)
+
+	var balance service.BalanceResponse
+	status := doJSON(t, "GET", srv.URL+"/accounts/1/balance", "", &balance)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, service.BalanceResponse{
+		AccountId: "1",
+		Balance:   helpers.INR("100.00"),
+		Held:      helpers.INR("30.00"),
+		Available: helpers.INR("70.00"),
+	}, balance)
+}
+
+func TestHTTP_HeldTransferIsAccepted(t *testing.T) {
+	repo := newBulkRepository(2, "100.00")
+	upiService := newRiskService(repo, &risk.NewPayeeRule{RuleName: "first-payment"})
+	srv := httptest.NewServer(service.NewHTTPHandler(
+		service.MakeTransferEndpoint(upiService),
+		service.MakeBalanceEndpoint(upiService),
+		service.MakeBulkTransferEndpoint(upiService),
+		service.MakeStatsEndpoint(upiService),
+	))
+	t.Cleanup(srv.Close)
+	body := `{"fromAccountId":"A00","toAccountId":"A01","amount":{"amount":"10.00","currency":"INR"},"requestId":"REQ-R"}`
+
+	var held service.TransferResponse
+	status := doJSON(t, "POST", srv.URL+"/transfers", body, &held)
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.False(t, held.Success)
+	assert.Equal(t, "REQ-R", held.RequestId)
+	require.NotNil(t, held.Review)
+	assert.Equal(t, upiService.PendingReviews()[0].ID, held.Review.ReviewId)
+	assert.Equal(t, risk.ReviewPending, held.Review.Status)
+	assert.Equal(t, []string{"first-payment"}, held.Review.Rules)
+
+	var retried service.TransferResponse
+	status = doJSON(t, "POST", srv.URL+"/transfers", body, &retried)
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.True(t, retried.Replayed)
+	assert.Equal(t, held.Review.ReviewId, retried.Review.ReviewId)
+
+	_, err := upiService.ApproveReview(context.Background(), held.Review.ReviewId)
+	require.NoError(t, err)
+	var done service.TransferResponse
+	status = doJSON(t, "POST", srv.URL+"/transfers", body, &done)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, done.Success)
+	assert.Nil(t, done.Review)
+}
@@ -2,7 +2,9 @@
 package service_test
 
 import (
+	"context"
 	"testing"
+	"time"
 	"transfer-service/models"
 	"transfer-service/service"
 	"transfer-service/test/helpers"
@@ -17,45 +19,82 @@ func TestUPITransferService_Transfer_Success(t *testing.T) {
 	mockRepo := new(mocks.MockAccountRepository)
 	upiService := service.NewUPITransferService(mockRepo)
 
-	fromAccount := helpers.CreateTestAccount("1", "Alice", 1000.00)
-	toAccount := helpers.CreateTestAccount("2", "Bob", 500.00)
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
 
-	mockRepo.On("GetAccountById", "1").Return(fromAccount, nil)
-	mockRepo.On("GetAccountById", "2").Return(toAccount, nil)
-	mockRepo.On("UpdateAccount", mock.AnythingOfType("*models.Account")).Return(nil).Twice()
+	mockRepo.ExpectAccount(fromAccount)
+	mockRepo.ExpectAccount(toAccount)
+	mockRepo.ExpectUpdate().Twice()
 
-	err := upiService.Transfer("1", "2", 300.00)
+	err := upiService.Transfer(context.Background(), "1", "2", helpers.INR("300.00"))
 
 	assert.NoError(t, err)
-	assert.Equal(t, 700.00, fromAccount.Balance)
-	assert.Equal(t, 800.00, toAccount.Balance)
+	assert.Equal(t, helpers.INR("700.00"), fromAccount.Balance)
+	assert.Equal(t, helpers.INR("800.00"), toAccount.Balance)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUPITransferService_Transfer_RepeatedSmallAmountsDoNotDrift(t *testing.T) {
+	mockRepo := new(mocks.MockAccountRepository)
+	upiService := service.NewUPITransferService(mockRepo)
+
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("0.00"))
+
+	mockRepo.ExpectAccount(fromAccount)
+	mockRepo.ExpectAccount(toAccount)
+	mockRepo.ExpectUpdate()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, upiService.Transfer(context.Background(), "1", "2", helpers.INR("0.10")))
+	}
+
+	assert.True(t, fromAccount.Balance.IsZero())
+	assert.Equal(t, helpers.INR("1.00"), toAccount.Balance)
+}
+
 func TestUPITransferService_Transfer_InsufficientBalance(t *testing.T) {
 	mockRepo := new(mocks.MockAccountRepository)
 	upiService := service.NewUPITransferService(mockRepo)
 
-	fromAccount := helpers.CreateTestAccount("1", "Alice", 100.00)
-	toAccount := helpers.CreateTestAccount("2", "Bob", 500.00)
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("100.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
 
-	mockRepo.On("GetAccountById", "1").Return(fromAccount, nil)
-	mockRepo.On("GetAccountById", "2").Return(toAccount, nil)
+	mockRepo.ExpectAccount(fromAccount)
+	mockRepo.ExpectAccount(toAccount)
 
-	err := upiService.Transfer("1", "2", 300.00)
+	err := upiService.Transfer(context.Background(), "1", "2", helpers.INR("300.00"))
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "INSUFFICIENT_BALANCE")
+	assert.Equal(t, helpers.INR("100.00"), err.(*models.TransferError).Details["balance"])
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUPITransferService_Transfer_CurrencyMismatch(t *testing.T) {
+	mockRepo := new(mocks.MockAccountRepository)
+	upiService := service.NewUPITransferService(mockRepo)
+
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("100.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+
+	mockRepo.ExpectAccount(fromAccount)
+	mockRepo.ExpectAccount(toAccount)
+
+	err := upiService.Transfer(context.Background(), "1", "2", models.MustParseMoney("10.00", "USD"))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "CURRENCY_MISMATCH")
+	assert.Equal(t, helpers.INR("100.00"), fromAccount.Balance)
+}
+
 func TestUPITransferService_Transfer_AccountNotFound(t *testing.T) {
 	mockRepo := new(mocks.MockAccountRepository)
 	upiService := service.NewUPITransferService(mockRepo)
 
-	mockRepo.On("GetAccountById", "999").Return(nil, models.NewAccountNotFoundError("999"))
+	mockRepo.ExpectAccountError("999", models.NewAccountNotFoundError("999"))
 
-	err := upiService.Transfer("999", "2", 300.00)
+	err := upiService.Transfer(context.Background(), "999", "2", helpers.INR("300.00"))
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "ACCOUNT_NOT_FOUND")
@@ -68,21 +107,22 @@ func TestUPITransferService_Transfer_InvalidInputs(t *testing.T) {
 		name          string
 		fromAccountId string
 		toAccountId   string
-		amount        float64
+		amount        models.Money
 		expectedError string
 	}{
-		{"Negative amount", "1", "2", -100.00, "INVALID_AMOUNT"},
-		{"Zero amount", "1", "2", 0.00, "INVALID_AMOUNT"},
-		{"Same account", "1", "1", 100.00, "SAME_ACCOUNT_TRANSFER"},
-		{"Empty from ID", "", "2", 100.00, "EMPTY_ACCOUNT_ID"},
-		{"Empty to ID", "1", "", 100.00, "EMPTY_ACCOUNT_ID"},
+		{"Negative amount", "1", "2", helpers.INR("-100.00"), "INVALID_AMOUNT"},
+		{"Zero amount", "1", "2", helpers.INR("0.00"), "INVALID_AMOUNT"},
+		{"Missing currency", "1", "2", models.Money{}, "UNKNOWN_CURRENCY"},
+		{"Same account", "1", "1", helpers.INR("100.00"), "SAME_ACCOUNT_TRANSFER"},
+		{"Empty from ID", "", "2", helpers.INR("100.00"), "EMPTY_ACCOUNT_ID"},
+		{"Empty to ID", "1", "", helpers.INR("100.00"), "EMPTY_ACCOUNT_ID"},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockRepo := new(mocks.MockAccountRepository)
 			upiService := service.NewUPITransferService(mockRepo)
-			err := upiService.Transfer(tc.fromAccountId, tc.toAccountId, tc.amount)
+			err := upiService.Transfer(context.Background(), tc.fromAccountId, tc.toAccountId, tc.amount)
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), tc.expectedError)
 		})
@@ -93,12 +133,66 @@ func TestUPITransferService_GetAccountBalance_Success(t *testing.T) {
 	mockRepo := new(mocks.MockAccountRepository)
 	upiService := service.NewUPITransferService(mockRepo)
 
-	account := helpers.CreateTestAccount("1", "Alice", 1000.00)
-	mockRepo.On("GetAccountById", "1").Return(account, nil)
+	account := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	mockRepo.ExpectAccount(account)
 
-	balance, err := upiService.GetAccountBalance("1")
+	balance, err := upiService.GetAccountBalance(context.Background(), "1")
 
 	assert.NoError(t, err)
-	assert.Equal(t, 1000.00, balance)
+	assert.Equal(t, models.AccountBalance{
+		Ledger:    helpers.INR("1000.00"),
+		Held:      helpers.INR("0.00"),
+		Available: helpers.INR("1000.00"),
+	}, balance)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestUPITransferService_Transfer_RepositoryCallsCarryDeadline(t *testing.T) {
+	mockRepo := new(mocks.MockAccountRepository)
+	upiService := service.NewUPITransferService(mockRepo)
+
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	toAccount := helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00"))
+	mockRepo.On("GetAccountById", mocks.ContextWithDeadline(), "1").Return(fromAccount, nil)
+	mockRepo.On("GetAccountById", mocks.ContextWithDeadline(), "2").Return(toAccount, nil)
+	mockRepo.On("UpdateAccount", mocks.ContextWithDeadline(), mock.Anything).Return(nil).Twice()
+
+	// the caller sets no deadline; the service bounds every call itself
+	assert.NoError(t, upiService.Transfer(context.Background(), "1", "2", helpers.INR("10.00")))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUPITransferService_Transfer_TimesOutReadingAccounts(t *testing.T) {
+	mockRepo := new(mocks.MockAccountRepository)
+	upiService := service.NewUPITransferService(mockRepo)
+
+	fromAccount := helpers.CreateTestAccount("1", "Alice", helpers.INR("1000.00"))
+	mockRepo.ExpectAccount(fromAccount)
+	mockRepo.ExpectAccountBlocked("2")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("300.00"))
+
+	assert.Equal(t, "TIMEOUT", models.ErrorCode(err))
+	assert.Equal(t, helpers.INR("1000.00"), fromAccount.GetBalance())
+	mockRepo.AssertNotCalled(t, "UpdateAccount", mock.Anything, mock.Anything)
+	total, success := upiService.GetStats()
+	assert.Equal(t, int64(1), total)
+	assert.Zero(t, success)
+}
+
+func TestUPITransferService_Transfer_CancelledReadingAccounts(t *testing.T) {
+	mockRepo := new(mocks.MockAccountRepository)
+	upiService := service.NewUPITransferService(mockRepo)
+
+	mockRepo.ExpectAccountBlocked("1")
+	mockRepo.ExpectAccount(helpers.CreateTestAccount("2", "Bob", helpers.INR("500.00")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := upiService.Transfer(ctx, "1", "2", helpers.INR("300.00"))
+
+	assert.Equal(t, "CANCELLED", models.ErrorCode(err))
+	mockRepo.AssertNotCalled(t, "UpdateAccount", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,398 @@
+// File: test/unit/webhooks/webhooks_test.go
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"transfer-service/events"
+	"transfer-service/models"
+	"transfer-service/test/helpers"
+	"transfer-service/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+type fakeClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// receiver is a merchant endpoint that answers with the queued status codes,
+// then 200, and keeps every request it verified
+type receiver struct {
+	secret   string
+	statuses []int
+	received []received
+	mutex    sync.Mutex
+}
+
+type received struct {
+	header  http.Header
+	body    []byte
+	payload map[string]interface{}
+	valid   bool
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	got := received{header: r.Header.Clone(), body: body}
+	got.valid = webhooks.Verify(rc.secret, r.Header.Get(webhooks.HeaderTimestamp), r.Header.Get(webhooks.HeaderSignature), body)
+	json.Unmarshal(body, &got.payload)
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	rc.received = append(rc.received, got)
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) Received() []received {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	return append([]received(nil), rc.received...)
+}
+
+func newReceiver(t *testing.T, secret string, statuses ...int) (*receiver, *httptest.Server) {
+	rc := &receiver{secret: secret, statuses: statuses}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+	return rc, server
+}
+
+func newDispatcher(clock *fakeClock, opts ...webhooks.Option) *webhooks.Dispatcher {
+	opts = append([]webhooks.Option{
+		webhooks.WithClock(clock.Now),
+		webhooks.WithRetry(3, time.Minute, 10*time.Minute),
+		webhooks.WithJitter(func() float64 { return 0 }),
+	}, opts...)
+	return webhooks.NewDispatcher(opts...)
+}
+
+func register(t *testing.T, d *webhooks.Dispatcher, accountId, url, secret string) *webhooks.Endpoint {
+	ep, err := d.Register(webhooks.Endpoint{AccountId: accountId, URL: url, Secret: secret})
+	require.NoError(t, err)
+	return ep
+}
+
+func completed(seq int64) events.TransferCompleted {
+	return events.TransferCompleted{
+		Meta:          events.Meta{Sequence: seq, OccurredAt: start},
+		Reference:     "R1",
+		RequestId:     "R1",
+		FromAccountId: "1",
+		ToAccountId:   "2",
+		Amount:        helpers.INR("10.00"),
+		Credit:        helpers.INR("10.00"),
+		Fee:           helpers.INR("0.00"),
+	}
+}
+
+func TestSign_VerifyRejectsTamperedInput(t *testing.T) {
+	body := []byte(`{"type":"TransferCompleted"}`)
+	sig := webhooks.Sign("s3cret", 1700000000, body)
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	assert.True(t, webhooks.Verify("s3cret", "1700000000", sig, body))
+	assert.False(t, webhooks.Verify("other", "1700000000", sig, body))
+	assert.False(t, webhooks.Verify("s3cret", "1700000001", sig, body))
+	assert.False(t, webhooks.Verify("s3cret", "1700000000", sig, []byte(`{"type":"TransferFailed"}`)))
+	assert.False(t, webhooks.Verify("s3cret", "not-a-number", sig, body))
+}
+
+func TestDispatcher_Register_Validates(t *testing.T) {
+	d := webhooks.NewDispatcher()
+	testCases := []struct {
+		name     string
+		endpoint webhooks.Endpoint
+	}{
+		{"missing account", webhooks.Endpoint{URL: "https://merchant.test/hook", Secret: "s"}},
+		{"missing secret", webhooks.Endpoint{AccountId: "1", URL: "https://merchant.test/hook"}},
+		{"relative url", webhooks.Endpoint{AccountId: "1", URL: "/hook", Secret: "s"}},
+		{"unsupported scheme", webhooks.Endpoint{AccountId: "1", URL: "ftp://merchant.test/hook", Secret: "s"}},
+		{"undeliverable event", webhooks.Endpoint{AccountId: "1", URL: "https://merchant.test/hook", Secret: "s",
+			Events: []events.Type{events.TypeAccountBalanceChanged}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := d.Register(tc.endpoint)
+			assert.Equal(t, "INVALID_ENDPOINT", models.ErrorCode(err))
+		})
+	}
+	assert.Empty(t, d.Endpoints(""))
+}
+
+func TestDispatcher_DeliversSignedPayloadToBothAccounts(t *testing.T) {
+	clock := &fakeClock{now: start}
+	d := newDispatcher(clock)
+	sender, senderServer := newReceiver(t, "sender-secret")
+	recipient, recipientServer := newReceiver(t, "recipient-secret")
+	register(t, d, "1", senderServer.URL, "sender-secret")
+	register(t, d, "2", recipientServer.URL, "recipient-secret")
+	register(t, d, "3", recipientServer.URL, "unrelated")
+
+	bus := events.NewBus()
+	bus.RegisterListener(d)
+	bus.Publish(completed(7))
+
+	attempts := d.ProcessDue(context.Background())
+	require.Len(t, attempts, 2)
+	for _, a := range attempts {
+		assert.True(t, a.Success)
+		assert.Equal(t, http.StatusOK, a.StatusCode)
+		assert.Equal(t, 1, a.Number)
+	}
+
+	for _, rc := range []*receiver{sender, recipient} {
+		got := rc.Received()
+		require.Len(t, got, 1)
+		assert.True(t, got[0].valid, "signature must verify with the endpoint's secret")
+		assert.Equal(t, "TransferCompleted", got[0].header.Get(webhooks.HeaderEventType))
+		assert.Equal(t, got[0].payload["deliveryId"], got[0].header.Get(webhooks.HeaderDeliveryId))
+		event := got[0].payload["event"].(map[string]interface{})
+		assert.Equal(t, "R1", event["reference"])
+		assert.Equal(t, float64(7), event["sequence"])
+	}
+	assert.Equal(t, "1", sender.Received()[0].payload["accountId"])
+	assert.Equal(t, "2", recipient.Received()[0].payload["accountId"])
+
+	for _, dl := range d.Deliveries() {
+		assert.Equal(t, webhooks.DeliveryDelivered, dl.Status)
+		assert.Equal(t, int64(7), dl.EventSequence)
+	}
+	assert.Empty(t, d.ProcessDue(context.Background()), "nothing is sent twice")
+}
+
+func TestDispatcher_RepublishedEventIsNotQueuedAgain(t *testing.T) {
+	d := newDispatcher(&fakeClock{now: start})
+	register(t, d, "1", "https://merchant.test/hook", "s")
+
+	d.OnTransferCompleted(completed(7))
+	d.OnTransferCompleted(completed(7))
+	d.OnTransferCompleted(completed(8))
+
+	assert.Len(t, d.Deliveries(), 2)
+}
+
+func TestDispatcher_ForgetsDeliveredHistoryAfterRetention(t *testing.T) {
+	clock := &fakeClock{now: start}
+	d := newDispatcher(clock, webhooks.WithRetention(time.Hour), webhooks.WithRetry(1, 0, 0))
+	_, server := newReceiver(t, "s", http.StatusInternalServerError)
+	register(t, d, "1", server.URL, "s")
+
+	d.OnTransferCompleted(completed(1))
+	d.ProcessDue(context.Background()) // dead-lettered
+	d.OnTransferCompleted(completed(2))
+	delivered := d.ProcessDue(context.Background())
+	require.Len(t, delivered, 1)
+	require.True(t, delivered[0].Success)
+
+	clock.Advance(time.Hour - time.Second)
+	d.OnTransferCompleted(completed(2))
+	assert.Len(t, d.Deliveries(), 2, "a republished event is still recognised")
+
+	clock.Advance(time.Second)
+	d.ProcessDue(context.Background())
+	_, err := d.Delivery(delivered[0].DeliveryId)
+	assert.Equal(t, "DELIVERY_NOT_FOUND", models.ErrorCode(err))
+	assert.Empty(t, d.Attempts(delivered[0].DeliveryId))
+	remaining := d.Deliveries()
+	require.Len(t, remaining, 1, "dead letters wait for a replay")
+	assert.Equal(t, webhooks.DeliveryDeadLettered, remaining[0].Status)
+
+	d.OnTransferCompleted(completed(2))
+	assert.Len(t, d.Deliveries(), 2, "past the retention period an event is queued again")
+}
+
+func TestDispatcher_SendsDueDeliveriesInOrder(t *testing.T) {
+	clock := &fakeClock{now: start}
+	d := newDispatcher(clock)
+	rc, server := newReceiver(t, "s", http.StatusInternalServerError)
+	register(t, d, "1", server.URL, "s")
+
+	d.OnTransferCompleted(completed(1))
+	require.Len(t, d.ProcessDue(context.Background()), 1) // fails, due again in 30s
+	clock.Advance(20 * time.Second)
+	d.OnTransferCompleted(completed(2))
+	clock.Advance(10 * time.Second)
+	d.OnTransferCompleted(completed(3))
+
+	attempts := d.ProcessDue(context.Background())
+	require.Len(t, attempts, 3)
+	var sequences []float64
+	for _, got := range rc.Received()[1:] {
+		sequences = append(sequences, got.payload["event"].(map[string]interface{})["sequence"].(float64))
+	}
+	assert.Equal(t, []float64{2, 1, 3}, sequences, "by when they fell due, then by id")
+}
+
+func TestDispatcher_EndpointOnlyGetsSubscribedEvents(t *testing.T) {
+	d := newDispatcher(&fakeClock{now: start})
+	_, err := d.Register(webhooks.Endpoint{AccountId: "1", URL: "https://merchant.test/hook", Secret: "s",
+		Events: []events.Type{events.TypeTransferFailed}})
+	require.NoError(t, err)
+
+	d.OnTransferCompleted(completed(1))
+	d.OnTransferFailed(events.TransferFailed{Meta: events.Meta{Sequence: 2}, FromAccountId: "1", ToAccountId: "2", ErrorCode: "INSUFFICIENT_BALANCE"})
+
+	deliveries := d.Deliveries()
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, events.TypeTransferFailed, deliveries[0].EventType)
+}
+
+func TestDispatcher_RetriesWithExponentialBackoffAndJitter(t *testing.T) {
+	clock := &fakeClock{now: start}
+	d := newDispatcher(clock, webhooks.WithRetry(5, time.Minute, 3*time.Minute),
+		webhooks.WithJitter(func() float64 { return 0.5 }))
+	rc, server := newReceiver(t, "s", http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable)
+	register(t, d, "1", server.URL, "s")
+	d.OnTransferCompleted(completed(1))
+
+	// equal jitter keeps half the delay and adds half of the rest:
+	// 1m -> 45s, 2m -> 90s, 4m capped at 3m -> 135s
+	wantDelays := []time.Duration{45 * time.Second, 90 * time.Second, 135 * time.Second}
+	for i, want := range wantDelays {
+		attempts := d.ProcessDue(context.Background())
+		require.Len(t, attempts, 1)
+		assert.False(t, attempts[0].Success)
+		dl := d.Deliveries()[0]
+		assert.Equal(t, webhooks.DeliveryPending, dl.Status)
+		assert.Equal(t, i+1, dl.Attempts)
+		assert.Equal(t, clock.Now().Add(want), dl.NextAttemptAt)
+		assert.Contains(t, dl.LastError, "endpoint returned")
+
+		clock.Advance(want - time.Second)
+		assert.Empty(t, d.ProcessDue(context.Background()), "not due before the backoff ends")
+		clock.Advance(time.Second)
+	}
+
+	attempts := d.ProcessDue(context.Background())
+	require.Len(t, attempts, 1)
+	assert.True(t, attempts[0].Success)
+	assert.Equal(t, webhooks.DeliveryDelivered, d.Deliveries()[0].Status)
+
+	log := d.Attempts(attempts[0].DeliveryId)
+	require.Len(t, log, 4)
+	assert.Equal(t, []int{500, 502, 503, 200}, []int{log[0].StatusCode, log[1].StatusCode, log[2].StatusCode, log[3].StatusCode})
+	for i, a := range log {
+		assert.Equal(t, i+1, a.Number)
+	}
+	assert.Len(t, rc.Received(), 4)
+}
+
+func TestDispatcher_DeadLettersAfterMaxAttemptsAndReplays(t *testing.T) {
+	clock := &fakeClock{now: start}
+	d := newDispatcher(clock)
+	rc, server := newReceiver(t, "s", 500, 500, 500)
+	register(t, d, "1", server.URL, "s")
+	d.OnTransferCompleted(completed(1))
+
+	for i := 0; i < 3; i++ {
+		d.ProcessDue(context.Background())
+		clock.Advance(time.Hour)
+	}
+	dead := d.DeadLetters()
+	require.Len(t, dead, 1)
+	assert.Equal(t, webhooks.DeliveryDeadLettered, dead[0].Status)
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Empty(t, d.ProcessDue(context.Background()), "dead letters are not retried")
+
+	replayed, err := d.Replay(dead[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.DeliveryPending, replayed.Status)
+	assert.Equal(t, 0, replayed.Attempts)
+	assert.Equal(t, 1, replayed.Replays)
+
+	_, err = d.Replay(dead[0].ID)
+	assert.Equal(t, "INVALID_DELIVERY_STATE", models.ErrorCode(err), "already queued")
+
+	attempts := d.ProcessDue(context.Background())
+	require.Len(t, attempts, 1)
+	assert.True(t, attempts[0].Success)
+	assert.Equal(t, 4, attempts[0].Number)
+	assert.Empty(t, d.DeadLetters())
+
+	got := rc.Received()
+	require.Len(t, got, 4)
+	assert.Equal(t, got[0].body, got[3].body, "a replay sends the same payload")
+	assert.Equal(t, got[0].header.Get(webhooks.HeaderDeliveryId), got[3].header.Get(webhooks.HeaderDeliveryId))
+	assert.True(t, got[3].valid)
+
+	_, err = d.Replay("WHD-99999999")
+	assert.Equal(t, "DELIVERY_NOT_FOUND", models.ErrorCode(err))
+}
+
+func TestDispatcher_UnreachableEndpointIsLoggedWithoutStatus(t *testing.T) {
+	clock := &fakeClock{now: start}
+	d := newDispatcher(clock, webhooks.WithRetry(1, 0, 0))
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	register(t, d, "1", url, "s")
+	d.OnTransferCompleted(completed(1))
+
+	attempts := d.ProcessDue(context.Background())
+	require.Len(t, attempts, 1)
+	assert.Zero(t, attempts[0].StatusCode)
+	assert.NotEmpty(t, attempts[0].Error)
+	assert.Len(t, d.DeadLetters(), 1)
+}
+
+func TestDispatcher_UnregisteredEndpointDeadLettersItsDeliveries(t *testing.T) {
+	d := newDispatcher(&fakeClock{now: start})
+	ep := register(t, d, "1", "https://merchant.test/hook", "s")
+	d.OnTransferCompleted(completed(1))
+
+	require.NoError(t, d.Unregister(ep.ID))
+	assert.Equal(t, "ENDPOINT_NOT_FOUND", models.ErrorCode(d.Unregister(ep.ID)))
+
+	assert.Empty(t, d.ProcessDue(context.Background()))
+	dead := d.DeadLetters()
+	require.Len(t, dead, 1)
+	assert.Equal(t, "endpoint unregistered", dead[0].LastError)
+
+	_, err := d.Replay(dead[0].ID)
+	assert.Equal(t, "ENDPOINT_NOT_FOUND", models.ErrorCode(err))
+}
+
+func TestDispatcher_RunDeliversAsSoonAsQueued(t *testing.T) {
+	rc, server := newReceiver(t, "s")
+	d := webhooks.NewDispatcher(webhooks.WithClient(server.Client()))
+	register(t, d, "1", server.URL, "s")
+
+	bus := events.NewBus(events.WithAsync(8))
+	bus.RegisterListener(d)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx, time.Hour) }()
+
+	bus.Publish(completed(1))
+	bus.Close()
+	require.Eventually(t, func() bool { return len(rc.Received()) == 1 }, 2*time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,532 @@
+// File: webhooks/dispatcher.go
+package webhooks
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"transfer-service/events"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultBackoff     = 30 * time.Second
+	defaultMaxBackoff  = time.Hour
+	defaultTimeout     = 10 * time.Second
+	defaultRetention   = 24 * time.Hour
+
+	// maxResponseBody bounds how much of a response is read before the
+	// connection is reused; the body itself is never looked at
+	maxResponseBody = 64 << 10
+)
+
+// Dispatcher queues webhook deliveries from transfer events and sends them.
+// Register it with the event bus; ProcessDue does one sending pass and Run
+// calls it on a ticker and whenever something new is queued. Endpoints,
+// deliveries and their attempt logs are kept in memory; delivered ones are
+// dropped once the retention period has passed, dead letters only when a
+// replay delivers them.
+type Dispatcher struct {
+	events.NopListener
+
+	client      *http.Client
+	now         func() time.Time
+	jitter      func() float64
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	retention   time.Duration
+
+	endpoints     map[string]*Endpoint
+	endpointOrder []string
+	deliveries    map[string]*Delivery
+	order         []string             // delivery ids in queue order, compacted as they are dropped
+	due           dueQueue             // pending deliveries by when they fall due
+	delivered     []stamped            // delivery ids by when they were delivered
+	queued        map[string]time.Time // endpoint id + event sequence, so a republished event is not sent twice
+	queuedOrder   []stamped            // keys of queued by when they were added
+	attempts      map[string][]Attempt
+	endpointSeq   int64
+	deliverySeq   int64
+	mutex         sync.Mutex
+	runMutex      sync.Mutex // one ProcessDue at a time
+	wake          chan struct{}
+}
+
+type Option func(*Dispatcher)
+
+// WithClient replaces the HTTP client deliveries are sent with; the default
+// one times out after ten seconds
+func WithClient(client *http.Client) Option {
+	return func(d *Dispatcher) { d.client = client }
+}
+
+// WithClock replaces time.Now so tests can move time forward
+func WithClock(now func() time.Time) Option {
+	return func(d *Dispatcher) { d.now = now }
+}
+
+// WithRetry sets how many attempts a delivery gets before it is
+// dead-lettered, the delay before the first retry, which doubles for each one
+// after that, and the cap on that delay
+func WithRetry(maxAttempts int, backoff, maxBackoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		if maxAttempts > 0 {
+			d.maxAttempts = maxAttempts
+		}
+		if backoff > 0 {
+			d.backoff = backoff
+		}
+		if maxBackoff > 0 {
+			d.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithRetention sets how long a delivered delivery and its attempts are kept,
+// and for how long a republished event is recognised and not queued again
+func WithRetention(retention time.Duration) Option {
+	return func(d *Dispatcher) {
+		if retention > 0 {
+			d.retention = retention
+		}
+	}
+}
+
+// WithJitter replaces the source of randomness in retry delays. It must
+// return values in [0, 1); a constant makes delays predictable in tests.
+func WithJitter(jitter func() float64) Option {
+	return func(d *Dispatcher) { d.jitter = jitter }
+}
+
+func NewDispatcher(opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		client:      &http.Client{Timeout: defaultTimeout},
+		now:         time.Now,
+		jitter:      rand.Float64,
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		retention:   defaultRetention,
+		endpoints:   make(map[string]*Endpoint),
+		deliveries:  make(map[string]*Delivery),
+		queued:      make(map[string]time.Time),
+		attempts:    make(map[string][]Attempt),
+		wake:        make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Register validates and stores an endpoint, assigning its id
+func (d *Dispatcher) Register(ep Endpoint) (*Endpoint, error) {
+	if err := ep.validate(); err != nil {
+		return nil, err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.endpointSeq++
+	stored := ep
+	stored.ID = fmt.Sprintf("WH-%06d", d.endpointSeq)
+	stored.Events = append([]events.Type(nil), ep.Events...)
+	stored.CreatedAt = d.now()
+	d.endpoints[stored.ID] = &stored
+	d.endpointOrder = append(d.endpointOrder, stored.ID)
+
+	copied := stored
+	return &copied, nil
+}
+
+// Unregister removes an endpoint. Its pending deliveries are dead-lettered
+// when they next fall due.
+func (d *Dispatcher) Unregister(id string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if _, ok := d.endpoints[id]; !ok {
+		return NewEndpointNotFoundError(id)
+	}
+	delete(d.endpoints, id)
+	for i, eid := range d.endpointOrder {
+		if eid == id {
+			d.endpointOrder = append(d.endpointOrder[:i:i], d.endpointOrder[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Endpoints returns the endpoints registered for accountId, or every endpoint
+// when accountId is empty, in the order they were registered
+func (d *Dispatcher) Endpoints(accountId string) []*Endpoint {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var list []*Endpoint
+	for _, id := range d.endpointOrder {
+		if ep := d.endpoints[id]; accountId == "" || ep.AccountId == accountId {
+			copied := *ep
+			list = append(list, &copied)
+		}
+	}
+	return list
+}
+
+func (d *Dispatcher) Delivery(id string) (*Delivery, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	dl, ok := d.deliveries[id]
+	if !ok {
+		return nil, NewDeliveryNotFoundError(id)
+	}
+	copied := *dl
+	return &copied, nil
+}
+
+// Deliveries returns every delivery in the order it was queued
+func (d *Dispatcher) Deliveries() []*Delivery {
+	return d.list(func(*Delivery) bool { return true })
+}
+
+// DeadLetters returns the deliveries that ran out of attempts, oldest first
+func (d *Dispatcher) DeadLetters() []*Delivery {
+	return d.list(func(dl *Delivery) bool { return dl.Status == DeliveryDeadLettered })
+}
+
+func (d *Dispatcher) list(keep func(*Delivery) bool) []*Delivery {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var list []*Delivery
+	for _, id := range d.order {
+		if dl, ok := d.deliveries[id]; ok && keep(dl) {
+			copied := *dl
+			list = append(list, &copied)
+		}
+	}
+	return list
+}
+
+// Attempts returns the attempt log of one delivery, oldest first
+func (d *Dispatcher) Attempts(deliveryId string) []Attempt {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]Attempt(nil), d.attempts[deliveryId]...)
+}
+
+// Replay queues a delivered or dead-lettered delivery again, with the same
+// id and payload and a fresh set of attempts. It is sent on the next pass.
+func (d *Dispatcher) Replay(id string) (*Delivery, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	dl, ok := d.deliveries[id]
+	if !ok {
+		return nil, NewDeliveryNotFoundError(id)
+	}
+	if dl.Status == DeliveryPending {
+		return nil, NewInvalidDeliveryStateError(id, dl.Status)
+	}
+	if _, ok := d.endpoints[dl.EndpointId]; !ok {
+		return nil, NewEndpointNotFoundError(dl.EndpointId)
+	}
+	now := d.now()
+	dl.Status, dl.Attempts, dl.LastError = DeliveryPending, 0, ""
+	dl.Replays++
+	dl.NextAttemptAt, dl.UpdatedAt = now, now
+	d.schedule(dl)
+	d.signal()
+
+	copied := *dl
+	return &copied, nil
+}
+
+// OnTransferCompleted queues a delivery to the endpoints of both accounts
+func (d *Dispatcher) OnTransferCompleted(e events.TransferCompleted) {
+	d.enqueue(e, e.FromAccountId, e.ToAccountId)
+}
+
+// OnTransferFailed queues a delivery to the endpoints of both accounts
+func (d *Dispatcher) OnTransferFailed(e events.TransferFailed) {
+	d.enqueue(e, e.FromAccountId, e.ToAccountId)
+}
+
+// enqueue only records deliveries, so it never holds up the bus
+func (d *Dispatcher) enqueue(e events.Event, accountIds ...string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.now()
+	d.pruneLocked(now)
+	seq := e.EventMeta().Sequence
+	for _, accountId := range accountIds {
+		for _, epId := range d.endpointOrder {
+			ep := d.endpoints[epId]
+			if ep.AccountId != accountId || !ep.wants(e.EventType()) {
+				continue
+			}
+			key := epId + "/" + strconv.FormatInt(seq, 10)
+			if seq != 0 {
+				if _, ok := d.queued[key]; ok {
+					continue
+				}
+				d.queued[key] = now
+				d.queuedOrder = append(d.queuedOrder, stamped{key: key, at: now})
+			}
+
+			d.deliverySeq++
+			id := fmt.Sprintf("WHD-%08d", d.deliverySeq)
+			payload, err := json.Marshal(Payload{DeliveryId: id, Type: e.EventType(), AccountId: accountId, Event: e})
+			if err != nil {
+				fmt.Printf("[WEBHOOKS] cannot encode %s for %s: %v\n", e.EventType(), epId, err)
+				continue
+			}
+			dl := &Delivery{
+				ID:            id,
+				EndpointId:    epId,
+				AccountId:     accountId,
+				EventType:     e.EventType(),
+				EventSequence: seq,
+				Payload:       payload,
+				Status:        DeliveryPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			d.deliveries[id] = dl
+			d.order = append(d.order, id)
+			d.schedule(dl)
+			d.signal()
+		}
+	}
+}
+
+// schedule puts a pending delivery in the due queue; the caller holds the
+// mutex
+func (d *Dispatcher) schedule(dl *Delivery) {
+	heap.Push(&d.due, dueEntry{at: dl.NextAttemptAt, id: dl.ID})
+}
+
+// pruneLocked drops deliveries delivered longer than the retention period
+// ago, with their attempts, and forgets the events queued before it
+func (d *Dispatcher) pruneLocked(now time.Time) {
+	cutoff := now.Add(-d.retention)
+	for len(d.delivered) > 0 && !d.delivered[0].at.After(cutoff) {
+		id := d.delivered[0].key
+		d.delivered = d.delivered[1:]
+		// a delivery replayed since is delivered again later, or pending
+		if dl, ok := d.deliveries[id]; ok && dl.Status == DeliveryDelivered && !dl.UpdatedAt.After(cutoff) {
+			delete(d.deliveries, id)
+			delete(d.attempts, id)
+		}
+	}
+	for len(d.queuedOrder) > 0 && !d.queuedOrder[0].at.After(cutoff) {
+		delete(d.queued, d.queuedOrder[0].key)
+		d.queuedOrder = d.queuedOrder[1:]
+	}
+	if len(d.order) > 2*len(d.deliveries) {
+		kept := make([]string, 0, len(d.deliveries))
+		for _, id := range d.order {
+			if _, ok := d.deliveries[id]; ok {
+				kept = append(kept, id)
+			}
+		}
+		d.order = kept
+	}
+}
+
+// signal wakes Run without blocking; the caller holds the mutex
+func (d *Dispatcher) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ProcessDue sends every delivery that is due, oldest first, and returns the
+// attempts it made. A delivery that fails is not retried within the same pass.
+func (d *Dispatcher) ProcessDue(ctx context.Context) []Attempt {
+	d.runMutex.Lock()
+	defer d.runMutex.Unlock()
+
+	var made []Attempt
+	for ctx.Err() == nil {
+		job, ok := d.nextDue()
+		if !ok {
+			break
+		}
+		made = append(made, d.finish(job, d.send(ctx, job)))
+	}
+	return made
+}
+
+// Run calls ProcessDue every interval, and as soon as a delivery is queued or
+// replayed, until ctx ends
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		d.ProcessDue(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// dueDelivery is a snapshot of the delivery being sent, taken under the lock
+type dueDelivery struct {
+	deliveryId string
+	eventType  events.Type
+	endpoint   Endpoint
+	payload    []byte
+	number     int
+	attemptAt  time.Time
+}
+
+func (d *Dispatcher) nextDue() (dueDelivery, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.now()
+	d.pruneLocked(now)
+	for d.due.Len() > 0 && !d.due[0].at.After(now) {
+		entry := heap.Pop(&d.due).(dueEntry)
+		dl, ok := d.deliveries[entry.id]
+		if !ok || dl.Status != DeliveryPending || !dl.NextAttemptAt.Equal(entry.at) {
+			continue // dropped or rescheduled since it was queued
+		}
+		ep, ok := d.endpoints[dl.EndpointId]
+		if !ok {
+			dl.Status, dl.NextAttemptAt, dl.UpdatedAt = DeliveryDeadLettered, time.Time{}, now
+			dl.LastError = "endpoint unregistered"
+			continue
+		}
+		return dueDelivery{
+			deliveryId: dl.ID,
+			eventType:  dl.EventType,
+			endpoint:   *ep,
+			payload:    dl.Payload,
+			number:     len(d.attempts[dl.ID]) + 1,
+			attemptAt:  now,
+		}, true
+	}
+	return dueDelivery{}, false
+}
+
+// send makes one signed POST; any 2xx response counts as delivered
+func (d *Dispatcher) send(ctx context.Context, job dueDelivery) (attempt Attempt) {
+	attempt = Attempt{DeliveryId: job.deliveryId, EndpointId: job.endpoint.ID, Number: job.number, At: job.attemptAt}
+	started := time.Now()
+	defer func() { attempt.Duration = time.Since(started) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.endpoint.URL, bytes.NewReader(job.payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := job.attemptAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryId, job.deliveryId)
+	req.Header.Set(HeaderEventType, string(job.eventType))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(job.endpoint.Secret, timestamp, job.payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+	resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("endpoint returned %s", resp.Status)
+		return attempt
+	}
+	attempt.Success = true
+	return attempt
+}
+
+// finish logs an attempt and moves the delivery on to delivered, its next
+// retry or the dead-letter store
+func (d *Dispatcher) finish(job dueDelivery, attempt Attempt) Attempt {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.now()
+	dl := d.deliveries[job.deliveryId]
+	dl.Attempts++
+	dl.UpdatedAt = now
+	switch {
+	case attempt.Success:
+		dl.Status, dl.NextAttemptAt, dl.LastError = DeliveryDelivered, time.Time{}, ""
+		d.delivered = append(d.delivered, stamped{key: dl.ID, at: now})
+	case dl.Attempts >= d.maxAttempts:
+		dl.Status, dl.NextAttemptAt, dl.LastError = DeliveryDeadLettered, time.Time{}, attempt.Error
+		fmt.Printf("[WEBHOOKS] %s to %s dead-lettered after %d attempts: %s\n", dl.ID, job.endpoint.URL, dl.Attempts, attempt.Error)
+	default:
+		dl.NextAttemptAt, dl.LastError = now.Add(d.delay(dl.Attempts)), attempt.Error
+		d.schedule(dl)
+	}
+	d.attempts[dl.ID] = append(d.attempts[dl.ID], attempt)
+	return attempt
+}
+
+// delay is the wait after the given failed attempt: the backoff doubled per
+// attempt and capped, with "equal jitter" so that at least half of it is kept
+// and retries from many deliveries do not arrive in lockstep
+func (d *Dispatcher) delay(attempt int) time.Duration {
+	delay := d.maxBackoff
+	if shift := attempt - 1; shift < 32 && d.backoff<<shift < d.maxBackoff {
+		delay = d.backoff << shift
+	}
+	half := delay / 2
+	return half + time.Duration(d.jitter()*float64(delay-half))
+}
+
+// stamped is a key with the time it was recorded, kept oldest first
+type stamped struct {
+	key string
+	at  time.Time
+}
+
+// dueEntry is a pending delivery in the due queue
+type dueEntry struct {
+	at time.Time
+	id string
+}
+
+// dueQueue is a heap of pending deliveries, earliest due first and by id
+// among those due together
+type dueQueue []dueEntry
+
+func (q dueQueue) Len() int { return len(q) }
+
+func (q dueQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].id < q[j].id
+}
+
+func (q dueQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *dueQueue) Push(x any) { *q = append(*q, x.(dueEntry)) }
+
+func (q *dueQueue) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
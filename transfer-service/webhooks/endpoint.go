@@ -0,0 +1,111 @@
+// File: webhooks/endpoint.go
+
+// Package webhooks calls merchants back over HTTP when a transfer touching
+// one of their accounts completes or fails. A Dispatcher listens on the event
+// bus, queues a signed delivery for every endpoint registered to an account
+// the transfer touched, and sends them in the background, retrying with
+// backoff and dead-lettering the ones that keep failing.
+package webhooks
+
+import (
+	"net/url"
+	"time"
+	"transfer-service/events"
+)
+
+// Endpoint is a merchant URL registered for one account. Secret keys the
+// HMAC signature of every delivery; Events narrows which transfer outcomes
+// are sent and defaults to both.
+type Endpoint struct {
+	ID        string        `json:"id"`
+	AccountId string        `json:"accountId"`
+	URL       string        `json:"url"`
+	Secret    string        `json:"-"`
+	Events    []events.Type `json:"events,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// deliverable are the event types an endpoint can subscribe to
+var deliverable = map[events.Type]bool{
+	events.TypeTransferCompleted: true,
+	events.TypeTransferFailed:    true,
+}
+
+func (e *Endpoint) validate() error {
+	if e.AccountId == "" {
+		return NewInvalidEndpointError("account id is required")
+	}
+	if e.Secret == "" {
+		return NewInvalidEndpointError("secret is required")
+	}
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewInvalidEndpointError("url must be an absolute http or https URL")
+	}
+	for _, t := range e.Events {
+		if !deliverable[t] {
+			return NewInvalidEndpointError("cannot subscribe to " + string(t))
+		}
+	}
+	return nil
+}
+
+func (e *Endpoint) wants(t events.Type) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, w := range e.Events {
+		if w == t {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending      DeliveryStatus = "PENDING"
+	DeliveryDelivered    DeliveryStatus = "DELIVERED"
+	DeliveryDeadLettered DeliveryStatus = "DEAD_LETTERED"
+)
+
+// Delivery is one event on its way to one endpoint. Attempts counts the tries
+// since it was queued or last replayed; a delivery is dead-lettered once that
+// reaches the dispatcher's limit.
+type Delivery struct {
+	ID            string         `json:"id"`
+	EndpointId    string         `json:"endpointId"`
+	AccountId     string         `json:"accountId"`
+	EventType     events.Type    `json:"eventType"`
+	EventSequence int64          `json:"eventSequence,omitempty"`
+	Payload       []byte         `json:"payload"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	Replays       int            `json:"replays"`
+	NextAttemptAt time.Time      `json:"nextAttemptAt,omitempty"`
+	LastError     string         `json:"lastError,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+}
+
+// Attempt is the log entry for one HTTP call. Number counts every attempt of
+// the delivery, replays included. StatusCode is 0 when no response came back.
+type Attempt struct {
+	DeliveryId string        `json:"deliveryId"`
+	EndpointId string        `json:"endpointId"`
+	Number     int           `json:"number"`
+	At         time.Time     `json:"at"`
+	StatusCode int           `json:"statusCode,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	Success    bool          `json:"success"`
+}
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	DeliveryId string       `json:"deliveryId"`
+	Type       events.Type  `json:"type"`
+	AccountId  string       `json:"accountId"`
+	Event      events.Event `json:"event"`
+}
@@ -0,0 +1,39 @@
+// File: webhooks/errors.go
+package webhooks
+
+import (
+	"fmt"
+	"transfer-service/models"
+)
+
+func NewInvalidEndpointError(reason string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "INVALID_ENDPOINT",
+		Message: "Invalid webhook endpoint: " + reason,
+		Details: map[string]interface{}{"reason": reason},
+	}
+}
+
+func NewEndpointNotFoundError(id string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "ENDPOINT_NOT_FOUND",
+		Message: fmt.Sprintf("Webhook endpoint %s not found", id),
+		Details: map[string]interface{}{"endpointId": id},
+	}
+}
+
+func NewDeliveryNotFoundError(id string) *models.TransferError {
+	return &models.TransferError{
+		Code:    "DELIVERY_NOT_FOUND",
+		Message: fmt.Sprintf("Webhook delivery %s not found", id),
+		Details: map[string]interface{}{"deliveryId": id},
+	}
+}
+
+func NewInvalidDeliveryStateError(id string, status DeliveryStatus) *models.TransferError {
+	return &models.TransferError{
+		Code:    "INVALID_DELIVERY_STATE",
+		Message: fmt.Sprintf("Cannot replay delivery %s while it is %s", id, status),
+		Details: map[string]interface{}{"deliveryId": id, "status": status},
+	}
+}